@@ -25,9 +25,12 @@ const COLUMNS: TaskStatus[] = ['todo', 'in-progress', 'done'];
 export function TaskBoard() {
   const {
     tasks,
+    nextCursor,
     isLoading,
+    isLoadingMore,
     error,
     fetchTasks,
+    loadMoreTasks,
     updateTaskStatus,
     isModalOpen,
     openModal,
@@ -145,6 +148,19 @@ export function TaskBoard() {
           ))}
         </div>
 
+        {nextCursor && (
+          <div className="flex justify-center mt-6">
+            <button
+              onClick={() => loadMoreTasks()}
+              disabled={isLoadingMore}
+              className="flex items-center gap-2 text-brand-600 hover:text-brand-700 dark:text-brand-400 dark:hover:text-brand-300 font-medium disabled:opacity-50"
+            >
+              {isLoadingMore && <Loader2 className="w-4 h-4 animate-spin" />}
+              Load more tasks
+            </button>
+          </div>
+        )}
+
         {/* Drag Overlay */}
         <DragOverlay>
           {activeTask && <TaskCard task={activeTask} isDragging />}
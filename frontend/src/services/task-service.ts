@@ -1,10 +1,27 @@
 import { apiClient } from '@/lib/api-client';
 import { API_CONFIG } from '@/lib/config';
-import { Task, CreateTaskRequest, UpdateTaskRequest } from '@/types';
+import {
+  Task,
+  CreateTaskRequest,
+  UpdateTaskRequest,
+  PaginatedResponse,
+  TaskListParams,
+} from '@/types';
+
+function toQueryString(params: TaskListParams): string {
+  const query = new URLSearchParams();
+  Object.entries(params).forEach(([key, value]) => {
+    if (value === undefined || value === '') return;
+    query.set(key, Array.isArray(value) ? value.join(',') : String(value));
+  });
+  const qs = query.toString();
+  return qs ? `?${qs}` : '';
+}
 
 export const taskService = {
-  async listTasks(): Promise<Task[]> {
-    return apiClient.get<Task[]>(API_CONFIG.TASKS.LIST);
+  // Returns one page of tasks; pass its next_cursor as cursor for the next
+  async listTasks(params: TaskListParams = {}): Promise<PaginatedResponse<Task>> {
+    return apiClient.get<PaginatedResponse<Task>>(`${API_CONFIG.TASKS.LIST}${toQueryString(params)}`);
   },
 
   async getTask(id: string): Promise<Task> {
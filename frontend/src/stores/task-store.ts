@@ -5,9 +5,15 @@ import { Task, TaskStatus, CreateTaskRequest, UpdateTaskRequest } from '@/types'
 import { taskService } from '@/services/task-service';
 import { generateTempId } from '@/lib/utils';
 
+// Tasks fetched per page; the board loads more on request
+const TASK_PAGE_SIZE = 50;
+
 interface TaskState {
   tasks: Task[];
+  // Cursor of the next page, or null once every task is loaded
+  nextCursor: string | null;
   isLoading: boolean;
+  isLoadingMore: boolean;
   error: string | null;
   selectedTask: Task | null;
   isModalOpen: boolean;
@@ -16,6 +22,7 @@ interface TaskState {
   
   // Actions
   fetchTasks: () => Promise<void>;
+  loadMoreTasks: () => Promise<void>;
   createTask: (task: CreateTaskRequest) => Promise<Task>;
   updateTask: (id: string, updates: UpdateTaskRequest) => Promise<Task>;
   updateTaskStatus: (id: string, status: TaskStatus) => Promise<void>;
@@ -36,7 +43,9 @@ interface TaskState {
 
 export const useTaskStore = create<TaskState>((set, get) => ({
   tasks: [],
+  nextCursor: null,
   isLoading: false,
+  isLoadingMore: false,
   error: null,
   selectedTask: null,
   isModalOpen: false,
@@ -46,8 +55,8 @@ export const useTaskStore = create<TaskState>((set, get) => ({
   fetchTasks: async () => {
     set({ isLoading: true, error: null });
     try {
-      const tasks = await taskService.listTasks();
-      set({ tasks, isLoading: false });
+      const page = await taskService.listTasks({ limit: TASK_PAGE_SIZE });
+      set({ tasks: page.data, nextCursor: page.next_cursor ?? null, isLoading: false });
     } catch (err) {
       const error = err as { message?: string };
       set({
@@ -57,6 +66,31 @@ export const useTaskStore = create<TaskState>((set, get) => ({
     }
   },
 
+  loadMoreTasks: async () => {
+    const { nextCursor, isLoadingMore } = get();
+    if (!nextCursor || isLoadingMore) return;
+
+    set({ isLoadingMore: true, error: null });
+    try {
+      const page = await taskService.listTasks({ limit: TASK_PAGE_SIZE, cursor: nextCursor });
+      set((state) => {
+        // Tasks created since the first page may already be in the list
+        const known = new Set(state.tasks.map((t) => t.id));
+        return {
+          tasks: [...state.tasks, ...page.data.filter((t) => !known.has(t.id))],
+          nextCursor: page.next_cursor ?? null,
+          isLoadingMore: false,
+        };
+      });
+    } catch (err) {
+      const error = err as { message?: string };
+      set({
+        isLoadingMore: false,
+        error: error.message || 'Failed to fetch tasks',
+      });
+    }
+  },
+
   createTask: async (taskData: CreateTaskRequest) => {
     set({ error: null });
     
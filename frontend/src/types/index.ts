@@ -3,37 +3,226 @@ export interface User {
   id: string;
   email: string;
   name: string;
+  email_verified_at: string | null;
   created_at: string;
   updated_at: string;
 }
 
 // Task types
-export type TaskStatus = 'todo' | 'in-progress' | 'done';
+// Statuses are defined per project; these are the keys of the default workflow
+export type TaskStatus = 'todo' | 'in-progress' | 'done' | (string & {});
+export type StatusCategory = 'not-started' | 'active' | 'done';
 export type TaskPriority = 'high' | 'medium' | 'low';
 
+export interface Label {
+  id: string;
+  user_id: string;
+  name: string;
+  color: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface Project {
+  id: string;
+  user_id: string;
+  name: string;
+  description?: string;
+  color: string;
+  archived: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface WorkflowStatus {
+  key: TaskStatus;
+  name: string;
+  category: StatusCategory;
+  position: number;
+}
+
+export interface WorkflowTransition {
+  from: TaskStatus;
+  to: TaskStatus;
+}
+
+// An empty transitions list allows every move between statuses
+export interface Workflow {
+  statuses: WorkflowStatus[];
+  transitions: WorkflowTransition[];
+}
+
+export interface TaskProgress {
+  checklist_done: number;
+  checklist_total: number;
+  subtasks_done: number;
+  subtasks_total: number;
+  done: number;
+  total: number;
+}
+
+export interface ChecklistItem {
+  id: string;
+  task_id: string;
+  text: string;
+  done: boolean;
+  position: number;
+  created_at: string;
+  updated_at: string;
+}
+
 export interface Task {
   id: string;
   user_id: string;
+  project_id?: string;
+  parent_id?: string;
   title: string;
   description?: string;
   status: TaskStatus;
   priority?: TaskPriority;
   due_date?: string;
   completed_at?: string;
+  recurrence?: string;
+  recurrence_timezone?: string;
+  recurrence_start?: string;
+  occurrence?: number;
+  created_at: string;
+  updated_at: string;
+  labels?: Label[];
+  progress?: TaskProgress;
+  comment_count?: number;
+  deleted_at?: string;
+  // Board order within the task's status; compare as plain strings
+  rank: string;
+  version: number;
+  // Sent back as If-Match on writes; a 412 response carries the current task.
+  // Writes return the new one in the ETag header instead.
+  etag?: string;
+}
+
+export interface Comment {
+  id: string;
+  task_id: string;
+  user_id: string;
+  body: string;
+  edited_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export type TaskEventAction = 'created' | 'updated' | 'completed' | 'deleted' | 'restored' | 'purged';
+
+// One entry in GET /tasks/:id/history. Field-level events carry the old and
+// new value as strings; created/deleted events have no field.
+export interface TaskEvent {
+  id: string;
+  task_id: string;
+  user_id: string;
+  action: TaskEventAction;
+  field?: string;
+  old_value: string | null;
+  new_value: string | null;
+  created_at: string;
+}
+
+export interface Attachment {
+  id: string;
+  task_id: string;
+  user_id: string;
+  filename: string;
+  content_type: string;
+  size: number;
+  created_at: string;
+}
+
+export interface Reminder {
+  id: string;
+  task_id: string;
+  user_id: string;
+  offset_minutes: number;
+  remind_at?: string;
+  sent_at?: string;
+  last_error?: string;
+  created_at: string;
+}
+
+// Data of a /tasks/stream event; the SSE event name is its type
+export type TaskStreamEventType = 'created' | 'updated' | 'deleted';
+
+export interface TaskStreamEvent {
+  id: number;
+  task_id: string;
+  action: 'created' | 'updated' | 'completed' | 'deleted' | 'restored' | 'purged';
+  // Omitted for deletions and for tasks that no longer exist
+  task?: Task;
+}
+
+export type WebhookEventType =
+  | 'task.created'
+  | 'task.updated'
+  | 'task.completed'
+  | 'task.deleted'
+  | 'task.restored'
+  | 'task.purged';
+
+export interface Webhook {
+  id: string;
+  user_id: string;
+  url: string;
+  events: WebhookEventType[];
+  active: boolean;
   created_at: string;
   updated_at: string;
 }
 
+// Returned once, when the webhook is created
+export interface CreatedWebhook extends Webhook {
+  secret: string;
+}
+
+export interface WebhookDelivery {
+  id: string;
+  webhook_id: string;
+  event_id: string;
+  event_type: WebhookEventType | 'webhook.test';
+  payload: unknown;
+  status: 'pending' | 'succeeded' | 'failed';
+  attempts: number;
+  response_status?: number;
+  last_error?: string;
+  next_attempt_at: string;
+  delivered_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+// The caller's iCalendar feed, from GET /calendar/feed
+export interface CalendarFeed {
+  id: string;
+  user_id: string;
+  last_accessed_at?: string;
+  created_at: string;
+}
+
+// Returned once, when the feed is created or its token replaced. Calendar
+// apps subscribe to path, relative to the API.
+export interface CreatedCalendarFeed extends CalendarFeed {
+  token: string;
+  path: string;
+}
+
 // Auth types
 export interface LoginCredentials {
   email: string;
   password: string;
+  device_name?: string;
 }
 
 export interface SignupCredentials {
   email: string;
   password: string;
   name: string;
+  device_name?: string;
 }
 
 export interface AuthTokens {
@@ -57,13 +246,51 @@ export interface RefreshTokenResponse {
   expires_in: number;
 }
 
+// Returned by signup instead of tokens when the address must be verified first
+export interface PendingVerificationResponse {
+  message: string;
+  user: User;
+}
+
+export interface VerifyEmailRequest {
+  token: string;
+}
+
+export interface ResendVerificationRequest {
+  email: string;
+}
+
+export interface ForgotPasswordRequest {
+  email: string;
+}
+
+export interface ResetPasswordRequest {
+  token: string;
+  password: string;
+}
+
+// A device the user is logged in on
+export interface Session {
+  id: string;
+  name: string;
+  user_agent: string;
+  ip_address: string;
+  last_used_at: string;
+  expires_at: string;
+  current: boolean;
+}
+
 // Task API types
 export interface CreateTaskRequest {
   title: string;
+  project_id?: string;
+  parent_id?: string;
   description?: string;
   status?: TaskStatus;
   priority?: TaskPriority;
   due_date?: string;
+  recurrence?: string;
+  recurrence_timezone?: string;
 }
 
 export interface UpdateTaskRequest {
@@ -73,6 +300,84 @@ export interface UpdateTaskRequest {
   priority?: TaskPriority;
   due_date?: string;
   completed_at?: string;
+  // An empty string stops the task from recurring
+  recurrence?: string;
+  recurrence_timezone?: string;
+}
+
+export interface CompleteTaskResponse extends Task {
+  next_occurrence?: Task;
+}
+
+// POST /tasks/:id/move, after a drag and drop on the board. before_id is the
+// task that ends up directly above, after_id the one directly below; leave
+// one out at the top or bottom of a column. status defaults to the current one.
+export interface RepositionTaskRequest {
+  status?: TaskStatus;
+  before_id?: string;
+  after_id?: string;
+}
+
+export type BulkTaskOperation =
+  | { op: 'update'; task_ids: string[]; fields: UpdateTaskRequest }
+  | { op: 'complete'; task_ids: string[]; subtasks?: 'refuse' | 'cascade' }
+  | { op: 'delete'; task_ids: string[] }
+  // A null project_id takes the tasks out of their project
+  | { op: 'move'; task_ids: string[]; project_id: string | null }
+  | { op: 'add_label'; task_ids: string[]; label_id: string };
+
+export interface BulkTaskRequest {
+  mode?: 'all_or_nothing' | 'best_effort';
+  operations: BulkTaskOperation[];
+}
+
+export interface BulkTaskResult {
+  // Index of the operation in the request
+  operation: number;
+  task_id: string;
+  status: 'succeeded' | 'failed' | 'rolled_back' | 'skipped';
+  code?: number;
+  error?: string;
+}
+
+export interface BulkTaskResponse {
+  mode: 'all_or_nothing' | 'best_effort';
+  committed: boolean;
+  succeeded: number;
+  failed: number;
+  results: BulkTaskResult[];
+}
+
+// A row of GET /tasks/export and POST /tasks/import. On import, id only links
+// subtasks to a parent in the same file; created_at and updated_at are ignored.
+export interface TaskRecord {
+  id?: string;
+  project_id?: string;
+  parent_id?: string;
+  title: string;
+  description?: string;
+  status?: TaskStatus;
+  priority?: TaskPriority;
+  due_date?: string;
+  completed_at?: string;
+  recurrence?: string;
+  recurrence_timezone?: string;
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface ImportTaskError {
+  // 1-based, not counting the CSV header
+  row: number;
+  error: string;
+}
+
+// Nothing is imported unless errors is empty
+export interface ImportTasksResponse {
+  dry_run: boolean;
+  rows: number;
+  imported: number;
+  errors: ImportTaskError[];
 }
 
 // API response types
@@ -84,9 +389,38 @@ export interface ApiError {
 
 export interface PaginatedResponse<T> {
   data: T[];
-  total: number;
-  page: number;
-  per_page: number;
+  next_cursor?: string;
+  limit: number;
+}
+
+export type TaskSortField =
+  | 'created_at'
+  | 'updated_at'
+  | 'due_date'
+  | 'completed_at'
+  | 'priority'
+  | 'status'
+  | 'title'
+  | 'rank';
+
+export interface TaskListParams {
+  status?: TaskStatus[];
+  priority?: TaskPriority[];
+  due_after?: string;
+  due_before?: string;
+  created_after?: string;
+  created_before?: string;
+  updated_after?: string;
+  updated_before?: string;
+  completed?: boolean;
+  sort?: TaskSortField;
+  order?: 'asc' | 'desc';
+  limit?: number;
+  labels?: string[];
+  label_match?: 'any' | 'all';
+  parent_id?: string | 'none';
+  project_id?: string | 'none';
+  cursor?: string;
 }
 
 // Task board column type
@@ -12,19 +12,18 @@ aws ecr get-login-password --region $AWS_REGION | docker login --username AWS --
 
 # Push auth-service
 echo "📦 Building auth-service..."
-cd ../services/auth-service
-docker build -t auth-service:$VERSION .
+# Images build from the services directory so they can copy the shared module
+cd ../services
+docker build -f auth-service/Dockerfile -t auth-service:$VERSION .
 docker tag auth-service:$VERSION $ECR_REGISTRY/auth-service:$VERSION
 docker push $ECR_REGISTRY/auth-service:$VERSION
-cd ..
 
 # Push task-service
 echo "📦 Building task-service..."
-cd task-service
-docker build -t task-service:$VERSION .
+docker build -f task-service/Dockerfile -t task-service:$VERSION .
 docker tag task-service:$VERSION $ECR_REGISTRY/task-service:$VERSION
 docker push $ECR_REGISTRY/task-service:$VERSION
-cd ../..
+cd ..
 
 # Kong doesn't need pushing - using official Kong image
 
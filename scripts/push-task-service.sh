@@ -12,11 +12,12 @@ aws ecr get-login-password --region $AWS_REGION | docker login --username AWS --
 
 # Push task-service
 echo "📦 Building task-service..."
-cd ../services/task-service
-docker build -t task-service:$VERSION .
+# The image builds from the services directory so it can copy the shared module
+cd ../services
+docker build -f task-service/Dockerfile -t task-service:$VERSION .
 docker tag task-service:$VERSION $ECR_REGISTRY/task-service:$VERSION
 docker push $ECR_REGISTRY/task-service:$VERSION
-cd ../..
+cd ..
 
 # Kong doesn't need pushing - using official Kong image
 
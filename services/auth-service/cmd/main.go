@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +16,8 @@ import (
 
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/handler"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/mailer"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
 )
 
 func main() {
@@ -31,6 +35,58 @@ func main() {
 	// Get configuration from environment
 	port := getEnv("AUTH_SERVICE_PORT", "8080")
 
+	// Sign access tokens with rotating keys, published at /.well-known/jwks.json
+	rotator := service.KeyRotator{
+		Algorithm: getEnv("JWT_SIGNING_ALG", service.AlgorithmEdDSA),
+		Refresh:   time.Minute,
+		Keys:      service.SigningKeys,
+	}
+	var err error
+	if rotator.Interval, err = getEnvDuration("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour); err != nil {
+		log.Fatal(err)
+	}
+	if rotator.Prepublish, err = getEnvDuration("JWT_KEY_PREPUBLISH", time.Hour); err != nil {
+		log.Fatal(err)
+	}
+	if rotator.Overlap, err = getEnvDuration("JWT_KEY_OVERLAP", time.Hour); err != nil {
+		log.Fatal(err)
+	}
+	// Private keys are sealed at rest with a data key from Secrets Manager
+	encryptionKey, err := base64.StdEncoding.DecodeString(os.Getenv("JWT_KEY_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatal("Invalid JWT_KEY_ENCRYPTION_KEY:", err)
+	}
+	if rotator.Encryption, err = service.NewKeyEncryption(encryptionKey); err != nil {
+		log.Fatal("Invalid JWT_KEY_ENCRYPTION_KEY:", err)
+	}
+	if service.DefaultJWTConfig.AccessTokenDuration, err = getEnvDuration("JWT_ACCESS_TOKEN_EXPIRY", service.DefaultJWTConfig.AccessTokenDuration); err != nil {
+		log.Fatal(err)
+	}
+	if err := rotator.Validate(service.DefaultJWTConfig.AccessTokenDuration); err != nil {
+		log.Fatal("Invalid signing key configuration:", err)
+	}
+	if err := rotator.RotateOnce(time.Now()); err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	go rotator.Run(context.Background())
+
+	// Account emails
+	accountMailer, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure email:", err)
+	}
+	service.EmailVerification.Mailer = accountMailer
+	service.EmailVerification.LinkURL = getEnv("EMAIL_VERIFICATION_URL", service.EmailVerification.LinkURL)
+	if service.EmailVerification.TokenTTL, err = getEnvDuration("EMAIL_VERIFICATION_TTL", service.EmailVerification.TokenTTL); err != nil {
+		log.Fatal(err)
+	}
+	service.EmailVerification.Required = os.Getenv("EMAIL_VERIFICATION_REQUIRED") == "true"
+	service.PasswordReset.Mailer = accountMailer
+	service.PasswordReset.LinkURL = getEnv("PASSWORD_RESET_URL", service.PasswordReset.LinkURL)
+	if service.PasswordReset.TokenTTL, err = getEnvDuration("PASSWORD_RESET_TTL", service.PasswordReset.TokenTTL); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize router
 	r := chi.NewRouter()
 
@@ -53,6 +109,7 @@ func main() {
 
 	// Routes
 	r.Get("/health", healthHandler)
+	r.Get("/.well-known/jwks.json", handler.JWKS)
 
 	// Auth routes
 	r.Route("/auth", func(r chi.Router) {
@@ -61,6 +118,13 @@ func main() {
 		r.Post("/refresh", handler.RefreshToken)
 		r.Get("/verify", handler.VerifyToken)
 		r.Post("/logout", handler.Logout)
+		r.Post("/logout-all", handler.LogoutAll)
+		r.Get("/sessions", handler.ListSessions)
+		r.Delete("/sessions/{sessionID}", handler.RevokeSession)
+		r.Post("/verify-email", handler.VerifyEmail)
+		r.Post("/resend-verification", handler.ResendVerification)
+		r.Post("/password/forgot", handler.ForgotPassword)
+		r.Post("/password/reset", handler.ResetPassword)
 	})
 
 	// Start server
@@ -85,3 +149,17 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration parses a duration such as "720h" from the environment
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 720h", key)
+	}
+	return d, nil
+}
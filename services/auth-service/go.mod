@@ -3,6 +3,7 @@ module github.com/williamschweitzer/task-management-app/services/auth-service
 go 1.24.0
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/go-chi/chi/v5 v5.0.10
 	github.com/go-chi/cors v1.2.1
 	github.com/google/uuid v1.6.0
@@ -28,7 +29,10 @@ require (
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/williamschweitzer/task-management-app/services/shared v0.0.0
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/text v0.30.0 // indirect
 	gorm.io/gorm v1.31.1
 )
+
+replace github.com/williamschweitzer/task-management-app/services/shared => ../shared
@@ -1,10 +1,15 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
@@ -14,11 +19,15 @@ type SignupRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Name     string `json:"name"`
+	// DeviceName names the session; omitted derives one from the User-Agent
+	DeviceName string `json:"device_name"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// DeviceName names the session; omitted derives one from the User-Agent
+	DeviceName string `json:"device_name"`
 }
 
 type LogoutRequest struct {
@@ -26,7 +35,6 @@ type LogoutRequest struct {
 }
 
 type RefreshTokenRequest struct {
-	Email        string `json:"email"`
 	RefreshToken string `json:"refresh_token"`
 }
 
@@ -57,6 +65,10 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Email, password, and name are required", http.StatusBadRequest)
 		return
 	}
+	if err := service.ValidatePassword(req.Password); err != nil {
+		writeInvalidPassword(w, err)
+		return
+	}
 
 	// Check if user already exists
 	var existingUser model.User
@@ -85,17 +97,32 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Send in the background so a slow mail server does not hold up signup
+	go sendVerificationEmail(context.WithoutCancel(r.Context()), &user)
+
+	// Unverified users may not log in yet, so issue no tokens
+	if service.EmailVerification.Required {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(PendingVerificationResponse{
+			Message: "Check your email to verify your address before logging in",
+			User:    user,
+		})
+		return
+	}
+
 	// Get JWT config at runtime
 	cfg := service.GetJWTConfig()
 
-	// Generate tokens
-	accessToken, err := service.GenerateAccessToken(cfg, user.ID, user.Email)
+	// Generate tokens for a new session
+	sessionID := uuid.New()
+	accessToken, err := service.GenerateAccessToken(cfg, user.ID, sessionID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
 		return
 	}
 
-	refreshToken, refreshTokenExpiry, err := service.GenerateRefreshToken(cfg, user.ID, user.Email)
+	refreshToken, refreshTokenExpiry, err := service.GenerateRefreshToken(user.ID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
 		return
@@ -108,8 +135,8 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Store the refresh token
-	err = service.StoreRefreshToken(user.ID, hashedRefreshToken, refreshTokenExpiry)
+	// Store the refresh token as the first of the session's family
+	err = service.StoreRefreshToken(user.ID, sessionID, hashedRefreshToken, refreshTokenExpiry, requestDevice(r, req.DeviceName))
 	if err != nil {
 		http.Error(w, "Failed to store refresh token", http.StatusInternalServerError)
 		return
@@ -119,7 +146,7 @@ func Signup(w http.ResponseWriter, r *http.Request) {
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    900, // 15 minutes
+		ExpiresIn:    int(cfg.AccessTokenDuration.Seconds()),
 		User:         user,
 	}
 
@@ -155,17 +182,23 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if service.EmailVerification.Required && !user.IsEmailVerified() {
+		http.Error(w, "Email address is not verified", http.StatusForbidden)
+		return
+	}
+
 	// Get JWT config at runtime
 	cfg := service.GetJWTConfig()
 
-	// Generate tokens
-	accessToken, err := service.GenerateAccessToken(cfg, user.ID, user.Email)
+	// Generate tokens for a new session
+	sessionID := uuid.New()
+	accessToken, err := service.GenerateAccessToken(cfg, user.ID, sessionID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
 		return
 	}
 
-	refreshToken, refreshTokenExpiry, err := service.GenerateRefreshToken(cfg, user.ID, user.Email)
+	refreshToken, refreshTokenExpiry, err := service.GenerateRefreshToken(user.ID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
 		return
@@ -178,8 +211,8 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Store the refresh token to auth.refresh_tokens
-	err = service.StoreRefreshToken(user.ID, hashedRefreshToken, refreshTokenExpiry)
+	// Store the refresh token to auth.refresh_tokens, starting the session's family
+	err = service.StoreRefreshToken(user.ID, sessionID, hashedRefreshToken, refreshTokenExpiry, requestDevice(r, req.DeviceName))
 	if err != nil {
 		http.Error(w, "Failed to create refresh token", http.StatusInternalServerError)
 		return
@@ -189,7 +222,7 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    900, // 15 minutes
+		ExpiresIn:    int(cfg.AccessTokenDuration.Seconds()),
 		User:         user,
 	}
 
@@ -206,11 +239,6 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := model.ValidateEmail(req.Email); err != nil {
-		http.Error(w, "Email is invalid", http.StatusBadRequest)
-		return
-	}
-
 	if req.RefreshToken == "" {
 		http.Error(w, "Refresh token is required", http.StatusBadRequest)
 		return
@@ -231,13 +259,31 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A token presented again after rotation has been copied, so end its
+	// session. One revoked by logging out is just rejected.
+	if refreshToken.WasRotated() {
+		reportRefreshTokenReuse(w, r, refreshToken)
+		return
+	}
+	if refreshToken.IsRevoked() {
+		http.Error(w, "Refresh token is already revoked", http.StatusUnauthorized)
+		return
+	}
+
 	if refreshToken.IsExpired() {
 		http.Error(w, "Refresh token has expired", http.StatusUnauthorized)
 		return
 	}
 
-	if refreshToken.IsRevoked() {
-		http.Error(w, "Refresh token is already revoked", http.StatusUnauthorized)
+	// The new tokens carry the account's current email, not one the client sent
+	var user model.User
+	if err := database.DB.First(&user, "id = ?", refreshToken.UserID).Error; err != nil {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+
+	if service.EmailVerification.Required && !user.IsEmailVerified() {
+		http.Error(w, "Email address is not verified", http.StatusForbidden)
 		return
 	}
 
@@ -245,24 +291,18 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 	cfg := service.GetJWTConfig()
 
 	// Generate new tokens
-	accessToken, err := service.GenerateAccessToken(cfg, refreshToken.UserID, req.Email)
+	accessToken, err := service.GenerateAccessToken(cfg, refreshToken.UserID, refreshToken.FamilyID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate access token", http.StatusInternalServerError)
 		return
 	}
 
-	newRefreshToken, newRefreshTokenExpiry, err := service.GenerateRefreshToken(cfg, refreshToken.UserID, req.Email)
+	newRefreshToken, newRefreshTokenExpiry, err := service.GenerateRefreshToken(refreshToken.UserID, user.Email)
 	if err != nil {
 		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
 		return
 	}
 
-	// Revoke old refresh token
-	if err := service.RevokeRefreshToken(refreshToken); err != nil {
-		http.Error(w, "Failed to revoke old refresh token", http.StatusInternalServerError)
-		return
-	}
-
 	// Hash new refresh token
 	hashedNewRefreshToken, err := service.HashToken(newRefreshToken)
 	if err != nil {
@@ -270,10 +310,18 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Store new refresh token
-	err = service.StoreRefreshToken(refreshToken.UserID, hashedNewRefreshToken, newRefreshTokenExpiry)
+	// Revoke old refresh token and store its successor in the same family
+	err = service.RotateRefreshToken(refreshToken, hashedNewRefreshToken, newRefreshTokenExpiry, requestDevice(r, ""))
+	if errors.Is(err, service.ErrRefreshTokenReused) {
+		reportRefreshTokenReuse(w, r, refreshToken)
+		return
+	}
+	if errors.Is(err, service.ErrRefreshTokenRevoked) {
+		http.Error(w, "Refresh token is already revoked", http.StatusUnauthorized)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to store new refresh token", http.StatusInternalServerError)
+		http.Error(w, "Failed to rotate refresh token", http.StatusInternalServerError)
 		return
 	}
 
@@ -281,7 +329,7 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 		AccessToken:  accessToken,
 		RefreshToken: newRefreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    900, // 15 minutes
+		ExpiresIn:    int(cfg.AccessTokenDuration.Seconds()),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -289,30 +337,52 @@ func RefreshToken(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-func VerifyToken(w http.ResponseWriter, r *http.Request) {
-	// Get token from Authorization header
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+// writeInvalidPassword rejects a password ValidatePassword refused with err
+func writeInvalidPassword(w http.ResponseWriter, err error) {
+	if errors.Is(err, service.ErrPasswordTooLong) {
+		http.Error(w, fmt.Sprintf("Password must be at most %d bytes", service.MaxPasswordBytes), http.StatusBadRequest)
 		return
 	}
+	http.Error(w, fmt.Sprintf("Password must be at least %d characters", service.MinPasswordLength), http.StatusBadRequest)
+}
 
-	// Extract token from "Bearer <token>"
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+// reportRefreshTokenReuse revokes the family of a replayed refresh token and
+// rejects the request
+func reportRefreshTokenReuse(w http.ResponseWriter, r *http.Request, refreshToken *model.RefreshToken) {
+	if err := service.ReportRefreshTokenReuse(refreshToken, clientIP(r), r.UserAgent()); err != nil {
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
 		return
 	}
 
-	tokenString := parts[1]
+	http.Error(w, "Refresh token reuse detected; please log in again", http.StatusUnauthorized)
+}
 
-	// Get JWT config at runtime
-	cfg := service.GetJWTConfig()
+// requestDevice describes the device r came from, named name or, if that
+// is empty, after its User-Agent
+func requestDevice(r *http.Request, name string) service.Device {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = service.DeviceName(r.UserAgent())
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
 
-	// Validate token
-	claims, err := service.ValidateToken(cfg, tokenString)
-	if err != nil {
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+	return service.Device{Name: name, UserAgent: r.UserAgent(), IPAddress: clientIP(r)}
+}
+
+// clientIP returns the caller's address; middleware.RealIP has already
+// applied any X-Forwarded-For or X-Real-IP header
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+func VerifyToken(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authenticate(w, r)
+	if !ok {
 		return
 	}
 
@@ -328,6 +398,33 @@ func VerifyToken(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// authenticate validates the access token in the Authorization header,
+// writing a 401 and returning false if it is missing or invalid
+func authenticate(w http.ResponseWriter, r *http.Request) (*service.Claims, bool) {
+	// Get token from Authorization header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "Authorization header required", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// Extract token from "Bearer <token>"
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// Validate token
+	claims, err := service.ValidateToken(service.GetJWTConfig(), parts[1])
+	if err != nil {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return claims, true
+}
+
 func Logout(w http.ResponseWriter, r *http.Request) {
 	var req LogoutRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
+)
+
+// JWKS publishes the public keys that verify access tokens. Verifiers may
+// cache it for a few minutes; keys are published well before they sign.
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(service.SigningKeys.JWKS(time.Now()))
+}
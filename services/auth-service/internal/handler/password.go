@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
+)
+
+// Reset email limits, per address and per client IP
+var (
+	forgotPerEmail = service.NewRateLimiter(3, time.Hour)
+	forgotPerIP    = service.NewRateLimiter(10, time.Hour)
+)
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// ForgotPassword emails a password reset link. It answers 200 whether or not
+// the address has an account, and whether or not a rate limit stopped the
+// email, so it cannot be used to find out who has an account.
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(req.Email)
+	if err := model.ValidateEmail(email); err != nil {
+		http.Error(w, "Email is invalid", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if ok, _ := forgotPerIP.Allow(clientIP(r), now); ok {
+		if ok, _ := forgotPerEmail.Allow(email, now); ok {
+			// Send in the background so the response takes as long for an
+			// unknown address as for a known one
+			var user model.User
+			if err := database.DB.Where("email = ?", email).First(&user).Error; err == nil {
+				go sendPasswordResetEmail(context.WithoutCancel(r.Context()), &user)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If that address has an account, a password reset link is on its way",
+	})
+}
+
+// ResetPassword sets a new password with the token from a reset link and
+// logs the user out of every session
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" || req.Password == "" {
+		http.Error(w, "Token and password are required", http.StatusBadRequest)
+		return
+	}
+	if err := service.ValidatePassword(req.Password); err != nil {
+		writeInvalidPassword(w, err)
+		return
+	}
+
+	user, err := service.ResetPassword(req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			http.Error(w, "Invalid or expired password reset token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Password reset for user %s; revoked all sessions", user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password updated; log in with your new password",
+	})
+}
+
+// sendPasswordResetEmail emails user a reset link, logging any failure
+func sendPasswordResetEmail(ctx context.Context, user *model.User) {
+	if err := service.PasswordReset.SendPasswordResetEmail(ctx, user); err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
+)
+
+// SessionResponse is a device the user is logged in on
+type SessionResponse struct {
+	// ID is the session's refresh token family
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session the request's access token belongs to
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the caller's active sessions
+func ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := service.ListSessions(claims.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch sessions", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]SessionResponse, 0, len(tokens))
+	for _, t := range tokens {
+		resp = append(resp, SessionResponse{
+			ID:         t.FamilyID,
+			Name:       t.Name,
+			UserAgent:  t.UserAgent,
+			IPAddress:  t.IPAddress,
+			LastUsedAt: t.LastUsedAt,
+			ExpiresAt:  t.ExpiresAt,
+			Current:    t.FamilyID == claims.SessionID,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RevokeSession logs the caller out of one session. Access tokens already
+// issued to it stay valid until they expire.
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := service.RevokeSession(claims.UserID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll logs the caller out of every session, this one included
+func LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	revoked, err := service.RevokeAllSessions(claims.UserID)
+	if err != nil {
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Logged out of all sessions",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/service"
+)
+
+// Resend limits, per address and per client IP
+var (
+	resendPerEmail = service.NewRateLimiter(3, time.Hour)
+	resendPerIP    = service.NewRateLimiter(10, time.Hour)
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// PendingVerificationResponse answers a signup when the address must be
+// verified before logging in
+type PendingVerificationResponse struct {
+	Message string     `json:"message"`
+	User    model.User `json:"user"`
+}
+
+// VerifyEmail marks the caller's address verified with the token from their
+// verification link
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if req.Token == "" {
+		http.Error(w, "Verification token is required", http.StatusBadRequest)
+		return
+	}
+
+	user, err := service.VerifyEmail(req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			http.Error(w, "Invalid or expired verification token", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to verify email", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Email verified",
+		"user":    user,
+	})
+}
+
+// ResendVerification emails a new verification link. It answers the same
+// whether or not the address belongs to an unverified user, so it cannot be
+// used to find out who has an account.
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	email := strings.ToLower(req.Email)
+	if err := model.ValidateEmail(email); err != nil {
+		http.Error(w, "Email is invalid", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if ok, retryAfter := resendPerIP.Allow(clientIP(r), now); !ok {
+		writeTooManyRequests(w, retryAfter)
+		return
+	}
+	if ok, retryAfter := resendPerEmail.Allow(email, now); !ok {
+		writeTooManyRequests(w, retryAfter)
+		return
+	}
+
+	// Send in the background so the response takes as long for an unknown
+	// address as for a known one
+	var user model.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err == nil && !user.IsEmailVerified() {
+		go sendVerificationEmail(context.WithoutCancel(r.Context()), &user)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If that address is waiting to be verified, a new link is on its way",
+	})
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	http.Error(w, "Too many requests; try again later", http.StatusTooManyRequests)
+}
+
+// sendVerificationEmail emails user a verification link. A failure is
+// logged rather than failing the request; the user can ask for another.
+func sendVerificationEmail(ctx context.Context, user *model.User) {
+	if err := service.EmailVerification.SendVerificationEmail(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Message is a plain-text email for one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email to users
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the Mailer selected by MAILER: "log" (the default) writes
+// messages to the service log, "smtp" sends them through the server
+// configured with the SMTP_* variables.
+func FromEnv() (Mailer, error) {
+	switch backend := os.Getenv("MAILER"); backend {
+	case "", "log":
+		log.Println("Account emails are written to the log; set MAILER=smtp to send them")
+		return LogMailer{}, nil
+	case "smtp":
+		port := 587
+		if raw := os.Getenv("SMTP_PORT"); raw != "" {
+			p, err := strconv.Atoi(raw)
+			if err != nil || p <= 0 {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", raw)
+			}
+			port = p
+		}
+
+		mailer, err := NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Sending account emails through SMTP server %s:%d", os.Getenv("SMTP_HOST"), port)
+		return mailer, nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER %q: must be log or smtp", backend)
+	}
+}
+
+// LogMailer writes messages to the service log instead of delivering them.
+// It is meant for development.
+type LogMailer struct{}
+
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// MemoryMailer keeps the messages it is given, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (m *MemoryMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
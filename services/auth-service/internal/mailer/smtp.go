@@ -0,0 +1,27 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/williamschweitzer/task-management-app/services/shared/smtpmail"
+)
+
+// SMTPConfig describes the mail server account emails are submitted to
+type SMTPConfig = smtpmail.Config
+
+// SMTPMailer emails messages through an SMTP submission server
+type SMTPMailer struct {
+	sender *smtpmail.Sender
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	sender, err := smtpmail.NewSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPMailer{sender: sender}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	return m.sender.Send(ctx, smtpmail.Message(msg))
+}
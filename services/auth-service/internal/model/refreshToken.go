@@ -6,13 +6,32 @@ import (
 	"github.com/google/uuid"
 )
 
+// Reasons a refresh token was revoked
+const (
+	RevokedReasonRotated        = "rotated"
+	RevokedReasonLogout         = "logout"
+	RevokedReasonSessionRevoked = "session_revoked"
+	RevokedReasonLogoutAll      = "logout_all"
+	RevokedReasonPasswordReset  = "password_reset"
+	RevokedReasonReuse          = "reuse"
+)
+
 type RefreshToken struct {
-	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	// FamilyID is shared by every token rotated from the same login
+	FamilyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
 	TokenHash string     `gorm:"not null" json:"-"`
 	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
 	CreatedAt time.Time  `json:"created_at"`
 	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	// RevokedReason is why RevokedAt was set, one of the RevokedReason values
+	RevokedReason string `gorm:"type:varchar(32);not null;default:''" json:"revoked_reason,omitempty"`
+	// Name, UserAgent and IPAddress describe the device the session is on
+	Name       string    `gorm:"type:varchar(255);not null" json:"name"`
+	UserAgent  string    `gorm:"not null" json:"user_agent"`
+	IPAddress  string    `gorm:"type:varchar(64);not null" json:"ip_address"`
+	LastUsedAt time.Time `gorm:"not null" json:"last_used_at"`
 }
 
 func (RefreshToken) TableName() string {
@@ -28,6 +47,12 @@ func (r *RefreshToken) IsRevoked() bool {
 	return r.RevokedAt != nil
 }
 
+// WasRotated reports whether the token was revoked by being exchanged for
+// its successor, so presenting it again means a copy of it is in use
+func (r *RefreshToken) WasRotated() bool {
+	return r.IsRevoked() && r.RevokedReason == RevokedReasonRotated
+}
+
 func (r *RefreshToken) IsExpired() bool {
 	return r.ExpiresAt.Before(time.Now())
 }
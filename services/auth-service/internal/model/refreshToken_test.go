@@ -33,10 +33,16 @@ func TestRefreshToken_FieldTags(t *testing.T) {
 	}{
 		{"ID", `type:uuid;primary_key;default:gen_random_uuid()`, "id"},
 		{"UserID", `type:uuid;not null;index`, "user_id"},
+		{"FamilyID", `type:uuid;not null;index`, "family_id"},
 		{"TokenHash", `not null`, "-"},
 		{"ExpiresAt", `not null`, "expires_at"},
 		{"CreatedAt", "", "created_at"},
 		{"RevokedAt", "", "revoked_at,omitempty"},
+		{"RevokedReason", `type:varchar(32);not null;default:''`, "revoked_reason,omitempty"},
+		{"Name", `type:varchar(255);not null`, "name"},
+		{"UserAgent", `not null`, "user_agent"},
+		{"IPAddress", `type:varchar(64);not null`, "ip_address"},
+		{"LastUsedAt", `not null`, "last_used_at"},
 	}
 
 	for _, tt := range tests {
@@ -62,12 +68,17 @@ func TestRefreshToken_JSON(t *testing.T) {
 	revoked := now.Add(time.Hour)
 
 	token := RefreshToken{
-		ID:        uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
-		UserID:    uuid.MustParse("11111111-1111-1111-1111-111111111111"),
-		TokenHash: "hashed-value-should-be-ignored",
-		ExpiresAt: future,
-		CreatedAt: now,
-		RevokedAt: &revoked,
+		ID:         uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		UserID:     uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		FamilyID:   uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+		TokenHash:  "hashed-value-should-be-ignored",
+		ExpiresAt:  future,
+		CreatedAt:  now,
+		RevokedAt:  &revoked,
+		Name:       "Firefox on Linux",
+		UserAgent:  "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+		IPAddress:  "203.0.113.7",
+		LastUsedAt: now,
 	}
 
 	t.Run("marshal", func(t *testing.T) {
@@ -77,9 +88,14 @@ func TestRefreshToken_JSON(t *testing.T) {
 		expected := `{
 			"id":"550e8400-e29b-41d4-a716-446655440000",
 			"user_id":"11111111-1111-1111-1111-111111111111",
+			"family_id":"22222222-2222-2222-2222-222222222222",
 			"expires_at":"` + future.Format(time.RFC3339) + `",
 			"created_at":"` + now.Format(time.RFC3339) + `",
-			"revoked_at":"` + revoked.Format(time.RFC3339) + `"
+			"revoked_at":"` + revoked.Format(time.RFC3339) + `",
+			"name":"Firefox on Linux",
+			"user_agent":"Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0",
+			"ip_address":"203.0.113.7",
+			"last_used_at":"` + now.Format(time.RFC3339) + `"
 		}`
 
 		// Normalize whitespace
@@ -183,3 +199,12 @@ func TestRefreshToken_StatusMethods(t *testing.T) {
 		})
 	}
 }
+
+func TestRefreshToken_WasRotated(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, (&RefreshToken{RevokedAt: &now, RevokedReason: RevokedReasonRotated}).WasRotated())
+	assert.False(t, (&RefreshToken{RevokedAt: &now, RevokedReason: RevokedReasonLogout}).WasRotated())
+	assert.False(t, (&RefreshToken{RevokedAt: &now}).WasRotated())
+	assert.False(t, (&RefreshToken{RevokedReason: RevokedReasonRotated}).WasRotated())
+}
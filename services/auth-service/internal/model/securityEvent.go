@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Security event types
+const (
+	// SecurityEventRefreshTokenReuse is a revoked refresh token presented
+	// again, which means it was copied; its family is revoked in response
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+)
+
+// SecurityEvent records something that happened to a user's account that
+// they or an operator may need to look into
+type SecurityEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Type      string     `gorm:"type:varchar(64);not null" json:"type"`
+	FamilyID  *uuid.UUID `gorm:"type:uuid" json:"family_id,omitempty"`
+	IPAddress string     `gorm:"type:varchar(64)" json:"ip_address,omitempty"`
+	UserAgent string     `json:"user_agent,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "auth.security_events"
+}
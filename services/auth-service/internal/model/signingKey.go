@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// SigningKey is a key the auth service signs access tokens with. Its public
+// half is published in the JWKS from creation until ExpiresAt; it signs from
+// ActiveAt until RetireAt.
+type SigningKey struct {
+	// ID is the kid in the header of the tokens the key signs
+	ID        string `gorm:"type:varchar(64);primary_key" json:"kid"`
+	Algorithm string `gorm:"type:varchar(16);not null" json:"alg"`
+	// PrivateKey is PKCS #8 DER sealed with AES-256-GCM under the key
+	// encryption key, prefixed with its nonce
+	PrivateKey []byte    `gorm:"type:bytea;not null" json:"-"`
+	ActiveAt   time.Time `gorm:"not null" json:"active_at"`
+	RetireAt   time.Time `gorm:"not null" json:"retire_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (SigningKey) TableName() string {
+	return "auth.signing_keys"
+}
+
+// IsPublished reports whether tokens signed with the key verify at now
+func (k *SigningKey) IsPublished(now time.Time) bool {
+	return now.Before(k.ExpiresAt)
+}
+
+// IsSigning reports whether the key is the one to sign with at now, unless
+// a newer key is signing too
+func (k *SigningKey) IsSigning(now time.Time) bool {
+	return !now.Before(k.ActiveAt) && now.Before(k.RetireAt)
+}
@@ -1,6 +1,34 @@
 package service
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password length limits. bcrypt refuses passwords longer than 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordBytes  = 72
+)
+
+var (
+	ErrPasswordTooShort = fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	ErrPasswordTooLong  = fmt.Errorf("password must be at most %d bytes", MaxPasswordBytes)
+)
+
+// ValidatePassword checks a new password against the length limits. It
+// returns ErrPasswordTooShort or ErrPasswordTooLong.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+	return nil
+}
 
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
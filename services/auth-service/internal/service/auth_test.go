@@ -1,6 +1,7 @@
 package service
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,3 +28,30 @@ func TestCheckPassword(t *testing.T) {
 	isValid = CheckPassword("WrongPassword", hashedPassword)
 	assert.False(t, isValid)
 }
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  error
+	}{
+		{name: "long enough", password: "SecurePassw0rd!"},
+		{name: "minimum length", password: "12345678"},
+		{name: "too short", password: "1234567", wantErr: ErrPasswordTooShort},
+		{name: "short in characters, not bytes", password: "ééééééé", wantErr: ErrPasswordTooShort},
+		{name: "maximum length", password: strings.Repeat("a", 72)},
+		{name: "too long for bcrypt", password: strings.Repeat("a", 73), wantErr: ErrPasswordTooLong},
+		{name: "too long in bytes", password: strings.Repeat("é", 37), wantErr: ErrPasswordTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.password)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
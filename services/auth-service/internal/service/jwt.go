@@ -1,9 +1,13 @@
 package service
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"time"
 
@@ -11,45 +15,47 @@ import (
 	"github.com/google/uuid"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
 	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRefreshTokenReused means a refresh token was presented after it had
+	// already been rotated
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrRefreshTokenRevoked means a refresh token was revoked other than by
+	// rotation, such as by logging out
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
 )
 
 type JWTConfig struct {
-	Secret              string
+	// Keys signs access tokens and verifies them by kid
+	Keys                *KeySet
 	Issuer              string
 	AccessTokenDuration time.Duration
 }
 
 var DefaultJWTConfig = JWTConfig{
-	Secret:              string(os.Getenv("JWT_SECRET")),
+	Keys:                SigningKeys,
 	Issuer:              "task-management-auth",
 	AccessTokenDuration: 15 * time.Minute,
 }
 
-// GetJWTConfig reads config from environment at runtime
+// GetJWTConfig returns the config for the keys loaded by the KeyRotator
 func GetJWTConfig() JWTConfig {
-	secret := os.Getenv("JWT_SECRET")
-	fmt.Printf("DEBUG: JWT_SECRET length: %d\n", len(secret))
-	if secret == "" {
-		// This is critical - log or panic if JWT_SECRET is not set
-		panic("JWT_SECRET environment variable is not set")
-	}
-
-	return JWTConfig{
-		Secret:              secret,
-		Issuer:              "task-management-auth",
-		AccessTokenDuration: 15 * time.Minute,
-	}
+	return DefaultJWTConfig
 }
 
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
+	// SessionID is the refresh token family the token was issued from
+	SessionID uuid.UUID `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(cfg JWTConfig, userID uuid.UUID, email string) (string, error) {
-	if cfg.Secret == "" {
-		return "", fmt.Errorf("JWT_SECRET is not set")
+func GenerateAccessToken(cfg JWTConfig, userID, sessionID uuid.UUID, email string) (string, error) {
+	if cfg.Keys == nil {
+		return "", ErrNoSigningKey
 	}
 
 	if err := model.ValidateEmail(email); err != nil {
@@ -60,35 +66,31 @@ func GenerateAccessToken(cfg JWTConfig, userID uuid.UUID, email string) (string,
 		return "", fmt.Errorf("userID cannot be nil")
 	}
 
-	expiryStr := os.Getenv("ACCESS_TOKEN_EXPIRY")
-	if expiryStr == "" {
-		expiryStr = "15m"
-	}
-
-	expiry, err := time.ParseDuration(expiryStr)
-	if err != nil {
-		expiry = 15 * time.Minute
-	}
-
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.AccessTokenDuration)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    cfg.Issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.Secret))
-}
-
-func GenerateRefreshToken(cfg JWTConfig, userID uuid.UUID, email string) (string, time.Time, error) {
-	if cfg.Secret == "" {
-		return "", time.Time{}, fmt.Errorf("JWT_SECRET is not set")
+	key, err := cfg.Keys.current(now)
+	if err != nil {
+		return "", err
 	}
 
+	token := jwt.NewWithClaims(key.method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signer)
+}
+
+// GenerateRefreshToken returns an opaque random token. Only its hash is
+// stored, so it needs no signature.
+func GenerateRefreshToken(userID uuid.UUID, email string) (string, time.Time, error) {
 	if err := model.ValidateEmail(email); err != nil {
 		return "", time.Time{}, err
 	}
@@ -97,7 +99,7 @@ func GenerateRefreshToken(cfg JWTConfig, userID uuid.UUID, email string) (string
 		return "", time.Time{}, fmt.Errorf("userID cannot be nil")
 	}
 
-	expiryStr := os.Getenv("REFRESH_TOKEN_EXPIRY")
+	expiryStr := os.Getenv("JWT_REFRESH_TOKEN_EXPIRY")
 	if expiryStr == "" {
 		expiryStr = "168h" // 7 days - use hours notation
 	}
@@ -107,30 +109,36 @@ func GenerateRefreshToken(cfg JWTConfig, userID uuid.UUID, email string) (string
 		expiry = 7 * 24 * time.Hour
 	}
 
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    cfg.Issuer,
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.Secret))
+	token, err := randomToken()
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
-	return tokenString, claims.ExpiresAt.Time, nil
+	return token, time.Now().Add(expiry), nil
 }
 
-func StoreRefreshToken(userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+// randomToken returns 256 random bits, URL-safe encoded, for tokens whose
+// hash is stored
+func randomToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// StoreRefreshToken stores a refresh token in family. Logins start a new
+// family; refreshes continue theirs through RotateRefreshToken.
+func StoreRefreshToken(userID, familyID uuid.UUID, tokenHash string, expiresAt time.Time, device Device) error {
 	refreshTokenEntry := model.RefreshToken{
-		UserID:    userID,
-		TokenHash: tokenHash,
-		ExpiresAt: expiresAt,
+		UserID:     userID,
+		FamilyID:   familyID,
+		TokenHash:  tokenHash,
+		ExpiresAt:  expiresAt,
+		Name:       device.Name,
+		UserAgent:  device.UserAgent,
+		IPAddress:  device.IPAddress,
+		LastUsedAt: time.Now(),
 	}
 
 	if err := database.DB.Create(&refreshTokenEntry).Error; err != nil {
@@ -154,6 +162,7 @@ func LookupRefreshToken(tokenHash string) (*model.RefreshToken, error) {
 func RevokeRefreshToken(refreshToken *model.RefreshToken) error {
 	now := time.Now()
 	refreshToken.RevokedAt = &now
+	refreshToken.RevokedReason = model.RevokedReasonLogout
 
 	if err := database.DB.Save(refreshToken).Error; err != nil {
 		return err
@@ -162,17 +171,98 @@ func RevokeRefreshToken(refreshToken *model.RefreshToken) error {
 	return nil
 }
 
+// RotateRefreshToken revokes old and stores its successor in the same family,
+// keeping the session's name and recording where it is now used from. It
+// returns ErrRefreshTokenReused if old was rotated in the meantime, such as
+// by a concurrent refresh with a copy of it, and ErrRefreshTokenRevoked if it
+// was revoked some other way.
+func RotateRefreshToken(old *model.RefreshToken, tokenHash string, expiresAt time.Time, device Device) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", old.ID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"revoked_reason": model.RevokedReasonRotated,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var current model.RefreshToken
+			if err := tx.Select("revoked_at", "revoked_reason").First(&current, "id = ?", old.ID).Error; err != nil {
+				return err
+			}
+			if current.WasRotated() {
+				return ErrRefreshTokenReused
+			}
+			return ErrRefreshTokenRevoked
+		}
+
+		return tx.Create(&model.RefreshToken{
+			UserID:     old.UserID,
+			FamilyID:   old.FamilyID,
+			TokenHash:  tokenHash,
+			ExpiresAt:  expiresAt,
+			Name:       old.Name,
+			UserAgent:  device.UserAgent,
+			IPAddress:  device.IPAddress,
+			LastUsedAt: time.Now(),
+		}).Error
+	})
+}
+
+// ReportRefreshTokenReuse responds to a refresh token being presented again
+// after it was rotated. Either the caller or whoever it was rotated to holds a stolen copy,
+// so every token in its family is revoked, ending that session, and a
+// security event is recorded.
+func ReportRefreshTokenReuse(token *model.RefreshToken, ipAddress, userAgent string) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := revokeRefreshTokenFamily(tx, token.FamilyID); err != nil {
+			return err
+		}
+
+		return tx.Create(&model.SecurityEvent{
+			UserID:    token.UserID,
+			Type:      model.SecurityEventRefreshTokenReuse,
+			FamilyID:  &token.FamilyID,
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Refresh token reuse for user %s; revoked token family %s", token.UserID, token.FamilyID)
+	return nil
+}
+
+func revokeRefreshTokenFamily(tx *gorm.DB, familyID uuid.UUID) error {
+	return tx.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": model.RevokedReasonReuse,
+		}).Error
+}
+
+// ValidateToken verifies tokenStr against the published key named by its kid
 func ValidateToken(cfg JWTConfig, tokenStr string) (*Claims, error) {
-	if cfg.Secret == "" {
-		return nil, fmt.Errorf("JWT_SECRET is not set")
+	if cfg.Keys == nil {
+		return nil, ErrNoSigningKey
 	}
 
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, alg, err := cfg.Keys.publicKey(kid, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != alg {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(cfg.Secret), nil
-	})
+		return key, nil
+	}, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
 
 	if err != nil {
 		return nil, err
@@ -3,21 +3,20 @@ package service
 
 import (
 	"encoding/hex"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
 )
 
 func TestGenerateAccessToken(t *testing.T) {
-	testCfg := JWTConfig{
-		Secret:              "test-secret-32-byte-key-for-hs256!!",
-		Issuer:              "task-management-auth",
-		AccessTokenDuration: 15 * time.Minute,
-	}
+	testCfg := testJWTConfig(t)
 
 	tests := []struct {
 		name    string // <-- explicit name
@@ -33,7 +32,7 @@ func TestGenerateAccessToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, err := GenerateAccessToken(testCfg, tt.userID, tt.email)
+			token, err := GenerateAccessToken(testCfg, tt.userID, uuid.New(), tt.email)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -42,14 +41,22 @@ func TestGenerateAccessToken(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("expires_after_the_configured_duration", func(t *testing.T) {
+		cfg := testCfg
+		cfg.AccessTokenDuration = 5 * time.Minute
+
+		token, err := GenerateAccessToken(cfg, uuid.New(), uuid.New(), "valid@email.com")
+		require.NoError(t, err)
+
+		claims := &Claims{}
+		_, _, err = jwt.NewParser().ParseUnverified(token, claims)
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+	})
 }
 
 func TestGenerateRefreshToken(t *testing.T) {
-	testCfg := JWTConfig{
-		Secret:              "test-secret-32-byte-key-for-hs256!!",
-		Issuer:              "task-management-auth",
-		AccessTokenDuration: 15 * time.Minute,
-	}
 
 	tests := []struct {
 		name    string // <-- explicit name
@@ -65,7 +72,7 @@ func TestGenerateRefreshToken(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			token, _, err := GenerateRefreshToken(testCfg, tt.userID, tt.email)
+			token, _, err := GenerateRefreshToken(tt.userID, tt.email)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -95,19 +102,19 @@ func TestRevokeRefreshToken(t *testing.T) {
 }
 
 func TestValidateToken(t *testing.T) {
-	cfg := JWTConfig{
-		Secret:              "test-secret-32-byte-key-for-hs256!!",
-		Issuer:              "task-management-auth",
-		AccessTokenDuration: 15 * time.Minute,
-	}
+	cfg := testJWTConfig(t)
+	key, err := cfg.Keys.current(time.Now())
+	require.NoError(t, err)
 
 	// ---- a fresh, valid token -------------------------------------------------
+	sessionID := uuid.New()
 	validToken, err := GenerateAccessToken(cfg,
 		uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		sessionID,
 		"valid@email.com")
 	assert.NoError(t, err)
 
-	// ---- an expired token (signed with the *same* secret) --------------------
+	// ---- an expired token (signed with the *same* key) -----------------------
 	expiredClaims := &Claims{
 		UserID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
 		Email:  "valid@email.com",
@@ -117,11 +124,40 @@ func TestValidateToken(t *testing.T) {
 			Issuer:    cfg.Issuer,
 		},
 	}
-	expiredObj := jwt.NewWithClaims(jwt.SigningMethodHS256, expiredClaims)
-	expiredToken, _ := expiredObj.SignedString(cfg.Secret)
+	expiredObj := jwt.NewWithClaims(jwt.SigningMethodEdDSA, expiredClaims)
+	expiredObj.Header["kid"] = key.ID
+	expiredToken, _ := expiredObj.SignedString(key.signer)
+
+	liveClaims := &Claims{
+		UserID: uuid.MustParse("550e8400-e29b-41d4-a716-446655440000"),
+		Email:  "valid@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    cfg.Issuer,
+		},
+	}
+
+	// ---- a token signed with a shared secret under a published kid -----------
+	hmacObj := jwt.NewWithClaims(jwt.SigningMethodHS256, liveClaims)
+	hmacObj.Header["kid"] = key.ID
+	hmacToken, _ := hmacObj.SignedString([]byte("test-secret-32-byte-key-for-hs256!!"))
+
+	// ---- a token signed with a key that is not published ---------------------
+	other, err := parseSigningKey(*mustGenerateSigningKey(t, AlgorithmEdDSA), testKeyEncryption)
+	require.NoError(t, err)
+	otherObj := jwt.NewWithClaims(jwt.SigningMethodEdDSA, liveClaims)
+	otherObj.Header["kid"] = other.ID
+	unknownKeyToken, _ := otherObj.SignedString(other.signer)
 
 	// ---- a token with a tampered signature -----------------------------------
-	tamperedToken := validToken[:len(validToken)-1] + "X"
+	// The last character of a base64url Ed25519 signature is partly padding,
+	// so change the first one
+	sigStart := strings.LastIndex(validToken, ".") + 1
+	tamperedChar := "A"
+	if validToken[sigStart] == 'A' {
+		tamperedChar = "B"
+	}
+	tamperedToken := validToken[:sigStart] + tamperedChar + validToken[sigStart+1:]
 
 	tests := []struct {
 		name    string // <-- explicit name
@@ -133,6 +169,8 @@ func TestValidateToken(t *testing.T) {
 		{name: "malformed_token", token: "invalid.token.string", wantErr: true},
 		{name: "empty_token", token: "", wantErr: true},
 		{name: "tampered_signature", token: tamperedToken, wantErr: true},
+		{name: "hmac_signed", token: hmacToken, wantErr: true},
+		{name: "unknown_key", token: unknownKeyToken, wantErr: true},
 	}
 
 	for _, tt := range tests {
@@ -144,6 +182,7 @@ func TestValidateToken(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, claims)
 				assert.Equal(t, "valid@email.com", claims.Email)
+				assert.Equal(t, sessionID, claims.SessionID)
 			}
 		})
 	}
@@ -199,3 +238,66 @@ func TestHashToken(t *testing.T) {
 		})
 	}
 }
+
+func TestRotateRefreshToken(t *testing.T) {
+	old := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New(), Name: "Work laptop"}
+	expiresAt := time.Now().Add(time.Hour)
+	device := Device{Name: "ignored", UserAgent: "curl/8.0", IPAddress: "203.0.113.7"}
+
+	t.Run("revokes the old token and continues its session", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2 WHERE id = \$3 AND revoked_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), model.RevokedReasonRotated, old.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`INSERT INTO "auth"."refresh_tokens"`).
+			WithArgs(old.UserID, old.FamilyID, "new-hash", expiresAt, sqlmock.AnyArg(), nil, "", "Work laptop", "curl/8.0", "203.0.113.7", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		require.NoError(t, RotateRefreshToken(old, "new-hash", expiresAt, device))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	revokedConcurrently := func(t *testing.T, reason string) error {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2`).
+			WithArgs(sqlmock.AnyArg(), model.RevokedReasonRotated, old.ID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT "revoked_at","revoked_reason" FROM "auth"."refresh_tokens" WHERE id = \$1`).
+			WithArgs(old.ID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"revoked_at", "revoked_reason"}).AddRow(time.Now(), reason))
+		mock.ExpectRollback()
+
+		err := RotateRefreshToken(old, "new-hash", expiresAt, device)
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+		return err
+	}
+
+	t.Run("old token rotated concurrently", func(t *testing.T) {
+		assert.ErrorIs(t, revokedConcurrently(t, model.RevokedReasonRotated), ErrRefreshTokenReused)
+	})
+
+	t.Run("old token logged out concurrently", func(t *testing.T) {
+		assert.ErrorIs(t, revokedConcurrently(t, model.RevokedReasonLogout), ErrRefreshTokenRevoked)
+	})
+}
+
+func TestReportRefreshTokenReuse(t *testing.T) {
+	mock := setupMockDB(t)
+	token := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2 WHERE family_id = \$3 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), model.RevokedReasonReuse, token.FamilyID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`INSERT INTO "auth"."security_events"`).
+		WithArgs(token.UserID, model.SecurityEventRefreshTokenReuse, token.FamilyID, "203.0.113.7", "curl/8.0", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	require.NoError(t, ReportRefreshTokenReuse(token, "203.0.113.7", "curl/8.0"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
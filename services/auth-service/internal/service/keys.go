@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// Signing algorithms, by their JWS alg name
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RS256 keys
+const rsaKeyBits = 2048
+
+// signingKeysLock is the advisory lock that keeps replicas from rotating at
+// the same time
+const signingKeysLock = 7_240_915
+
+// keyEncryptionKeySize is the size of the AES-256 key that seals signing
+// keys at rest
+const keyEncryptionKeySize = 32
+
+var (
+	ErrNoSigningKey = errors.New("no signing key is active")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// SigningKeys holds the keys loaded by the KeyRotator
+var SigningKeys = &KeySet{}
+
+// signingKey is a stored key with its private half parsed
+type signingKey struct {
+	model.SigningKey
+	signer crypto.Signer
+}
+
+func (k signingKey) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+	return jwt.SigningMethodEdDSA
+}
+
+// KeySet is the in-memory copy of the signing keys, safe for concurrent use
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []signingKey
+}
+
+func (s *KeySet) set(keys []signingKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// current returns the key to sign with at now: the most recently activated
+// key that is signing
+func (s *KeySet) current(now time.Time) (*signingKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found *signingKey
+	for i := range s.keys {
+		k := &s.keys[i]
+		if k.IsSigning(now) && (found == nil || k.ActiveAt.After(found.ActiveAt)) {
+			found = k
+		}
+	}
+	if found == nil {
+		return nil, ErrNoSigningKey
+	}
+	return found, nil
+}
+
+// publicKey returns the public half of the published key kid and the
+// algorithm it signs with
+func (s *KeySet) publicKey(kid string, now time.Time) (crypto.PublicKey, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.ID == kid && k.IsPublished(now) {
+			return k.signer.Public(), k.Algorithm, nil
+		}
+	}
+	return nil, "", ErrUnknownKey
+}
+
+// JWK is a public key in JSON Web Key form (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// Crv and X are set for Ed25519 keys
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	// N and E are set for RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the keys published at now: upcoming, signing and retired
+// ones alike, so verifiers know a key before its first token and after its
+// last
+func (s *KeySet) JWKS(now time.Time) JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		if !k.IsPublished(now) {
+			continue
+		}
+
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Algorithm}
+		switch pub := k.signer.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// NewKeyEncryption returns the AES-256-GCM cipher that seals private keys
+// before they are stored, from a 32-byte data key
+func NewKeyEncryption(key []byte) (cipher.AEAD, error) {
+	if len(key) != keyEncryptionKeySize {
+		return nil, fmt.Errorf("key encryption key must be %d bytes, got %d", keyEncryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealPrivateKey encrypts der, prefixed with its nonce. The kid is bound as
+// additional data so a sealed key cannot be swapped onto another row.
+func sealPrivateKey(aead cipher.AEAD, kid string, der []byte) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	return aead.Seal(nonce, nonce, der, []byte(kid))
+}
+
+// openPrivateKey reverses sealPrivateKey
+func openPrivateKey(aead cipher.AEAD, kid string, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed key is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(kid))
+}
+
+// generateSigningKey creates a key for alg with a random kid, its private
+// half sealed with aead
+func generateSigningKey(alg string, aead cipher.AEAD) (*model.SigningKey, error) {
+	var private any
+	var err error
+	switch alg {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	kid := make([]byte, 8)
+	rand.Read(kid)
+	id := hex.EncodeToString(kid)
+
+	return &model.SigningKey{
+		ID:         id,
+		Algorithm:  alg,
+		PrivateKey: sealPrivateKey(aead, id, der),
+	}, nil
+}
+
+// parseSigningKey decrypts and parses the private half of a stored key
+func parseSigningKey(stored model.SigningKey, aead cipher.AEAD) (signingKey, error) {
+	der, err := openPrivateKey(aead, stored.ID, stored.PrivateKey)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("failed to decrypt signing key %s: %w", stored.ID, err)
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("failed to parse signing key %s: %w", stored.ID, err)
+	}
+
+	switch key := private.(type) {
+	case ed25519.PrivateKey:
+		if stored.Algorithm == AlgorithmEdDSA {
+			return signingKey{SigningKey: stored, signer: key}, nil
+		}
+	case *rsa.PrivateKey:
+		if stored.Algorithm == AlgorithmRS256 {
+			return signingKey{SigningKey: stored, signer: key}, nil
+		}
+	}
+	return signingKey{}, fmt.Errorf("signing key %s does not match algorithm %s", stored.ID, stored.Algorithm)
+}
+
+// KeyRotator keeps a signing key active at all times. Each key signs for
+// Interval. Its successor is created Prepublish before it retires, so
+// verifiers that cache the JWKS fetch it before it signs anything, and it
+// stays published for Overlap after it retires, which must cover the
+// lifetime of the access tokens it signed.
+type KeyRotator struct {
+	Algorithm  string
+	Interval   time.Duration
+	Prepublish time.Duration
+	Overlap    time.Duration
+	// Refresh is how often the keys are reloaded, picking up those created
+	// by other replicas
+	Refresh time.Duration
+	// Encryption seals private keys at rest; see NewKeyEncryption
+	Encryption cipher.AEAD
+	Keys       *KeySet
+}
+
+// Validate checks the rotator can run and that retired keys stay published
+// for as long as the access tokens they signed, which live accessTokenTTL
+func (r KeyRotator) Validate(accessTokenTTL time.Duration) error {
+	if r.Algorithm != AlgorithmEdDSA && r.Algorithm != AlgorithmRS256 {
+		return fmt.Errorf("unsupported signing algorithm %q", r.Algorithm)
+	}
+	if r.Interval <= 0 {
+		return fmt.Errorf("key rotation interval must be positive, got %s", r.Interval)
+	}
+	if r.Overlap < accessTokenTTL {
+		return fmt.Errorf("key overlap %s is shorter than the access token lifetime %s", r.Overlap, accessTokenTTL)
+	}
+	if r.Encryption == nil {
+		return errors.New("no key encryption key is configured")
+	}
+	return nil
+}
+
+// Run rotates every Refresh until ctx is done. Call RotateOnce first so the
+// service starts with a key.
+func (r KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := r.RotateOnce(time.Now()); err != nil {
+			log.Printf("Signing key rotation failed: %v", err)
+		}
+	}
+}
+
+// RotateOnce deletes expired keys, creates the next key when it is due, and
+// loads the published keys into Keys
+func (r KeyRotator) RotateOnce(now time.Time) error {
+	if r.Algorithm != AlgorithmEdDSA && r.Algorithm != AlgorithmRS256 {
+		return fmt.Errorf("unsupported signing algorithm %q", r.Algorithm)
+	}
+	if r.Encryption == nil {
+		return errors.New("no key encryption key is configured")
+	}
+
+	var stored []model.SigningKey
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeysLock).Error; err != nil {
+			return fmt.Errorf("failed to lock signing keys: %w", err)
+		}
+
+		if err := tx.Where("expires_at <= ?", now).Delete(&model.SigningKey{}).Error; err != nil {
+			return fmt.Errorf("failed to delete expired signing keys: %w", err)
+		}
+
+		if err := tx.Order("active_at").Find(&stored).Error; err != nil {
+			return fmt.Errorf("failed to load signing keys: %w", err)
+		}
+
+		activeAt, due := r.nextActivation(stored, now)
+		if !due {
+			return nil
+		}
+
+		key, err := generateSigningKey(r.Algorithm, r.Encryption)
+		if err != nil {
+			return err
+		}
+		key.ActiveAt = activeAt
+		key.RetireAt = activeAt.Add(r.Interval)
+		key.ExpiresAt = key.RetireAt.Add(r.Overlap)
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to store signing key: %w", err)
+		}
+		log.Printf("Created signing key %s (%s), active from %s", key.ID, key.Algorithm, key.ActiveAt.Format(time.RFC3339))
+
+		stored = append(stored, *key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := make([]signingKey, 0, len(stored))
+	for _, s := range stored {
+		key, err := parseSigningKey(s, r.Encryption)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	r.Keys.set(keys)
+	return nil
+}
+
+// nextActivation decides whether a key must be created at now and when it
+// should start signing. The first key activates at once; later ones when the
+// last key retires, created Prepublish ahead of that.
+func (r KeyRotator) nextActivation(keys []model.SigningKey, now time.Time) (time.Time, bool) {
+	sorted := append([]model.SigningKey(nil), keys...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].RetireAt.Before(sorted[j].RetireAt) })
+
+	if len(sorted) == 0 {
+		return now, true
+	}
+
+	last := sorted[len(sorted)-1]
+	if !last.RetireAt.After(now) {
+		// Nothing signs any more, say after downtime
+		return now, true
+	}
+	if last.RetireAt.Sub(now) <= r.Prepublish {
+		return last.RetireAt, true
+	}
+	return time.Time{}, false
+}
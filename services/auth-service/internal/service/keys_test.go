@@ -0,0 +1,292 @@
+package service
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func setupMockDB(t *testing.T) sqlmock.Sqlmock {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm connection: %v", err)
+	}
+
+	database.DB = gormDB
+	return mock
+}
+
+// testKeyEncryption seals the keys generated in tests
+var testKeyEncryption = func() cipher.AEAD {
+	aead, err := NewKeyEncryption(make([]byte, keyEncryptionKeySize))
+	if err != nil {
+		panic(err)
+	}
+	return aead
+}()
+
+func mustGenerateSigningKey(t *testing.T, alg string) *model.SigningKey {
+	key, err := generateSigningKey(alg, testKeyEncryption)
+	require.NoError(t, err)
+	return key
+}
+
+// testJWTConfig returns a config with one key signing now
+func testJWTConfig(t *testing.T) JWTConfig {
+	stored := mustGenerateSigningKey(t, AlgorithmEdDSA)
+	stored.ActiveAt = time.Now().Add(-time.Hour)
+	stored.RetireAt = time.Now().Add(time.Hour)
+	stored.ExpiresAt = time.Now().Add(2 * time.Hour)
+
+	key, err := parseSigningKey(*stored, testKeyEncryption)
+	require.NoError(t, err)
+
+	keys := &KeySet{}
+	keys.set([]signingKey{key})
+	return JWTConfig{Keys: keys, Issuer: "task-management-auth", AccessTokenDuration: 15 * time.Minute}
+}
+
+func TestKeySet(t *testing.T) {
+	now := time.Now()
+	newKey := func(alg string, activeAt, retireAt, expiresAt time.Time) signingKey {
+		stored := mustGenerateSigningKey(t, alg)
+		stored.ActiveAt, stored.RetireAt, stored.ExpiresAt = activeAt, retireAt, expiresAt
+		key, err := parseSigningKey(*stored, testKeyEncryption)
+		require.NoError(t, err)
+		return key
+	}
+
+	retired := newKey(AlgorithmEdDSA, now.Add(-2*time.Hour), now.Add(-time.Minute), now.Add(time.Hour))
+	signing := newKey(AlgorithmRS256, now.Add(-time.Minute), now.Add(time.Hour), now.Add(2*time.Hour))
+	upcoming := newKey(AlgorithmEdDSA, now.Add(time.Hour), now.Add(2*time.Hour), now.Add(3*time.Hour))
+	expired := newKey(AlgorithmEdDSA, now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour))
+
+	keys := &KeySet{}
+	keys.set([]signingKey{retired, signing, upcoming, expired})
+
+	t.Run("signs with the active key", func(t *testing.T) {
+		current, err := keys.current(now)
+		require.NoError(t, err)
+		assert.Equal(t, signing.ID, current.ID)
+	})
+
+	t.Run("verifies with any published key", func(t *testing.T) {
+		for _, k := range []signingKey{retired, signing, upcoming} {
+			_, alg, err := keys.publicKey(k.ID, now)
+			require.NoError(t, err)
+			assert.Equal(t, k.Algorithm, alg)
+		}
+
+		_, _, err := keys.publicKey(expired.ID, now)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("publishes every unexpired key", func(t *testing.T) {
+		jwks := keys.JWKS(now)
+		require.Len(t, jwks.Keys, 3)
+
+		byKid := map[string]JWK{}
+		for _, jwk := range jwks.Keys {
+			byKid[jwk.Kid] = jwk
+		}
+		assert.NotContains(t, byKid, expired.ID)
+
+		okp := byKid[retired.ID]
+		assert.Equal(t, "OKP", okp.Kty)
+		assert.Equal(t, "Ed25519", okp.Crv)
+		x, err := base64.RawURLEncoding.DecodeString(okp.X)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(retired.signer.Public().(ed25519.PublicKey)), x)
+
+		rsaJWK := byKid[signing.ID]
+		assert.Equal(t, "RSA", rsaJWK.Kty)
+		assert.Equal(t, "RS256", rsaJWK.Alg)
+		assert.Equal(t, "AQAB", rsaJWK.E)
+		n, err := base64.RawURLEncoding.DecodeString(rsaJWK.N)
+		require.NoError(t, err)
+		assert.Equal(t, signing.signer.Public().(*rsa.PublicKey).N.Bytes(), n)
+	})
+
+	t.Run("no key signing", func(t *testing.T) {
+		_, err := (&KeySet{}).current(now)
+		assert.ErrorIs(t, err, ErrNoSigningKey)
+	})
+}
+
+func TestNextActivation(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := KeyRotator{Interval: 24 * time.Hour, Prepublish: time.Hour, Overlap: time.Hour}
+
+	tests := []struct {
+		name     string
+		retireAt []time.Time
+		wantDue  bool
+		wantAt   time.Time
+	}{
+		{name: "no keys", wantDue: true, wantAt: now},
+		{name: "current key has long to run", retireAt: []time.Time{now.Add(2 * time.Hour)}, wantDue: false},
+		{name: "current key retires soon", retireAt: []time.Time{now.Add(30 * time.Minute)}, wantDue: true, wantAt: now.Add(30 * time.Minute)},
+		{name: "successor already created", retireAt: []time.Time{now.Add(30 * time.Minute), now.Add(24*time.Hour + 30*time.Minute)}, wantDue: false},
+		{name: "every key retired", retireAt: []time.Time{now.Add(-time.Hour)}, wantDue: true, wantAt: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []model.SigningKey
+			for _, retireAt := range tt.retireAt {
+				keys = append(keys, model.SigningKey{RetireAt: retireAt})
+			}
+
+			at, due := r.nextActivation(keys, now)
+
+			assert.Equal(t, tt.wantDue, due)
+			assert.Equal(t, tt.wantAt, at)
+		})
+	}
+}
+
+func TestRotateOnce(t *testing.T) {
+	now := time.Now()
+	keyColumns := []string{"id", "algorithm", "private_key", "active_at", "retire_at", "expires_at", "created_at"}
+	rotator := KeyRotator{Algorithm: AlgorithmEdDSA, Interval: 24 * time.Hour, Prepublish: time.Hour, Overlap: time.Hour, Encryption: testKeyEncryption}
+
+	expectLoad := func(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+			WithArgs(signingKeysLock).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM "auth"."signing_keys" WHERE expires_at <= \$1`).
+			WithArgs(now).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "auth"."signing_keys" ORDER BY active_at`).
+			WillReturnRows(rows)
+	}
+
+	t.Run("creates the first key", func(t *testing.T) {
+		mock := setupMockDB(t)
+		keys := &KeySet{}
+		rotator := rotator
+		rotator.Keys = keys
+
+		expectLoad(mock, sqlmock.NewRows(keyColumns))
+		mock.ExpectExec(`INSERT INTO "auth"."signing_keys"`).
+			WithArgs(sqlmock.AnyArg(), AlgorithmEdDSA, sqlmock.AnyArg(), now, now.Add(24*time.Hour), now.Add(25*time.Hour), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, rotator.RotateOnce(now))
+
+		current, err := keys.current(now)
+		require.NoError(t, err)
+		assert.Equal(t, AlgorithmEdDSA, current.Algorithm)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("loads the stored keys when none is due", func(t *testing.T) {
+		mock := setupMockDB(t)
+		keys := &KeySet{}
+		rotator := rotator
+		rotator.Keys = keys
+		stored := mustGenerateSigningKey(t, AlgorithmEdDSA)
+
+		expectLoad(mock, sqlmock.NewRows(keyColumns).
+			AddRow(stored.ID, stored.Algorithm, stored.PrivateKey, now.Add(-time.Hour), now.Add(12*time.Hour), now.Add(13*time.Hour), now))
+		mock.ExpectCommit()
+
+		require.NoError(t, rotator.RotateOnce(now))
+
+		assert.Len(t, keys.JWKS(now).Keys, 1)
+		current, err := keys.current(now)
+		require.NoError(t, err)
+		assert.Equal(t, stored.ID, current.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		rotator := rotator
+		rotator.Algorithm = "HS256"
+
+		assert.Error(t, rotator.RotateOnce(now))
+	})
+}
+
+func TestKeyEncryption(t *testing.T) {
+	stored := mustGenerateSigningKey(t, AlgorithmEdDSA)
+
+	t.Run("stores no plaintext key", func(t *testing.T) {
+		_, err := x509.ParsePKCS8PrivateKey(stored.PrivateKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("needs the same data key", func(t *testing.T) {
+		other, err := NewKeyEncryption(bytes.Repeat([]byte{1}, keyEncryptionKeySize))
+		require.NoError(t, err)
+
+		_, err = parseSigningKey(*stored, other)
+		assert.Error(t, err)
+	})
+
+	t.Run("is bound to the kid", func(t *testing.T) {
+		moved := *stored
+		moved.ID = "0000000000000000"
+
+		_, err := parseSigningKey(moved, testKeyEncryption)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects short data keys", func(t *testing.T) {
+		_, err := NewKeyEncryption(make([]byte, 16))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateKeyRotator(t *testing.T) {
+	valid := KeyRotator{Algorithm: AlgorithmEdDSA, Interval: 24 * time.Hour, Prepublish: time.Hour, Overlap: time.Hour, Encryption: testKeyEncryption}
+
+	tests := []struct {
+		name    string
+		modify  func(r *KeyRotator)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(r *KeyRotator) {}},
+		{name: "overlap equals the token lifetime", modify: func(r *KeyRotator) { r.Overlap = 15 * time.Minute }},
+		{name: "overlap shorter than the token lifetime", modify: func(r *KeyRotator) { r.Overlap = 5 * time.Minute }, wantErr: true},
+		{name: "unsupported algorithm", modify: func(r *KeyRotator) { r.Algorithm = "HS256" }, wantErr: true},
+		{name: "no interval", modify: func(r *KeyRotator) { r.Interval = 0 }, wantErr: true},
+		{name: "no encryption", modify: func(r *KeyRotator) { r.Encryption = nil }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid
+			tt.modify(&r)
+
+			err := r.Validate(15 * time.Minute)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
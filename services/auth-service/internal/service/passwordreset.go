@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/mailer"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+
+// PasswordResetConfig controls how users recover their account
+type PasswordResetConfig struct {
+	Mailer mailer.Mailer
+	// LinkURL is the page that submits the token and new password to
+	// POST /auth/password/reset; the token is added as its token query
+	// parameter
+	LinkURL  string
+	TokenTTL time.Duration
+}
+
+// PasswordReset is set from the environment at startup
+var PasswordReset = PasswordResetConfig{
+	Mailer:   mailer.LogMailer{},
+	LinkURL:  "http://localhost:3000/reset-password",
+	TokenTTL: time.Hour,
+}
+
+// SendPasswordResetEmail emails user a new password reset link. Links sent
+// earlier stop working, so only the latest email counts.
+func (c PasswordResetConfig) SendPasswordResetEmail(ctx context.Context, user *model.User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	tokenHash, err := HashToken(token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(c.TokenTTL),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store password reset token: %w", err)
+	}
+
+	link, err := tokenLink(c.LinkURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid password reset link URL: %w", err)
+	}
+
+	return c.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nChoose a new password by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not ask to reset your password, you can "+
+			"ignore this email; your password has not changed.",
+			user.Name, link, formatTTL(c.TokenTTL)),
+	})
+}
+
+// ResetPassword uses up token and sets its user's password to password. It
+// revokes all of the user's refresh tokens, logging them out everywhere, and
+// marks their address verified, since they received the link.
+func ResetPassword(token, password string) (*model.User, error) {
+	if err := ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	tokenHash, err := HashToken(token)
+	if err != nil {
+		return nil, err
+	}
+	// Hash before taking the row lock; bcrypt is deliberately slow
+	passwordHash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var stored model.PasswordResetToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&stored).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		if stored.IsUsed() || stored.IsExpired() {
+			return ErrInvalidResetToken
+		}
+
+		now := time.Now()
+		if err := tx.Model(&stored).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).
+			Where("id = ?", stored.UserID).
+			Updates(map[string]interface{}{
+				"password_hash":     passwordHash,
+				"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", now),
+			}).Error; err != nil {
+			return err
+		}
+		if err := revokeUserRefreshTokens(tx, stored.UserID, model.RevokedReasonPasswordReset).Error; err != nil {
+			return err
+		}
+
+		return tx.First(&user, "id = ?", stored.UserID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
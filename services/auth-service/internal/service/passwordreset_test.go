@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/mailer"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+)
+
+// passwordMatches checks that the argument is a bcrypt hash of password
+type passwordMatches string
+
+func (p passwordMatches) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	return ok && CheckPassword(string(p), hash)
+}
+
+func TestSendPasswordResetEmail(t *testing.T) {
+	mock := setupMockDB(t)
+	outbox := &mailer.MemoryMailer{}
+	cfg := PasswordResetConfig{Mailer: outbox, LinkURL: "https://app.example.com/reset-password", TokenTTL: time.Hour}
+	user := &model.User{ID: uuid.New(), Email: "user@example.com", Name: "Zoë"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "auth"."password_reset_tokens" SET "used_at"=\$1 WHERE user_id = \$2 AND used_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "auth"."password_reset_tokens"`).
+		WithArgs(user.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	require.NoError(t, cfg.SendPasswordResetEmail(context.Background(), user))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	messages := outbox.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "user@example.com", messages[0].To)
+	assert.Contains(t, messages[0].Body, "https://app.example.com/reset-password?token=")
+	assert.Contains(t, messages[0].Body, "expires in 1 hour")
+}
+
+func TestResetPassword(t *testing.T) {
+	userID := uuid.New()
+	token := "emailed-token"
+	tokenHash, _ := HashToken(token)
+	tokenColumns := []string{"id", "user_id", "token_hash", "expires_at", "used_at", "created_at"}
+
+	expectToken := func(mock sqlmock.Sqlmock, expiresAt time.Time, usedAt *time.Time) uuid.UUID {
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "auth"."password_reset_tokens" WHERE token_hash = \$1 .* FOR UPDATE`).
+			WithArgs(tokenHash, 1).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(id, userID, tokenHash, expiresAt, usedAt, time.Now()))
+		return id
+	}
+
+	t.Run("sets the password and revokes every session", func(t *testing.T) {
+		mock := setupMockDB(t)
+		id := expectToken(mock, time.Now().Add(time.Hour), nil)
+		mock.ExpectExec(`UPDATE "auth"."password_reset_tokens" SET "used_at"=\$1 WHERE "id" = \$2`).
+			WithArgs(sqlmock.AnyArg(), id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "auth"."users" SET "email_verified_at"=COALESCE\(email_verified_at, \$1\),"password_hash"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+			WithArgs(sqlmock.AnyArg(), passwordMatches("new-password"), sqlmock.AnyArg(), userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2 WHERE user_id = \$3 AND revoked_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), model.RevokedReasonPasswordReset, userID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectQuery(`SELECT \* FROM "auth"."users" WHERE id = \$1`).
+			WithArgs(userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(userID, "user@example.com"))
+		mock.ExpectCommit()
+
+		user, err := ResetPassword(token, "new-password")
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, user.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		usedAt := time.Now().Add(-time.Minute)
+		expectToken(mock, time.Now().Add(time.Hour), &usedAt)
+		mock.ExpectRollback()
+
+		_, err := ResetPassword(token, "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		expectToken(mock, time.Now().Add(-time.Minute), nil)
+		mock.ExpectRollback()
+
+		_, err := ResetPassword(token, "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "auth"."password_reset_tokens"`).
+			WillReturnRows(sqlmock.NewRows(tokenColumns))
+		mock.ExpectRollback()
+
+		_, err := ResetPassword(token, "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("password too long", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		_, err := ResetPassword(token, strings.Repeat("a", MaxPasswordBytes+1))
+
+		assert.ErrorIs(t, err, ErrPasswordTooLong)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows up to Limit events per key in any Window. It keeps its
+// counts in memory, so each replica enforces the limit separately.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, events: map[string][]time.Time{}}
+}
+
+// Allow records an event for key at now if the limit permits it. Otherwise
+// it returns false and how long until the next event would be allowed.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop keys that have gone quiet so the map does not grow forever
+	if now.Sub(l.lastSweep) >= l.window {
+		for k, times := range l.events {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= l.window {
+				delete(l.events, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	recent := l.events[key][:0]
+	for _, t := range l.events[key] {
+		if now.Sub(t) < l.window {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= l.limit {
+		l.events[key] = recent
+		return false, recent[0].Add(l.window).Sub(now)
+	}
+
+	l.events[key] = append(recent, now)
+	return true, 0
+}
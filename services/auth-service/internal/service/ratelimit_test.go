@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(2, time.Hour)
+
+	ok, _ := limiter.Allow("user@example.com", start)
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("user@example.com", start.Add(10*time.Minute))
+	assert.True(t, ok)
+
+	ok, retryAfter := limiter.Allow("user@example.com", start.Add(20*time.Minute))
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Minute, retryAfter)
+
+	// Other keys have their own allowance
+	ok, _ = limiter.Allow("other@example.com", start.Add(20*time.Minute))
+	assert.True(t, ok)
+
+	// The first event leaves the window
+	ok, _ = limiter.Allow("user@example.com", start.Add(time.Hour))
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("user@example.com", start.Add(time.Hour))
+	assert.False(t, ok)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Device describes where a refresh token is used
+type Device struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+// DeviceName derives a name such as "Firefox on Linux" from a User-Agent,
+// for sessions whose client did not name them
+func DeviceName(userAgent string) string {
+	// Order matters: Edge and Chrome also claim Safari, and Chrome on iOS
+	// claims neither
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"FxiOS/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"CriOS/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	systems := []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		return "Unknown device"
+	}
+}
+
+// ListSessions returns the user's active refresh tokens, one per session,
+// most recently used first. A session's ID is its token family.
+func ListSessions(userID uuid.UUID) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeSession revokes the user's refresh tokens in the session familyID.
+// It returns ErrSessionNotFound if the user has no active session by that ID.
+func RevokeSession(userID, familyID uuid.UUID) error {
+	result := database.DB.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": model.RevokedReasonSessionRevoked,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions revokes every refresh token the user holds and returns
+// how many were active
+func RevokeAllSessions(userID uuid.UUID) (int64, error) {
+	result := revokeUserRefreshTokens(database.DB, userID, model.RevokedReasonLogoutAll)
+	return result.RowsAffected, result.Error
+}
+
+// revokeUserRefreshTokens revokes every refresh token the user holds,
+// recording reason
+func revokeUserRefreshTokens(tx *gorm.DB, userID uuid.UUID, reason string) *gorm.DB {
+	return tx.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		})
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+)
+
+func TestDeviceName(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      string
+	}{
+		{name: "firefox_linux", userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", want: "Firefox on Linux"},
+		{name: "chrome_windows", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", want: "Chrome on Windows"},
+		{name: "edge_windows", userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", want: "Edge on Windows"},
+		{name: "safari_iphone", userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", want: "Safari on iOS"},
+		{name: "chrome_android", userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", want: "Chrome on Android"},
+		{name: "safari_mac", userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15", want: "Safari on macOS"},
+		{name: "unknown_client", userAgent: "curl/8.0", want: "Unknown device"},
+		{name: "empty", userAgent: "", want: "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DeviceName(tt.userAgent))
+		})
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	mock := setupMockDB(t)
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM "auth"."refresh_tokens" WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > \$2 ORDER BY last_used_at DESC`).
+		WithArgs(userID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "family_id", "name"}).
+			AddRow(uuid.New(), userID, uuid.New(), "Work laptop"))
+
+	sessions, err := ListSessions(userID)
+
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "Work laptop", sessions[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeSession(t *testing.T) {
+	userID := uuid.New()
+	familyID := uuid.New()
+
+	t.Run("revokes the session's tokens", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2 WHERE user_id = \$3 AND family_id = \$4 AND revoked_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), model.RevokedReasonSessionRevoked, userID, familyID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, RevokeSession(userID, familyID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("another user's or an ended session", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2`).
+			WithArgs(sqlmock.AnyArg(), model.RevokedReasonSessionRevoked, userID, familyID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, RevokeSession(userID, familyID), ErrSessionNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	mock := setupMockDB(t)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "auth"."refresh_tokens" SET "revoked_at"=\$1,"revoked_reason"=\$2 WHERE user_id = \$3 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), model.RevokedReasonLogoutAll, userID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	revoked, err := RevokeAllSessions(userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
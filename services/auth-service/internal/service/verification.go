@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/mailer"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// EmailVerificationConfig controls how users prove they own their address
+type EmailVerificationConfig struct {
+	Mailer mailer.Mailer
+	// LinkURL is the page that submits the token to POST /auth/verify-email;
+	// the token is added as its token query parameter
+	LinkURL  string
+	TokenTTL time.Duration
+	// Required stops unverified users from logging in or refreshing
+	Required bool
+}
+
+// EmailVerification is set from the environment at startup
+var EmailVerification = EmailVerificationConfig{
+	Mailer:   mailer.LogMailer{},
+	LinkURL:  "http://localhost:3000/verify-email",
+	TokenTTL: 24 * time.Hour,
+}
+
+// SendVerificationEmail emails user a new verification link. Links sent
+// earlier stop working, so only the latest email counts.
+func (c EmailVerificationConfig) SendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	tokenHash, err := HashToken(token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.EmailVerificationToken{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: now.Add(c.TokenTTL),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	link, err := tokenLink(c.LinkURL, token)
+	if err != nil {
+		return fmt.Errorf("invalid verification link URL: %w", err)
+	}
+
+	return c.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in %s. If you did not sign up, you can ignore this email.",
+			user.Name, link, formatTTL(c.TokenTTL)),
+	})
+}
+
+// VerifyEmail uses up token and marks its user's address verified
+func VerifyEmail(token string) (*model.User, error) {
+	tokenHash, err := HashToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var user model.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var stored model.EmailVerificationToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&stored).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidVerificationToken
+		}
+		if err != nil {
+			return err
+		}
+		if stored.IsUsed() || stored.IsExpired() {
+			return ErrInvalidVerificationToken
+		}
+
+		now := time.Now()
+		if err := tx.Model(&stored).Update("used_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.User{}).
+			Where("id = ? AND email_verified_at IS NULL", stored.UserID).
+			Update("email_verified_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.First(&user, "id = ?", stored.UserID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// tokenLink adds token to base as its token query parameter
+func tokenLink(base, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// formatTTL renders whole hours as "24 hours" and anything else as a duration
+func formatTTL(d time.Duration) string {
+	switch {
+	case d == time.Hour:
+		return "1 hour"
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/mailer"
+	"github.com/williamschweitzer/task-management-app/services/auth-service/internal/model"
+)
+
+func TestSendVerificationEmail(t *testing.T) {
+	mock := setupMockDB(t)
+	outbox := &mailer.MemoryMailer{}
+	cfg := EmailVerificationConfig{Mailer: outbox, LinkURL: "https://app.example.com/verify-email", TokenTTL: 24 * time.Hour}
+	user := &model.User{ID: uuid.New(), Email: "user@example.com", Name: "Zoë"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "auth"."email_verification_tokens" SET "used_at"=\$1 WHERE user_id = \$2 AND used_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), user.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "auth"."email_verification_tokens"`).
+		WithArgs(user.ID, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	require.NoError(t, cfg.SendVerificationEmail(context.Background(), user))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	messages := outbox.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "user@example.com", messages[0].To)
+	assert.Contains(t, messages[0].Body, "expires in 24 hours")
+
+	// The emailed link carries the token whose hash was stored
+	start := strings.Index(messages[0].Body, "https://")
+	require.NotEqual(t, -1, start)
+	link, err := url.Parse(strings.Fields(messages[0].Body[start:])[0])
+	require.NoError(t, err)
+	assert.Equal(t, "/verify-email", link.Path)
+	assert.Len(t, link.Query().Get("token"), 43)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	userID := uuid.New()
+	token := "emailed-token"
+	tokenHash, _ := HashToken(token)
+	tokenColumns := []string{"id", "user_id", "token_hash", "expires_at", "used_at", "created_at"}
+
+	expectToken := func(mock sqlmock.Sqlmock, expiresAt time.Time, usedAt *time.Time) uuid.UUID {
+		id := uuid.New()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "auth"."email_verification_tokens" WHERE token_hash = \$1 .* FOR UPDATE`).
+			WithArgs(tokenHash, 1).
+			WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(id, userID, tokenHash, expiresAt, usedAt, time.Now()))
+		return id
+	}
+
+	t.Run("verifies the address", func(t *testing.T) {
+		mock := setupMockDB(t)
+		id := expectToken(mock, time.Now().Add(time.Hour), nil)
+		mock.ExpectExec(`UPDATE "auth"."email_verification_tokens" SET "used_at"=\$1 WHERE "id" = \$2`).
+			WithArgs(sqlmock.AnyArg(), id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "auth"."users" SET "email_verified_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND email_verified_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "auth"."users" WHERE id = \$1`).
+			WithArgs(userID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_verified_at"}).AddRow(userID, "user@example.com", time.Now()))
+		mock.ExpectCommit()
+
+		user, err := VerifyEmail(token)
+
+		require.NoError(t, err)
+		assert.True(t, user.IsEmailVerified())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("used token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		usedAt := time.Now().Add(-time.Minute)
+		expectToken(mock, time.Now().Add(time.Hour), &usedAt)
+		mock.ExpectRollback()
+
+		_, err := VerifyEmail(token)
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		expectToken(mock, time.Now().Add(-time.Minute), nil)
+		mock.ExpectRollback()
+
+		_, err := VerifyEmail(token)
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "auth"."email_verification_tokens"`).
+			WillReturnRows(sqlmock.NewRows(tokenColumns))
+		mock.ExpectRollback()
+
+		_, err := VerifyEmail(token)
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
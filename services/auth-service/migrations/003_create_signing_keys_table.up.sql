@@ -0,0 +1,16 @@
+-- Keys the auth service signs access tokens with. Each key is published in
+-- the JWKS from its creation, signs from active_at until retire_at, and stays
+-- published until expires_at so tokens it signed keep verifying. private_key
+-- is PKCS #8 DER sealed with AES-256-GCM under JWT_KEY_ENCRYPTION_KEY, never
+-- plaintext.
+CREATE TABLE auth.signing_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    algorithm VARCHAR(16) NOT NULL,
+    private_key BYTEA NOT NULL,
+    active_at TIMESTAMP NOT NULL,
+    retire_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON auth.signing_keys(expires_at);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS auth.security_events;
+
+DROP INDEX IF EXISTS auth.idx_refresh_tokens_family_id;
+ALTER TABLE auth.refresh_tokens DROP COLUMN IF EXISTS revoked_reason;
+ALTER TABLE auth.refresh_tokens DROP COLUMN IF EXISTS family_id;
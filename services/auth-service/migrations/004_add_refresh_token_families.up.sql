@@ -0,0 +1,25 @@
+-- Refresh tokens rotated from one login form a family. Replaying a revoked
+-- member revokes the whole family; until now each token was its own family.
+ALTER TABLE auth.refresh_tokens ADD COLUMN family_id UUID;
+UPDATE auth.refresh_tokens SET family_id = id;
+ALTER TABLE auth.refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON auth.refresh_tokens(family_id);
+
+-- Only a token revoked by rotation counts as reused when presented again;
+-- logging out or revoking a session does not. Tokens revoked before this
+-- column existed have no reason and are simply rejected.
+ALTER TABLE auth.refresh_tokens ADD COLUMN revoked_reason VARCHAR(32) NOT NULL DEFAULT '';
+
+-- Security-relevant events, such as a refresh token replayed after rotation
+CREATE TABLE auth.security_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    type VARCHAR(64) NOT NULL,
+    family_id UUID,
+    ip_address VARCHAR(64),
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_security_events_user_id ON auth.security_events(user_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE auth.refresh_tokens
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS ip_address,
+    DROP COLUMN IF EXISTS last_used_at;
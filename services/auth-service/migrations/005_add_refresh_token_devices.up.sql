@@ -0,0 +1,10 @@
+-- Each refresh token family is a session on one device. Rotation copies the
+-- name and refreshes the rest, so the active token describes the session.
+ALTER TABLE auth.refresh_tokens
+    ADD COLUMN name VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN last_used_at TIMESTAMP;
+
+UPDATE auth.refresh_tokens SET last_used_at = created_at;
+ALTER TABLE auth.refresh_tokens ALTER COLUMN last_used_at SET NOT NULL;
@@ -0,0 +1,16 @@
+-- Users prove they own their address by following an emailed link. Existing
+-- users signed up before verification existed and are treated as verified.
+ALTER TABLE auth.users ADD COLUMN email_verified_at TIMESTAMP;
+UPDATE auth.users SET email_verified_at = created_at;
+
+-- Single-use verification links. Only a hash of each token is stored.
+CREATE TABLE auth.email_verification_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_verification_tokens_user_id ON auth.email_verification_tokens(user_id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS auth.password_reset_tokens;
@@ -0,0 +1,216 @@
+package smtpmail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts mail on a local port and records what it received.
+// Recipients in reject get a 550 at RCPT TO.
+type fakeSMTPServer struct {
+	listener net.Listener
+	reject   map[string]bool
+
+	mu       sync.Mutex
+	auth     string
+	from     string
+	to       []string
+	messages []string
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTPServer{listener: l, reject: map[string]bool{}}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost fake SMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		s.mu.Lock()
+		switch verb {
+		case "EHLO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "HELO":
+			reply("250 localhost")
+		case "AUTH":
+			s.auth = line
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			addr := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if s.reject[addr] {
+				reply("550 5.1.1 No such user")
+			} else {
+				s.to = append(s.to, addr)
+				reply("250 OK")
+			}
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					s.mu.Unlock()
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.messages = append(s.messages, data.String())
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSender(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("delivers a message", func(t *testing.T) {
+		server := startFakeSMTPServer(t)
+		sender, err := NewSender(Config{
+			Host:     "127.0.0.1",
+			Port:     server.port(),
+			Username: "accounts",
+			Password: "secret",
+			From:     "Accounts <accounts@example.com>",
+		})
+		require.NoError(t, err)
+
+		err = sender.Send(ctx, Message{
+			To:      "user@example.com",
+			Subject: `Welcome, "Zoë"`,
+			Body:    "Confirm your email address.\nThe link expires in 24 hours.",
+		})
+		require.NoError(t, err)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		assert.Equal(t, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00accounts\x00secret")), server.auth)
+		assert.Equal(t, "MAIL FROM:<accounts@example.com>", server.from)
+		assert.Equal(t, []string{"user@example.com"}, server.to)
+		require.Len(t, server.messages, 1)
+
+		msg, err := mail.ReadMessage(strings.NewReader(server.messages[0]))
+		require.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, `Welcome, "Zoë"`, subject)
+		assert.Equal(t, `"Accounts" <accounts@example.com>`, msg.Header.Get("From"))
+		assert.Equal(t, "<user@example.com>", msg.Header.Get("To"))
+		assert.Equal(t, "text/plain; charset=utf-8", msg.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		require.NoError(t, err)
+		assert.Equal(t, "Confirm your email address.\r\nThe link expires in 24 hours.", strings.TrimSuffix(string(body), "\r\n"))
+	})
+
+	t.Run("rejected recipients are an error", func(t *testing.T) {
+		server := startFakeSMTPServer(t)
+		server.reject["gone@example.com"] = true
+		sender, err := NewSender(Config{Host: "127.0.0.1", Port: server.port(), From: "accounts@example.com"})
+		require.NoError(t, err)
+
+		err = sender.Send(ctx, Message{To: "gone@example.com", Subject: "Hi", Body: "Hi"})
+
+		assert.ErrorContains(t, err, "RCPT TO failed")
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Empty(t, server.messages)
+	})
+
+	t.Run("header injection is refused before connecting", func(t *testing.T) {
+		sender, err := NewSender(Config{Host: "127.0.0.1", Port: 1, From: "accounts@example.com"})
+		require.NoError(t, err)
+
+		err = sender.Send(ctx, Message{To: "user@example.com", Subject: "Hi\r\nBcc: victim@example.com", Body: "Hi"})
+		assert.EqualError(t, err, "subject cannot contain line breaks")
+
+		err = sender.Send(ctx, Message{To: "user@example.com\r\nBcc: victim@example.com", Subject: "Hi", Body: "Hi"})
+		assert.Error(t, err)
+	})
+
+	t.Run("unreachable servers time out", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+		// Accept but never greet
+		go func() {
+			conn, err := l.Accept()
+			if err == nil {
+				defer conn.Close()
+				time.Sleep(time.Second)
+			}
+		}()
+
+		port := l.Addr().(*net.TCPAddr).Port
+		sender, err := NewSender(Config{Host: "127.0.0.1", Port: port, From: "accounts@example.com", Timeout: 50 * time.Millisecond})
+		require.NoError(t, err)
+
+		assert.Error(t, sender.Send(ctx, Message{To: "user@example.com", Subject: "Hi", Body: "Hi"}))
+	})
+
+	t.Run("configuration is validated", func(t *testing.T) {
+		_, err := NewSender(Config{From: "accounts@example.com"})
+		assert.Error(t, err)
+
+		_, err = NewSender(Config{Host: "smtp.example.com", From: "not an address"})
+		assert.Error(t, err)
+	})
+}
@@ -1,19 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+	// Recurrence timezones must resolve in images without system zoneinfo
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/handler"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/jobs"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/notify"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/storage"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/stream"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/utils"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/webhook"
 )
 
 func main() {
@@ -28,6 +39,96 @@ func main() {
 	}
 	defer database.Close()
 
+	// Configure attachment storage
+	if err := storage.Init(); err != nil {
+		log.Fatal("Failed to configure attachment storage:", err)
+	}
+	if maxBytes := os.Getenv("ATTACHMENT_MAX_BYTES"); maxBytes != "" {
+		limit, err := strconv.ParseInt(maxBytes, 10, 64)
+		if err != nil || limit <= 0 {
+			log.Fatal("ATTACHMENT_MAX_BYTES must be a positive number of bytes")
+		}
+		model.MaxAttachmentSize = limit
+	}
+
+	// Optimistic concurrency: make If-Match mandatory on task writes
+	handler.RequireIfMatch = os.Getenv("TASK_REQUIRE_IF_MATCH") == "true"
+
+	// Purge expired trash in the background
+	retention, err := getEnvDuration("TRASH_RETENTION", jobs.DefaultTrashRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+	purgeInterval, err := getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.TrashPurger{Retention: retention, Interval: purgeInterval, BatchSize: 500}.Run(context.Background())
+
+	// Respread board columns whose ranks have grown long
+	rebalanceInterval, err := getEnvDuration("RANK_REBALANCE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go jobs.RankRebalancer{MaxLength: jobs.DefaultMaxRankLength, Interval: rebalanceInterval, BatchSize: 100}.Run(context.Background())
+
+	// Send due-date reminders in the background
+	notifier, err := notify.FromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure notifications:", err)
+	}
+	reminderInterval, err := getEnvDuration("REMINDER_INTERVAL", time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reminderLease, err := getEnvDuration("REMINDER_LEASE", 5*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+	reminderStaleAfter, err := getEnvDuration("REMINDER_STALE_AFTER", 24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	owner := schedulerOwner()
+	go jobs.ReminderScheduler{
+		Notifier:    notifier,
+		Owner:       owner,
+		Interval:    reminderInterval,
+		Lease:       reminderLease,
+		StaleAfter:  reminderStaleAfter,
+		BatchSize:   100,
+		MaxAttempts: 5,
+	}.Run(context.Background())
+
+	// Send queued webhook deliveries in the background
+	webhookInterval, err := getEnvDuration("WEBHOOK_INTERVAL", 5*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	webhookTimeout, err := getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	allowPrivate := os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true"
+	go jobs.WebhookDispatcher{
+		Sender:      webhook.NewSender(webhookTimeout, allowPrivate),
+		Owner:       owner,
+		Interval:    webhookInterval,
+		Lease:       2 * webhookTimeout,
+		BatchSize:   50,
+		MaxAttempts: 10,
+	}.Run(context.Background())
+
+	// Relay task changes from every replica to this replica's live streams
+	dsn, err := database.DSN()
+	if err != nil {
+		log.Fatal(err)
+	}
+	go stream.Listener{DSN: dsn, Broker: stream.Events}.Run(context.Background())
+
+	// Verify access tokens with the auth-service's published keys
+	keys := utils.NewJWKS(getEnv("JWKS_URL", "http://auth-service:8080/.well-known/jwks.json"), 5*time.Minute)
+
 	// Get port configuration from environment
 	port := getEnv("TASK_SERVICE_PORT", "8081")
 
@@ -37,33 +138,128 @@ func main() {
 	// Middleware
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(utils.StripQueryToken) // Keep stream tokens out of the request log
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 
 	// CORS configuration
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8000"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User-ID"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-User-ID", "If-Match"},
+		ExposedHeaders:   []string{"Link", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	// Routes
-	r.Get("/health", healthHandler)
+	// Routes. Live task updates stay open far longer than the request
+	// timeout. EventSource cannot set headers, so this route alone also takes
+	// its token from the jwt query parameter.
+	r.With(utils.EventStreamAuthMiddleware(keys)).Get("/tasks/stream", handler.StreamTasks) // GET /tasks/stream (Server-Sent Events)
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+
+		r.Get("/health", healthHandler)
+
+		// Task routes
+		r.Route("/tasks", func(r chi.Router) {
+			r.Use(utils.AuthMiddleware(keys)) // Require JWT in all Task routes
+
+			r.Get("/", handler.ListTasks)                       // GET /tasks
+			r.Post("/", handler.CreateTask)                     // POST /tasks
+			r.Post("/bulk", handler.BulkTasks)                  // POST /tasks/bulk
+			r.Get("/export", handler.ExportTasks)               // GET /tasks/export?format=csv|json
+			r.Post("/import", handler.ImportTasks)              // POST /tasks/import?format=csv|json&dry_run=
+			r.Get("/search", handler.SearchTasks)               // GET /tasks/search?q=
+			r.Get("/trash", handler.ListTrash)                  // GET /tasks/trash
+			r.Delete("/trash", handler.EmptyTrash)              // DELETE /tasks/trash
+			r.Delete("/trash/{taskID}", handler.PurgeTask)      // DELETE /tasks/trash/:id
+			r.Get("/{taskID}", handler.GetTask)                 // GET /tasks/:id
+			r.Put("/{taskID}", handler.UpdateTask)              // PUT /tasks/:id
+			r.Delete("/{taskID}", handler.DeleteTask)           // DELETE /tasks/:id
+			r.Patch("/{taskID}/complete", handler.CompleteTask) // PATCH /tasks/:id/complete
+			r.Post("/{taskID}/restore", handler.RestoreTask)    // POST /tasks/:id/restore
+			r.Post("/{taskID}/move", handler.RepositionTask)    // POST /tasks/:id/move (board position)
+
+			r.Put("/{taskID}/labels/{labelID}", handler.AttachTaskLabel)    // PUT /tasks/:id/labels/:labelId
+			r.Delete("/{taskID}/labels/{labelID}", handler.DetachTaskLabel) // DELETE /tasks/:id/labels/:labelId
+
+			r.Put("/{taskID}/project", handler.MoveTask) // PUT /tasks/:id/project
+
+			r.Get("/{taskID}/subtasks", handler.ListSubtasks)   // GET /tasks/:id/subtasks
+			r.Get("/{taskID}/history", handler.ListTaskHistory) // GET /tasks/:id/history
+
+			r.Get("/{taskID}/comments", handler.ListComments)                 // GET /tasks/:id/comments
+			r.Post("/{taskID}/comments", handler.CreateComment)               // POST /tasks/:id/comments
+			r.Put("/{taskID}/comments/{commentID}", handler.UpdateComment)    // PUT /tasks/:id/comments/:commentId
+			r.Delete("/{taskID}/comments/{commentID}", handler.DeleteComment) // DELETE /tasks/:id/comments/:commentId
+
+			r.Get("/{taskID}/attachments", handler.ListAttachments)                    // GET /tasks/:id/attachments
+			r.Post("/{taskID}/attachments", handler.UploadAttachment)                  // POST /tasks/:id/attachments
+			r.Get("/{taskID}/attachments/{attachmentID}", handler.DownloadAttachment)  // GET /tasks/:id/attachments/:attachmentId
+			r.Delete("/{taskID}/attachments/{attachmentID}", handler.DeleteAttachment) // DELETE /tasks/:id/attachments/:attachmentId
+
+			r.Get("/{taskID}/reminders", handler.ListReminders)                  // GET /tasks/:id/reminders
+			r.Post("/{taskID}/reminders", handler.CreateReminder)                // POST /tasks/:id/reminders
+			r.Delete("/{taskID}/reminders/{reminderID}", handler.DeleteReminder) // DELETE /tasks/:id/reminders/:reminderId
+
+			r.Get("/{taskID}/checklist", handler.ListChecklistItems)              // GET /tasks/:id/checklist
+			r.Post("/{taskID}/checklist", handler.CreateChecklistItem)            // POST /tasks/:id/checklist
+			r.Put("/{taskID}/checklist/{itemID}", handler.UpdateChecklistItem)    // PUT /tasks/:id/checklist/:itemId
+			r.Delete("/{taskID}/checklist/{itemID}", handler.DeleteChecklistItem) // DELETE /tasks/:id/checklist/:itemId
+		})
+
+		// Label routes
+		r.Route("/labels", func(r chi.Router) {
+			r.Use(utils.AuthMiddleware(keys))
+
+			r.Get("/", handler.ListLabels)              // GET /labels
+			r.Post("/", handler.CreateLabel)            // POST /labels
+			r.Get("/{labelID}", handler.GetLabel)       // GET /labels/:id
+			r.Put("/{labelID}", handler.UpdateLabel)    // PUT /labels/:id
+			r.Delete("/{labelID}", handler.DeleteLabel) // DELETE /labels/:id
+		})
 
-	// Task routes
-	r.Route("/tasks", func(r chi.Router) {
-		r.Use(utils.AuthMiddleware(os.Getenv("JWT_SECRET"))) // Require JWT in all Task routes
+		// Project routes
+		r.Route("/projects", func(r chi.Router) {
+			r.Use(utils.AuthMiddleware(keys))
 
-		r.Get("/", handler.ListTasks)                       // GET /tasks
-		r.Post("/", handler.CreateTask)                     // POST /tasks
-		r.Get("/{taskID}", handler.GetTask)                 // GET /tasks/:id
-		r.Put("/{taskID}", handler.UpdateTask)              // PUT /tasks/:id
-		r.Delete("/{taskID}", handler.DeleteTask)           // DELETE /tasks/:id
-		r.Patch("/{taskID}/complete", handler.CompleteTask) // PATCH /tasks/:id/complete
+			r.Get("/", handler.ListProjects)                      // GET /projects
+			r.Post("/", handler.CreateProject)                    // POST /projects
+			r.Get("/{projectID}", handler.GetProject)             // GET /projects/:id
+			r.Put("/{projectID}", handler.UpdateProject)          // PUT /projects/:id
+			r.Delete("/{projectID}", handler.DeleteProject)       // DELETE /projects/:id
+			r.Get("/{projectID}/tasks", handler.ListProjectTasks) // GET /projects/:id/tasks
+
+			r.Get("/{projectID}/workflow", handler.GetProjectWorkflow)     // GET /projects/:id/workflow
+			r.Put("/{projectID}/workflow", handler.ReplaceProjectWorkflow) // PUT /projects/:id/workflow
+		})
+
+		// Webhook routes
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(utils.AuthMiddleware(keys))
+
+			r.Get("/", handler.ListWebhooks)                                // GET /webhooks
+			r.Post("/", handler.CreateWebhook)                              // POST /webhooks
+			r.Get("/{webhookID}", handler.GetWebhook)                       // GET /webhooks/:id
+			r.Put("/{webhookID}", handler.UpdateWebhook)                    // PUT /webhooks/:id
+			r.Delete("/{webhookID}", handler.DeleteWebhook)                 // DELETE /webhooks/:id
+			r.Get("/{webhookID}/deliveries", handler.ListWebhookDeliveries) // GET /webhooks/:id/deliveries
+			r.Post("/{webhookID}/test", handler.SendTestWebhook)            // POST /webhooks/:id/test
+		})
+
+		// Calendar feed routes
+		r.Route("/calendar", func(r chi.Router) {
+			r.Use(utils.AuthMiddleware(keys))
+
+			r.Get("/feed", handler.GetCalendarFeed)       // GET /calendar/feed
+			r.Post("/feed", handler.CreateCalendarFeed)   // POST /calendar/feed
+			r.Delete("/feed", handler.DeleteCalendarFeed) // DELETE /calendar/feed
+		})
+
+		// Calendar apps fetch feeds with the feed's secret token instead of a JWT
+		r.Get("/feeds/{token}.ics", handler.ServeCalendarFeed) // GET /feeds/:token.ics
 	})
 
 	// Start server
@@ -88,3 +284,26 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration parses a duration such as "720h" from the environment
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 720h", key)
+	}
+	return d, nil
+}
+
+// schedulerOwner names this replica in reminder and webhook delivery leases
+func schedulerOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "task-service"
+	}
+	return host + "-" + uuid.NewString()[:8]
+}
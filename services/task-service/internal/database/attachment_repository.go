@@ -0,0 +1,80 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// attachmentOf scopes a query to a single attachment on taskID
+func attachmentOf(taskID, attachmentID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND task_id = ?", attachmentID, taskID)
+	}
+}
+
+// GetAttachments returns the attachments on one of userID's tasks, oldest first
+func GetAttachments(userID, taskID uuid.UUID) ([]model.Attachment, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var attachments []model.Attachment
+	if err := DB.Where("task_id = ?", taskID).Order("created_at, id").Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment returns a single attachment on one of userID's tasks
+func GetAttachment(userID, taskID, attachmentID uuid.UUID) (*model.Attachment, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var attachment model.Attachment
+	if err := DB.Scopes(attachmentOf(taskID, attachmentID)).First(&attachment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// CreateAttachment records an uploaded file on one of userID's tasks. The blob
+// must already be stored under attachment.StorageKey.
+func CreateAttachment(userID uuid.UUID, attachment *model.Attachment) error {
+	if attachment == nil {
+		return fmt.Errorf("attachment cannot be nil")
+	}
+
+	if _, err := GetTask(userID, attachment.TaskID); err != nil {
+		return err
+	}
+
+	attachment.UserID = userID
+	return DB.Create(attachment).Error
+}
+
+// DeleteAttachment removes an attachment's row and returns it so the caller
+// can delete the blob
+func DeleteAttachment(userID, taskID, attachmentID uuid.UUID) (*model.Attachment, error) {
+	attachment, err := GetAttachment(userID, taskID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := DB.Delete(attachment).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return attachment, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var attachmentColumns = []string{"id", "task_id", "user_id", "filename", "content_type", "size", "storage_key", "created_at"}
+
+func TestDeleteAttachment(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	attachmentID := uuid.New()
+
+	t.Run("returns the deleted attachment", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."attachments" WHERE id = \$1 AND task_id = \$2`).
+			WithArgs(attachmentID, taskID, 1).
+			WillReturnRows(sqlmock.NewRows(attachmentColumns).
+				AddRow(attachmentID, taskID, userID, "spec.pdf", "application/pdf", 1024, "tasks/x/y", time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."attachments" WHERE "attachments"."id" = \$1`).
+			WithArgs(attachmentID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		attachment, err := DeleteAttachment(userID, taskID, attachmentID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "tasks/x/y", attachment.StorageKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("attachment not found", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."attachments"`).
+			WillReturnRows(sqlmock.NewRows(attachmentColumns))
+
+		attachment, err := DeleteAttachment(userID, taskID, attachmentID)
+
+		assert.ErrorIs(t, err, ErrAttachmentNotFound)
+		assert.Nil(t, attachment)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// CreateCalendarFeed gives userID a feed with a new token and returns the
+// token. A user's existing feed is replaced, so its old token stops working.
+func CreateCalendarFeed(userID uuid.UUID) (*model.CalendarFeed, string, error) {
+	token := model.NewCalendarFeedToken()
+	feed := model.CalendarFeed{
+		UserID:    userID,
+		TokenHash: model.HashCalendarFeedToken(token),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	err := DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"token_hash":       feed.TokenHash,
+			"created_at":       feed.CreatedAt,
+			"last_accessed_at": nil,
+		}),
+	}).Create(&feed).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &feed, token, nil
+}
+
+func GetCalendarFeed(userID uuid.UUID) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := DB.Where("user_id = ?", userID).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// DeleteCalendarFeed revokes userID's feed token
+func DeleteCalendarFeed(userID uuid.UUID) error {
+	result := DB.Where("user_id = ?", userID).Delete(&model.CalendarFeed{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// AccessCalendarFeed returns the feed token belongs to and records that it
+// was fetched
+func AccessCalendarFeed(token string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	err := DB.Where("token_hash = ?", model.HashCalendarFeedToken(token)).First(&feed).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := DB.Model(&feed).UpdateColumn("last_accessed_at", now).Error; err != nil {
+		return nil, err
+	}
+	feed.LastAccessedAt = &now
+
+	return &feed, nil
+}
+
+// GetCalendarTasks returns userID's tasks that have a due date, outside the
+// trash, soonest first
+func GetCalendarTasks(userID uuid.UUID) ([]model.Task, error) {
+	var tasks []model.Task
+	err := DB.Where("user_id = ? AND due_date IS NOT NULL", userID).
+		Order("due_date, id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
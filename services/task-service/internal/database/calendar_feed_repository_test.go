@@ -0,0 +1,111 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var calendarFeedColumns = []string{"id", "user_id", "token_hash", "last_accessed_at", "created_at"}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	feedID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"."calendar_feeds" \("user_id","token_hash","last_accessed_at","created_at"\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT \("user_id"\) DO UPDATE SET "created_at"=\$5,"last_accessed_at"=\$6,"token_hash"=\$7 RETURNING "id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(feedID))
+	mock.ExpectCommit()
+
+	feed, token, err := CreateCalendarFeed(userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, feedID, feed.ID)
+	assert.Equal(t, userID, feed.UserID)
+	assert.Equal(t, model.HashCalendarFeedToken(token), feed.TokenHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAccessCalendarFeed(t *testing.T) {
+	token := model.NewCalendarFeedToken()
+
+	t.Run("known token", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		feedID := uuid.New()
+		userID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."calendar_feeds" WHERE token_hash = \$1`).
+			WithArgs(model.HashCalendarFeedToken(token), 1).
+			WillReturnRows(sqlmock.NewRows(calendarFeedColumns).
+				AddRow(feedID, userID, model.HashCalendarFeedToken(token), nil, time.Now()))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."calendar_feeds" SET "last_accessed_at"=\$1 WHERE "id" = \$2`).
+			WithArgs(sqlmock.AnyArg(), feedID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		feed, err := AccessCalendarFeed(token)
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, feed.UserID)
+		assert.NotNil(t, feed.LastAccessedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."calendar_feeds" WHERE token_hash = \$1`).
+			WillReturnRows(sqlmock.NewRows(calendarFeedColumns))
+
+		_, err := AccessCalendarFeed(token)
+
+		assert.ErrorIs(t, err, ErrCalendarFeedNotFound)
+	})
+}
+
+func TestDeleteCalendarFeed(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "tasks"."calendar_feeds" WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := DeleteCalendarFeed(userID)
+
+	assert.ErrorIs(t, err, ErrCalendarFeedNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCalendarTasks(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND due_date IS NOT NULL\) AND "tasks"."deleted_at" IS NULL ORDER BY due_date, id`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(uuid.New(), "Due soon"))
+
+	tasks, err := GetCalendarTasks(userID)
+
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Due soon", tasks[0].Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
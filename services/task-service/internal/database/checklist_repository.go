@@ -0,0 +1,138 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var ErrChecklistItemNotFound = errors.New("checklist item not found")
+
+// Checklist positions are kept contiguous (0..n-1) per task so clients can
+// render and reorder by index without gaps.
+
+// checklistItemOf scopes a query to a single item of taskID
+func checklistItemOf(taskID, itemID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND task_id = ?", itemID, taskID)
+	}
+}
+
+func GetChecklist(userID, taskID uuid.UUID) ([]model.ChecklistItem, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var items []model.ChecklistItem
+	if err := DB.Where("task_id = ?", taskID).Order("position, created_at").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CreateChecklistItem appends item to the end of its task's checklist
+func CreateChecklistItem(userID uuid.UUID, item *model.ChecklistItem) error {
+	if item == nil {
+		return fmt.Errorf("checklist item cannot be nil")
+	}
+
+	if _, err := GetTask(userID, item.TaskID); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.ChecklistItem{}).Where("task_id = ?", item.TaskID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		item.Position = int(count)
+		return tx.Create(item).Error
+	})
+}
+
+// UpdateChecklistItem applies updates to an item and, when position is set,
+// moves it there and shifts the items in between to keep positions contiguous
+func UpdateChecklistItem(userID, taskID, itemID uuid.UUID, updates map[string]interface{}, position *int) (*model.ChecklistItem, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+
+	var item model.ChecklistItem
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(checklistItemOf(taskID, itemID)).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChecklistItemNotFound
+			}
+			return err
+		}
+
+		if position != nil && *position != item.Position {
+			var count int64
+			if err := tx.Model(&model.ChecklistItem{}).Where("task_id = ?", taskID).Count(&count).Error; err != nil {
+				return err
+			}
+
+			target := min(max(*position, 0), int(count)-1)
+			shift := tx.Model(&model.ChecklistItem{}).Where("task_id = ? AND id <> ?", taskID, itemID)
+			if target < item.Position {
+				shift = shift.Where("position >= ? AND position < ?", target, item.Position).
+					Update("position", gorm.Expr("position + 1"))
+			} else {
+				shift = shift.Where("position > ? AND position <= ?", item.Position, target).
+					Update("position", gorm.Expr("position - 1"))
+			}
+			if shift.Error != nil {
+				return fmt.Errorf("failed to reorder checklist: %w", shift.Error)
+			}
+
+			updates["position"] = target
+		}
+
+		if len(updates) > 0 {
+			if err := tx.Model(&model.ChecklistItem{}).Scopes(checklistItemOf(taskID, itemID)).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update checklist item: %w", err)
+			}
+		}
+
+		return tx.Scopes(checklistItemOf(taskID, itemID)).First(&item).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// DeleteChecklistItem removes an item and closes the gap it leaves in the positions
+func DeleteChecklistItem(userID, taskID, itemID uuid.UUID) error {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var item model.ChecklistItem
+		if err := tx.Scopes(checklistItemOf(taskID, itemID)).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChecklistItemNotFound
+			}
+			return err
+		}
+
+		if err := tx.Scopes(checklistItemOf(taskID, itemID)).Delete(&model.ChecklistItem{}).Error; err != nil {
+			return fmt.Errorf("failed to delete checklist item: %w", err)
+		}
+
+		return tx.Model(&model.ChecklistItem{}).
+			Where("task_id = ? AND position > ?", taskID, item.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
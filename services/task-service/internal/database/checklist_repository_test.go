@@ -0,0 +1,146 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var checklistColumns = []string{"id", "task_id", "text", "done", "position", "created_at", "updated_at"}
+
+// expectOwnedTask expects the ownership check every checklist call starts with
+func expectOwnedTask(mock sqlmock.Sqlmock, userID, taskID uuid.UUID) {
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+		WithArgs(taskID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+			taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now,
+		))
+}
+
+func TestCreateChecklistItem(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+
+	t.Run("appends at the end", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."checklist_items" WHERE task_id = \$1`).
+			WithArgs(taskID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectQuery(`INSERT INTO "tasks"."checklist_items"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		item := &model.ChecklistItem{TaskID: taskID, Text: "Write tests"}
+		err := CreateChecklistItem(userID, item)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, item.Position)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		err := CreateChecklistItem(userID, &model.ChecklistItem{TaskID: taskID, Text: "x"})
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateChecklistItem(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	itemID := uuid.New()
+	now := time.Now()
+
+	itemRows := func(position int) *sqlmock.Rows {
+		return sqlmock.NewRows(checklistColumns).AddRow(itemID, taskID, "Item", false, position, now, now)
+	}
+
+	t.Run("move up shifts items in between down", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		position := 0
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."checklist_items" WHERE id = \$1 AND task_id = \$2`).
+			WithArgs(itemID, taskID, 1).
+			WillReturnRows(itemRows(2))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."checklist_items"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		mock.ExpectExec(`UPDATE "tasks"."checklist_items" SET "position"=position \+ 1,.* WHERE \(task_id = \$\d+ AND id <> \$\d+\) AND \(position >= \$\d+ AND position < \$\d+\)`).
+			WithArgs(sqlmock.AnyArg(), taskID, itemID, 0, 2).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE "tasks"."checklist_items" SET .*"position"=\$\d+`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."checklist_items"`).
+			WillReturnRows(itemRows(0))
+		mock.ExpectCommit()
+
+		item, err := UpdateChecklistItem(userID, taskID, itemID, nil, &position)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, item.Position)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("item not found", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."checklist_items"`).
+			WillReturnRows(sqlmock.NewRows(checklistColumns))
+		mock.ExpectRollback()
+
+		item, err := UpdateChecklistItem(userID, taskID, itemID, map[string]interface{}{"done": true}, nil)
+
+		assert.ErrorIs(t, err, ErrChecklistItemNotFound)
+		assert.Nil(t, item)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteChecklistItem(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	taskID := uuid.New()
+	itemID := uuid.New()
+	now := time.Now()
+
+	expectOwnedTask(mock, userID, taskID)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."checklist_items"`).
+		WillReturnRows(sqlmock.NewRows(checklistColumns).AddRow(itemID, taskID, "Item", false, 1, now, now))
+	mock.ExpectExec(`DELETE FROM "tasks"."checklist_items" WHERE id = \$1 AND task_id = \$2`).
+		WithArgs(itemID, taskID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "tasks"."checklist_items" SET "position"=position - 1,.* WHERE task_id = \$\d+ AND position > \$\d+`).
+		WithArgs(sqlmock.AnyArg(), taskID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err := DeleteChecklistItem(userID, taskID, itemID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrNotCommentAuthor = errors.New("only the author can change this comment")
+)
+
+// commentOf scopes a query to a single comment on taskID
+func commentOf(taskID, commentID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND task_id = ?", commentID, taskID)
+	}
+}
+
+// GetComments returns the comments on one of userID's tasks, oldest first
+func GetComments(userID, taskID uuid.UUID) ([]model.Comment, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var comments []model.Comment
+	if err := DB.Where("task_id = ?", taskID).Order("created_at, id").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// CreateComment adds comment to one of userID's tasks with userID as its author
+func CreateComment(userID uuid.UUID, comment *model.Comment) error {
+	if comment == nil {
+		return fmt.Errorf("comment cannot be nil")
+	}
+
+	if _, err := GetTask(userID, comment.TaskID); err != nil {
+		return err
+	}
+
+	comment.UserID = userID
+	return DB.Create(comment).Error
+}
+
+// authoredComment loads a comment on one of userID's tasks and checks userID wrote it
+func authoredComment(userID, taskID, commentID uuid.UUID) (*model.Comment, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var comment model.Comment
+	if err := DB.Scopes(commentOf(taskID, commentID)).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+
+	if comment.UserID != userID {
+		return nil, ErrNotCommentAuthor
+	}
+
+	return &comment, nil
+}
+
+// UpdateComment replaces the body of a comment written by userID and marks it edited
+func UpdateComment(userID, taskID, commentID uuid.UUID, body string) (*model.Comment, error) {
+	comment, err := authoredComment(userID, taskID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"body":      body,
+		"edited_at": now,
+	}
+	if err := DB.Model(comment).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	comment.Body = body
+	comment.EditedAt = &now
+	return comment, nil
+}
+
+// DeleteComment soft-deletes a comment written by userID
+func DeleteComment(userID, taskID, commentID uuid.UUID) error {
+	comment, err := authoredComment(userID, taskID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := DB.Delete(comment).Error; err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	return nil
+}
+
+// GetCommentCounts returns the number of comments on each of taskIDs. Tasks
+// without comments are omitted.
+func GetCommentCounts(taskIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		TaskID uuid.UUID
+		Count  int
+	}
+	err := DB.Model(&model.Comment{}).
+		Select("task_id, COUNT(*) AS count").
+		Where("task_id IN ?", taskIDs).
+		Group("task_id").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.TaskID] = row.Count
+	}
+
+	return counts, nil
+}
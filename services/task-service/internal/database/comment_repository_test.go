@@ -0,0 +1,119 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var commentColumns = []string{"id", "task_id", "user_id", "body", "edited_at", "created_at", "updated_at", "deleted_at"}
+
+func TestGetComments(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	expectOwnedTask(mock, userID, taskID)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."comments" WHERE task_id = \$1 AND "comments"."deleted_at" IS NULL ORDER BY created_at, id`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(uuid.New(), taskID, userID, "First!", nil, now, now, nil))
+
+	comments, err := GetComments(userID, taskID)
+
+	require.NoError(t, err)
+	assert.Len(t, comments, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateComment(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	commentID := uuid.New()
+	now := time.Now()
+
+	t.Run("author edits and the comment is marked edited", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."comments" WHERE \(id = \$1 AND task_id = \$2\) AND "comments"."deleted_at" IS NULL`).
+			WithArgs(commentID, taskID, 1).
+			WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(commentID, taskID, userID, "Old", nil, now, now, nil))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."comments" SET "body"=\$1,"edited_at"=\$2,"updated_at"=\$3 WHERE "comments"."deleted_at" IS NULL AND "id" = \$4`).
+			WithArgs("New", sqlmock.AnyArg(), sqlmock.AnyArg(), commentID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		comment, err := UpdateComment(userID, taskID, commentID, "New")
+
+		require.NoError(t, err)
+		assert.Equal(t, "New", comment.Body)
+		assert.NotNil(t, comment.EditedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("someone else's comment", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."comments"`).
+			WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(commentID, taskID, uuid.New(), "Theirs", nil, now, now, nil))
+
+		comment, err := UpdateComment(userID, taskID, commentID, "Mine now")
+
+		assert.ErrorIs(t, err, ErrNotCommentAuthor)
+		assert.Nil(t, comment)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteComment(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	taskID := uuid.New()
+	commentID := uuid.New()
+	now := time.Now()
+
+	expectOwnedTask(mock, userID, taskID)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."comments"`).
+		WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(commentID, taskID, userID, "Oops", nil, now, now, nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "tasks"."comments" SET "deleted_at"=\$1 WHERE "comments"."id" = \$2 AND "comments"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), commentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := DeleteComment(userID, taskID, commentID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCommentCounts(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	busy, quiet := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT task_id, COUNT\(\*\) AS count FROM "tasks"."comments" WHERE task_id IN \(\$1,\$2\) AND "comments"."deleted_at" IS NULL GROUP BY "task_id"`).
+		WithArgs(busy, quiet).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "count"}).AddRow(busy, 3))
+
+	counts, err := GetCommentCounts([]uuid.UUID{busy, quiet})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, counts[busy])
+	assert.Zero(t, counts[quiet])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -12,7 +12,8 @@ import (
 
 var DB *gorm.DB
 
-func Connect() error {
+// DSN builds the connection string from the DB_* environment variables
+func DSN() (string, error) {
 	host := os.Getenv("DB_HOST")
 	port := os.Getenv("DB_PORT")
 	user := os.Getenv("DB_USER")
@@ -22,7 +23,7 @@ func Connect() error {
 
 	// Validate required fields
 	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
-		return fmt.Errorf("missing required database environment variables")
+		return "", fmt.Errorf("missing required database environment variables")
 	}
 
 	// Default to require for RDS connections
@@ -30,12 +31,18 @@ func Connect() error {
 		sslmode = "require"
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode)
-
 	log.Printf("Connecting to database at %s:%s (sslmode=%s)", host, port, sslmode)
 
-	var err error
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, user, password, dbname, sslmode), nil
+}
+
+func Connect() error {
+	dsn, err := DSN()
+	if err != nil {
+		return err
+	}
+
 	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLabelNotFound  = errors.New("label not found")
+	ErrLabelNameTaken = errors.New("a label with this name already exists")
+)
+
+// labelOwnedBy scopes a query to a single label belonging to userID
+func labelOwnedBy(userID, labelID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND user_id = ?", labelID, userID)
+	}
+}
+
+// labelNameTaken reports whether userID already has a label called name (case-insensitive),
+// ignoring the label with excludeID so renames to the same name are allowed
+func labelNameTaken(userID uuid.UUID, name string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := DB.Model(&model.Label{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// isLabelNameViolation reports whether err is the unique index on a user's
+// label names rejecting a write. A concurrent request can claim the name
+// after labelNameTaken has checked it.
+func isLabelNameViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_labels_user_name"
+}
+
+func CreateLabel(label *model.Label) error {
+	if label == nil {
+		return fmt.Errorf("label cannot be nil")
+	}
+
+	taken, err := labelNameTaken(label.UserID, label.Name, uuid.Nil)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrLabelNameTaken
+	}
+
+	if err := DB.Create(label).Error; err != nil {
+		if isLabelNameViolation(err) {
+			return ErrLabelNameTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+func GetLabelsByUserID(userID uuid.UUID) ([]model.Label, error) {
+	var labels []model.Label
+
+	if err := DB.Where("user_id = ?", userID).Order("name").Find(&labels).Error; err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+func GetLabel(userID, labelID uuid.UUID) (*model.Label, error) {
+	return getLabel(DB, userID, labelID)
+}
+
+func getLabel(db *gorm.DB, userID, labelID uuid.UUID) (*model.Label, error) {
+	var label model.Label
+	if err := db.Scopes(labelOwnedBy(userID, labelID)).First(&label).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLabelNotFound
+		}
+		return nil, err
+	}
+
+	return &label, nil
+}
+
+func UpdateLabel(userID, labelID uuid.UUID, updates map[string]interface{}) (*model.Label, error) {
+	if name, ok := updates["name"].(string); ok {
+		taken, err := labelNameTaken(userID, name, labelID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrLabelNameTaken
+		}
+	}
+
+	result := DB.Model(&model.Label{}).Scopes(labelOwnedBy(userID, labelID)).Updates(updates)
+	if isLabelNameViolation(result.Error) {
+		return nil, ErrLabelNameTaken
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update label: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrLabelNotFound
+	}
+
+	return GetLabel(userID, labelID)
+}
+
+// DeleteLabel removes a label; its task attachments are removed by ON DELETE CASCADE
+func DeleteLabel(userID, labelID uuid.UUID) error {
+	result := DB.Scopes(labelOwnedBy(userID, labelID)).Delete(&model.Label{})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete label: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrLabelNotFound
+	}
+
+	return nil
+}
+
+// AttachLabel adds labelID to taskID. Both must belong to userID. Attaching a
+// label that is already present is a no-op.
+func AttachLabel(userID, taskID, labelID uuid.UUID) error {
+	return attachLabel(DB, userID, taskID, labelID)
+}
+
+func attachLabel(db *gorm.DB, userID, taskID, labelID uuid.UUID) error {
+	if _, err := getTask(db, userID, taskID); err != nil {
+		return err
+	}
+	if _, err := getLabel(db, userID, labelID); err != nil {
+		return err
+	}
+
+	link := model.TaskLabel{TaskID: taskID, LabelID: labelID}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&link).Error; err != nil {
+		return fmt.Errorf("failed to attach label: %w", err)
+	}
+
+	return nil
+}
+
+// DetachLabel removes labelID from taskID. Both must belong to userID.
+func DetachLabel(userID, taskID, labelID uuid.UUID) error {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return err
+	}
+	if _, err := GetLabel(userID, labelID); err != nil {
+		return err
+	}
+
+	if err := DB.Where("task_id = ? AND label_id = ?", taskID, labelID).Delete(&model.TaskLabel{}).Error; err != nil {
+		return fmt.Errorf("failed to detach label: %w", err)
+	}
+
+	return nil
+}
+
+// taskLabelRow is a label joined with the task it is attached to
+type taskLabelRow struct {
+	model.Label
+	TaskID uuid.UUID `gorm:"column:task_id"`
+}
+
+// GetLabelsForTasks returns the labels attached to each of taskIDs, keyed by task ID
+func GetLabelsForTasks(userID uuid.UUID, taskIDs []uuid.UUID) (map[uuid.UUID][]model.Label, error) {
+	labels := make(map[uuid.UUID][]model.Label, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return labels, nil
+	}
+
+	var rows []taskLabelRow
+	err := DB.Table("tasks.labels").
+		Select("labels.*, task_labels.task_id").
+		Joins("JOIN tasks.task_labels ON task_labels.label_id = labels.id").
+		Where("labels.user_id = ? AND task_labels.task_id IN ?", userID, taskIDs).
+		Order("labels.name").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		labels[row.TaskID] = append(labels[row.TaskID], row.Label)
+	}
+
+	return labels, nil
+}
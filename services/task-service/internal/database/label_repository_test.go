@@ -0,0 +1,238 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var labelColumns = []string{"id", "user_id", "name", "color", "created_at", "updated_at"}
+
+func TestCreateLabel(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("successful creation", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels" WHERE user_id = \$1 AND LOWER\(name\) = LOWER\(\$2\)`).
+			WithArgs(userID, "bug", uuid.Nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."labels"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		err := CreateLabel(&model.Label{UserID: userID, Name: "bug", Color: "#d73a4a"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WithArgs(userID, "Bug", uuid.Nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		err := CreateLabel(&model.Label{UserID: userID, Name: "Bug", Color: "#d73a4a"})
+
+		assert.ErrorIs(t, err, ErrLabelNameTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("name claimed concurrently", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WithArgs(userID, "bug", uuid.Nil).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."labels"`).
+			WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_labels_user_name"})
+		mock.ExpectRollback()
+
+		err := CreateLabel(&model.Label{UserID: userID, Name: "bug", Color: "#d73a4a"})
+
+		assert.ErrorIs(t, err, ErrLabelNameTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateLabel(t *testing.T) {
+	userID := uuid.New()
+	labelID := uuid.New()
+
+	t.Run("name claimed concurrently", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WithArgs(userID, "bug", labelID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."labels" SET "name"=\$1,"updated_at"=\$2 WHERE id = \$3 AND user_id = \$4`).
+			WithArgs("bug", sqlmock.AnyArg(), labelID, userID).
+			WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_labels_user_name"})
+		mock.ExpectRollback()
+
+		_, err := UpdateLabel(userID, labelID, map[string]interface{}{"name": "bug"})
+
+		assert.ErrorIs(t, err, ErrLabelNameTaken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetLabel(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	labelID := uuid.New()
+	now := time.Now()
+
+	t.Run("label found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns).AddRow(labelID, userID, "bug", "#d73a4a", now, now))
+
+		label, err := GetLabel(userID, labelID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "bug", label.Name)
+	})
+
+	t.Run("label owned by another user", func(t *testing.T) {
+		otherUserID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, otherUserID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns))
+
+		label, err := GetLabel(otherUserID, labelID)
+
+		assert.ErrorIs(t, err, ErrLabelNotFound)
+		assert.Nil(t, label)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteLabel(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	labelID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(labelID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := DeleteLabel(userID, labelID)
+
+	assert.ErrorIs(t, err, ErrLabelNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttachLabel(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	labelID := uuid.New()
+	now := time.Now()
+
+	t.Run("attaches an owned label to an owned task", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "T", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns).AddRow(labelID, userID, "bug", "#d73a4a", now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_labels" .* ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		err := AttachLabel(userID, taskID, labelID)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cannot attach to another user's task", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		err := AttachLabel(userID, taskID, labelID)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cannot attach another user's label", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "T", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels"`).
+			WithArgs(labelID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns))
+
+		err := AttachLabel(userID, taskID, labelID)
+
+		assert.ErrorIs(t, err, ErrLabelNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListTasksLabelFilter(t *testing.T) {
+	userID := uuid.New()
+	bug, ui := uuid.New(), uuid.New()
+
+	t.Run("any of", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`WHERE user_id = \$1 AND id IN \(SELECT task_id FROM tasks.task_labels WHERE label_id IN \(\$2,\$3\)\) AND "tasks"."deleted_at" IS NULL ORDER BY`).
+			WithArgs(userID, bug, ui, DefaultTaskPageSize+1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		_, _, err := ListTasks(userID, TaskFilter{LabelIDs: []uuid.UUID{bug, ui}})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("all of", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`WHERE user_id = \$1 AND id IN \(SELECT task_id FROM tasks.task_labels WHERE label_id IN \(\$2,\$3\) GROUP BY task_id HAVING COUNT\(DISTINCT label_id\) = \$4\)`).
+			WithArgs(userID, bug, ui, 2, DefaultTaskPageSize+1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		_, _, err := ListTasks(userID, TaskFilter{LabelIDs: []uuid.UUID{bug, ui}, LabelMatchAll: true})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
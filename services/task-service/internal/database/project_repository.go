@@ -0,0 +1,224 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProjectNotFound = errors.New("project not found")
+	ErrProjectArchived = errors.New("project is archived")
+	ErrSubtaskProject  = errors.New("subtasks always belong to their parent's project")
+)
+
+// projectOwnedBy scopes a query to a single project belonging to userID
+func projectOwnedBy(userID, projectID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND user_id = ?", projectID, userID)
+	}
+}
+
+func CreateProject(project *model.Project) error {
+	if project == nil {
+		return fmt.Errorf("project cannot be nil")
+	}
+
+	return DB.Create(project).Error
+}
+
+// GetProjectsByUserID lists userID's projects by name, leaving out archived
+// ones unless includeArchived is set
+func GetProjectsByUserID(userID uuid.UUID, includeArchived bool) ([]model.Project, error) {
+	var projects []model.Project
+
+	query := DB.Where("user_id = ?", userID)
+	if !includeArchived {
+		query = query.Where("archived = ?", false)
+	}
+
+	if err := query.Order("name").Find(&projects).Error; err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func GetProject(userID, projectID uuid.UUID) (*model.Project, error) {
+	return getProject(DB, userID, projectID)
+}
+
+func getProject(db *gorm.DB, userID, projectID uuid.UUID) (*model.Project, error) {
+	var project model.Project
+	if err := db.Scopes(projectOwnedBy(userID, projectID)).First(&project).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotFound
+		}
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func UpdateProject(userID, projectID uuid.UUID, updates map[string]interface{}) (*model.Project, error) {
+	result := DB.Model(&model.Project{}).Scopes(projectOwnedBy(userID, projectID)).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update project: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrProjectNotFound
+	}
+
+	return GetProject(userID, projectID)
+}
+
+// DeleteProject removes a project. Its tasks are kept and have their
+// project_id cleared by ON DELETE SET NULL, so they first move to the default
+// workflow: statuses it lacks are remapped by category and completed_at is
+// brought in line with the new statuses.
+func DeleteProject(userID, projectID uuid.UUID) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := getProject(tx, userID, projectID); err != nil {
+			return err
+		}
+
+		from, err := loadWorkflow(tx, projectID)
+		if err != nil {
+			return err
+		}
+		to := model.DefaultWorkflow()
+
+		// Trashed tasks are remapped too so they can be restored later
+		tasks := tx.Unscoped().Model(&model.Task{}).Where("project_id = ?", projectID)
+
+		var used []string
+		if err := tasks.Session(&gorm.Session{}).Distinct().Pluck("status", &used).Error; err != nil {
+			return fmt.Errorf("failed to load task statuses: %w", err)
+		}
+
+		remap := remapStatusesForMove(used, from, &to)
+		for _, old := range used {
+			target, ok := remap[old]
+			if !ok {
+				continue
+			}
+			if err := tasks.Session(&gorm.Session{}).Where("status = ?", old).Update("status", target).Error; err != nil {
+				return fmt.Errorf("failed to remap status %s: %w", old, err)
+			}
+		}
+
+		if err := syncCompletedAt(tx, projectID, to); err != nil {
+			return err
+		}
+
+		result := tx.Scopes(projectOwnedBy(userID, projectID)).Delete(&model.Project{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete project: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrProjectNotFound
+		}
+
+		return nil
+	})
+}
+
+// ValidateProject checks that projectID belongs to userID and can take new tasks
+func ValidateProject(userID, projectID uuid.UUID) error {
+	return validateProject(DB, userID, projectID)
+}
+
+func validateProject(db *gorm.DB, userID, projectID uuid.UUID) error {
+	project, err := getProject(db, userID, projectID)
+	if err != nil {
+		return err
+	}
+
+	if project.Archived {
+		return ErrProjectArchived
+	}
+
+	return nil
+}
+
+// MoveTask puts a top-level task, together with its subtasks, into projectID,
+// or takes it out of any project when projectID is nil. Statuses the target
+// workflow lacks are replaced by its first status of the same category.
+// A non-zero ifVersion makes the move conditional on the task's version.
+func MoveTask(userID, taskID uuid.UUID, ifVersion int, projectID *uuid.UUID) (*model.Task, error) {
+	return moveTask(DB, userID, taskID, ifVersion, projectID)
+}
+
+func moveTask(db *gorm.DB, userID, taskID uuid.UUID, ifVersion int, projectID *uuid.UUID) (*model.Task, error) {
+	task, err := getTask(db, userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if ifVersion != 0 && task.Version != ifVersion {
+		return nil, ErrVersionConflict
+	}
+
+	if task.ParentID != nil {
+		return nil, ErrSubtaskProject
+	}
+
+	if projectID != nil {
+		if err := validateProject(db, userID, *projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	from, err := workflowFor(db, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := workflowFor(db, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// The status remapping was worked out for the version read above
+		if ifVersion != 0 {
+			if _, err := lockTask(tx, userID, taskID, ifVersion); err != nil {
+				return err
+			}
+		}
+
+		// Trashed subtasks move too, so restoring one keeps it in its parent's project
+		family := tx.Unscoped().Model(&model.Task{}).Where("user_id = ? AND (id = ? OR parent_id = ?)", userID, taskID, taskID)
+
+		var used []string
+		if err := family.Session(&gorm.Session{}).Distinct().Pluck("status", &used).Error; err != nil {
+			return fmt.Errorf("failed to load task statuses: %w", err)
+		}
+
+		remap := remapStatusesForMove(used, from, to)
+		for old, target := range remap {
+			if err := family.Session(&gorm.Session{}).Where("status = ?", old).Update("status", target).Error; err != nil {
+				return fmt.Errorf("failed to remap status %s: %w", old, err)
+			}
+		}
+
+		if err := family.Session(&gorm.Session{}).Update("project_id", projectID).Error; err != nil {
+			return fmt.Errorf("failed to move task: %w", err)
+		}
+
+		moved := *task
+		moved.ProjectID = projectID
+		if target, ok := remap[task.Status]; ok {
+			moved.Status = target
+		}
+		return recordTaskEvents(tx, taskChanges(userID, model.TaskEventUpdated, task, &moved))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return getTask(db, userID, taskID)
+}
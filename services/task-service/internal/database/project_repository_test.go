@@ -0,0 +1,209 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var projectColumns = []string{"id", "user_id", "name", "description", "color", "archived", "created_at", "updated_at"}
+
+func TestGetProjectsByUserID(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("hides archived projects by default", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE user_id = \$1 AND archived = \$2 ORDER BY name`).
+			WithArgs(userID, false).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+
+		_, err := GetProjectsByUserID(userID, false)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("includes archived projects on request", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE user_id = \$1 ORDER BY name`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+
+		_, err := GetProjectsByUserID(userID, true)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestValidateProject(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		rows    *sqlmock.Rows
+		wantErr error
+	}{
+		{
+			name:    "active project",
+			rows:    sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", false, now, now),
+			wantErr: nil,
+		},
+		{
+			name:    "archived project",
+			rows:    sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", true, now, now),
+			wantErr: ErrProjectArchived,
+		},
+		{
+			name:    "project owned by another user",
+			rows:    sqlmock.NewRows(projectColumns),
+			wantErr: ErrProjectNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gormDB, mock := setupMockDB(t)
+			DB = gormDB
+
+			mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE id = \$1 AND user_id = \$2`).
+				WithArgs(projectID, userID, 1).
+				WillReturnRows(tt.rows)
+
+			err := ValidateProject(userID, projectID)
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMoveTask(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "project_id", "parent_id")
+
+	t.Run("moves the task and its subtasks", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, nil, nil))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", false, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses" WHERE project_id = \$1`).
+			WithArgs(projectID).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT DISTINCT "status" FROM "tasks"."tasks" WHERE user_id = \$1 AND \(id = \$2 OR parent_id = \$3\)`).
+			WithArgs(userID, taskID, taskID).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("todo").AddRow("done"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "project_id"=\$1,.* WHERE user_id = \$\d+ AND \(id = \$\d+ OR parent_id = \$\d+\)`).
+			WithArgs(projectID, sqlmock.AnyArg(), userID, taskID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(taskID, userID, "updated", "project_id", nil, projectID.String()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, projectID, nil))
+
+		task, err := MoveTask(userID, taskID, 0, &projectID)
+
+		require.NoError(t, err)
+		assert.Equal(t, &projectID, task.ProjectID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("subtasks cannot be moved on their own", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, nil, uuid.New()))
+
+		task, err := MoveTask(userID, taskID, 0, &projectID)
+
+		assert.ErrorIs(t, err, ErrSubtaskProject)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteProject(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+
+	t.Run("moves its tasks to the default workflow first", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(projectID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", false, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses" WHERE project_id = \$1 ORDER BY position`).
+			WithArgs(projectID).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns).
+				AddRow(uuid.New(), projectID, "backlog", "Backlog", "not-started", 0).
+				AddRow(uuid.New(), projectID, "in-review", "In Review", "active", 1).
+				AddRow(uuid.New(), projectID, "shipped", "Shipped", "done", 2))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_transitions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"project_id", "from_status", "to_status"}))
+		mock.ExpectQuery(`SELECT DISTINCT "status" FROM "tasks"."tasks" WHERE project_id = \$1`).
+			WithArgs(projectID).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("in-review").AddRow("shipped"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "status"=\$1,.* WHERE project_id = \$\d+ AND status = \$\d+`).
+			WithArgs("in-progress", sqlmock.AnyArg(), projectID, "in-review").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "status"=\$1,.* WHERE project_id = \$\d+ AND status = \$\d+`).
+			WithArgs("done", sqlmock.AnyArg(), projectID, "shipped").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,.* WHERE project_id = \$\d+ AND status IN \(\$\d+\) AND completed_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), projectID, "done").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,.* WHERE project_id = \$\d+ AND status NOT IN \(\$\d+\) AND completed_at IS NOT NULL`).
+			WithArgs(nil, sqlmock.AnyArg(), projectID, "done").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM "tasks"."projects" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(projectID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, DeleteProject(userID, projectID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("another user's project", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WithArgs(projectID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, DeleteProject(userID, projectID), ErrProjectNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
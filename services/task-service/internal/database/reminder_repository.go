@@ -0,0 +1,205 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReminderNotFound = errors.New("reminder not found")
+	ErrReminderExists   = errors.New("the task already has a reminder at this offset")
+)
+
+// reminderOf scopes a query to a single reminder of taskID
+func reminderOf(taskID, reminderID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND task_id = ?", reminderID, taskID)
+	}
+}
+
+// withRemindAt fills in when each reminder fires for task's due date
+func withRemindAt(task *model.Task, reminders []model.Reminder) {
+	if task.DueDate == nil {
+		return
+	}
+	for i := range reminders {
+		at := reminders[i].At(*task.DueDate)
+		reminders[i].RemindAt = &at
+	}
+}
+
+// GetReminders returns taskID's reminders, earliest first
+func GetReminders(userID, taskID uuid.UUID) ([]model.Reminder, error) {
+	task, err := GetTask(userID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []model.Reminder
+	if err := DB.Where("task_id = ?", taskID).Order("offset_minutes DESC").Find(&reminders).Error; err != nil {
+		return nil, err
+	}
+
+	withRemindAt(task, reminders)
+	return reminders, nil
+}
+
+func CreateReminder(userID uuid.UUID, reminder *model.Reminder) error {
+	if reminder == nil {
+		return fmt.Errorf("reminder cannot be nil")
+	}
+
+	task, err := GetTask(userID, reminder.TaskID)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	err = DB.Model(&model.Reminder{}).
+		Where("task_id = ? AND offset_minutes = ?", reminder.TaskID, reminder.OffsetMinutes).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrReminderExists
+	}
+
+	reminder.UserID = userID
+	if err := DB.Create(reminder).Error; err != nil {
+		return err
+	}
+
+	if task.DueDate != nil {
+		at := reminder.At(*task.DueDate)
+		reminder.RemindAt = &at
+	}
+	return nil
+}
+
+func DeleteReminder(userID, taskID, reminderID uuid.UUID) error {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return err
+	}
+
+	result := DB.Scopes(reminderOf(taskID, reminderID)).Delete(&model.Reminder{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete reminder: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrReminderNotFound
+	}
+
+	return nil
+}
+
+// copyReminders gives the task with toID the same reminders as fromID, as
+// part of tx
+func copyReminders(tx *gorm.DB, fromID, toID uuid.UUID) error {
+	err := tx.Exec(`INSERT INTO tasks.reminders (task_id, user_id, email, offset_minutes)
+		SELECT ?, user_id, email, offset_minutes FROM tasks.reminders WHERE task_id = ?`, toID, fromID).Error
+	if err != nil {
+		return fmt.Errorf("failed to copy reminders: %w", err)
+	}
+	return nil
+}
+
+// DueReminder is a claimed reminder together with the task it is for and
+// the address its owner has now
+type DueReminder struct {
+	model.Reminder
+	TaskTitle string    `gorm:"column:task_title"`
+	DueDate   time.Time `gorm:"column:due_date"`
+	Email     string    `gorm:"column:email"`
+}
+
+// claimRemindersSQL leases up to @limit reminders that are due at @now to
+// @owner until @lease_until. A reminder is due once its task's due date minus
+// its offset has passed, unless it already fired for that due date, the task
+// is done or trashed, or the due date is older than @stale. Reminders leased
+// by another replica, or waiting to be retried, have lease_until in the
+// future and are skipped; SKIP LOCKED keeps concurrent claims from blocking
+// on or taking the same rows. The owner's email address is read from
+// auth.users so reminders follow address changes.
+const claimRemindersSQL = `
+UPDATE tasks.reminders AS r
+SET lease_owner = @owner, lease_until = @lease_until, attempts = r.attempts + 1
+FROM tasks.tasks AS t
+JOIN auth.users AS u ON u.id = t.user_id
+WHERE t.id = r.task_id AND r.id IN (
+	SELECT d.id FROM tasks.reminders AS d
+	JOIN tasks.tasks AS dt ON dt.id = d.task_id
+	WHERE dt.due_date IS NOT NULL AND dt.completed_at IS NULL AND dt.deleted_at IS NULL
+		AND dt.due_date > @stale
+		AND dt.due_date - d.offset_minutes * INTERVAL '1 minute' <= @now
+		AND (d.sent_for IS NULL OR d.sent_for <> dt.due_date)
+		AND (d.lease_until IS NULL OR d.lease_until <= @now)
+	ORDER BY dt.due_date - d.offset_minutes * INTERVAL '1 minute'
+	LIMIT @limit
+	FOR UPDATE OF d SKIP LOCKED
+)
+RETURNING r.*, t.title AS task_title, t.due_date, u.email`
+
+// ClaimDueReminders leases up to limit due reminders to owner for lease, so
+// no other scheduler replica sends them while owner does. Reminders whose
+// task was due more than stale before now are left alone.
+func ClaimDueReminders(owner string, now time.Time, lease, stale time.Duration, limit int) ([]DueReminder, error) {
+	var due []DueReminder
+	err := DB.Raw(claimRemindersSQL, map[string]interface{}{
+		"owner":       owner,
+		"lease_until": now.Add(lease),
+		"now":         now,
+		"stale":       now.Add(-stale),
+		"limit":       limit,
+	}).Scan(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	return due, nil
+}
+
+// leasedBy scopes a query to a reminder or webhook delivery still leased by
+// owner, so a replica whose lease expired cannot overwrite the outcome of the
+// one that took over
+func leasedBy(id uuid.UUID, owner string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND lease_owner = ?", id, owner)
+	}
+}
+
+// MarkReminderSent records that a reminder owner had claimed was delivered
+// for the due date dueDate and releases the lease
+func MarkReminderSent(reminderID uuid.UUID, owner string, dueDate, now time.Time) error {
+	return DB.Model(&model.Reminder{}).Scopes(leasedBy(reminderID, owner)).Updates(map[string]interface{}{
+		"sent_for":    dueDate,
+		"sent_at":     now,
+		"attempts":    0,
+		"last_error":  nil,
+		"lease_owner": nil,
+		"lease_until": nil,
+	}).Error
+}
+
+// MarkReminderFailed records a failed delivery and releases the lease. The
+// reminder is retried from retryAt, or, if retryAt is nil, given up on until
+// the task's due date changes.
+func MarkReminderFailed(reminderID uuid.UUID, owner string, dueDate time.Time, deliveryErr error, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"last_error":  deliveryErr.Error(),
+		"lease_owner": nil,
+		"lease_until": retryAt,
+	}
+	if retryAt == nil {
+		updates["sent_for"] = dueDate
+		updates["attempts"] = 0
+	}
+
+	return DB.Model(&model.Reminder{}).Scopes(leasedBy(reminderID, owner)).Updates(updates).Error
+}
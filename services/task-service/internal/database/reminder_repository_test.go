@@ -0,0 +1,210 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var reminderColumns = []string{
+	"id", "task_id", "user_id", "offset_minutes", "sent_for", "sent_at",
+	"attempts", "last_error", "lease_owner", "lease_until", "created_at",
+}
+
+func TestGetReminders(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+		WithArgs(taskID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, due, nil, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."reminders" WHERE task_id = \$1 ORDER BY offset_minutes DESC`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows(reminderColumns).
+			AddRow(uuid.New(), taskID, userID, 1440, nil, nil, 0, nil, nil, nil, now).
+			AddRow(uuid.New(), taskID, userID, 0, nil, nil, 0, nil, nil, nil, now))
+
+	reminders, err := GetReminders(userID, taskID)
+
+	require.NoError(t, err)
+	require.Len(t, reminders, 2)
+	assert.Equal(t, due.Add(-24*time.Hour), *reminders[0].RemindAt)
+	assert.Equal(t, due, *reminders[1].RemindAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReminder(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+
+	t.Run("creates the reminder for the caller", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."reminders" WHERE task_id = \$1 AND offset_minutes = \$2`).
+			WithArgs(taskID, 60).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."reminders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()))
+		mock.ExpectCommit()
+
+		reminder := &model.Reminder{TaskID: taskID, OffsetMinutes: 60}
+		err := CreateReminder(userID, reminder)
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, reminder.UserID)
+		assert.Nil(t, reminder.RemindAt, "task has no due date")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("one reminder per offset", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."reminders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		err := CreateReminder(userID, &model.Reminder{TaskID: taskID, OffsetMinutes: 60})
+
+		assert.ErrorIs(t, err, ErrReminderExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		err := CreateReminder(userID, &model.Reminder{TaskID: taskID})
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteReminder(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	reminderID := uuid.New()
+
+	t.Run("deletes the reminder", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."reminders" WHERE id = \$1 AND task_id = \$2`).
+			WithArgs(reminderID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, DeleteReminder(userID, taskID, reminderID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reminder of another task", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectOwnedTask(mock, userID, taskID)
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."reminders"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.ErrorIs(t, DeleteReminder(userID, taskID, reminderID), ErrReminderNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClaimDueReminders(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	now := time.Now()
+	due := now.Add(time.Hour)
+	reminderID := uuid.New()
+
+	mock.ExpectQuery(`UPDATE tasks.reminders AS r SET lease_owner = \$1, lease_until = \$2, attempts = r.attempts \+ 1 .* FOR UPDATE OF d SKIP LOCKED \) RETURNING r.\*, t.title AS task_title, t.due_date, u.email`).
+		WithArgs("replica-1", now.Add(5*time.Minute), now.Add(-24*time.Hour), now, now, 10).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, reminderColumns...), "task_title", "due_date", "email")).
+			AddRow(reminderID, uuid.New(), uuid.New(), 60, nil, nil, 1, nil, "replica-1", now.Add(5*time.Minute), now, "Ship it", due, "user@example.com"))
+
+	claimed, err := ClaimDueReminders("replica-1", now, 5*time.Minute, 24*time.Hour, 10)
+
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, reminderID, claimed[0].ID)
+	assert.Equal(t, "Ship it", claimed[0].TaskTitle)
+	assert.Equal(t, due, claimed[0].DueDate)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.Equal(t, "user@example.com", claimed[0].Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkReminderSent(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	reminderID := uuid.New()
+	now := time.Now()
+	due := now.Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "tasks"."reminders" SET "attempts"=\$1,"last_error"=\$2,"lease_owner"=\$3,"lease_until"=\$4,"sent_at"=\$5,"sent_for"=\$6 WHERE id = \$7 AND lease_owner = \$8`).
+		WithArgs(0, nil, nil, nil, now, due, reminderID, "replica-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, MarkReminderSent(reminderID, "replica-1", due, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkReminderFailed(t *testing.T) {
+	reminderID := uuid.New()
+	now := time.Now()
+	due := now.Add(time.Hour)
+	sendErr := errors.New("connection refused")
+
+	t.Run("schedules a retry", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		retryAt := now.Add(time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."reminders" SET "last_error"=\$1,"lease_owner"=\$2,"lease_until"=\$3 WHERE id = \$4 AND lease_owner = \$5`).
+			WithArgs("connection refused", nil, retryAt, reminderID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, MarkReminderFailed(reminderID, "replica-1", due, sendErr, &retryAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("gives up until the due date changes", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."reminders" SET "attempts"=\$1,"last_error"=\$2,"lease_owner"=\$3,"lease_until"=\$4,"sent_for"=\$5 WHERE id = \$6 AND lease_owner = \$7`).
+			WithArgs(0, "connection refused", nil, nil, due, reminderID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, MarkReminderFailed(reminderID, "replica-1", due, sendErr, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
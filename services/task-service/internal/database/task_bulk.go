@@ -0,0 +1,58 @@
+package database
+
+import (
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// Tx runs task operations inside a single transaction, so that a batch of
+// them is committed or rolled back together. Its methods behave like the
+// package functions of the same name, without version checks.
+type Tx struct {
+	db *gorm.DB
+}
+
+// Transaction runs fn in a transaction that is committed if fn returns nil
+// and rolled back otherwise
+func Transaction(fn func(Tx) error) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return fn(Tx{db: tx})
+	})
+}
+
+// Savepoint runs fn so that, if it fails, only its own changes are undone
+// and the rest of the transaction carries on
+func (t Tx) Savepoint(fn func(Tx) error) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		return fn(Tx{db: tx})
+	})
+}
+
+func (t Tx) GetTask(userID, taskID uuid.UUID) (*model.Task, error) {
+	return getTask(t.db, userID, taskID)
+}
+
+func (t Tx) PrepareStatusChange(task *model.Task, status string, updates map[string]interface{}) error {
+	return prepareStatusChange(t.db, task, status, updates)
+}
+
+func (t Tx) UpdateTask(userID, taskID uuid.UUID, updates map[string]interface{}) (*model.Task, error) {
+	return updateTask(t.db, userID, taskID, 0, updates)
+}
+
+func (t Tx) CompleteTask(userID, taskID uuid.UUID, policy SubtaskPolicy) (task, next *model.Task, err error) {
+	return completeTask(t.db, userID, taskID, 0, policy)
+}
+
+func (t Tx) DeleteTask(userID, taskID uuid.UUID) error {
+	return deleteTask(t.db, userID, taskID, 0)
+}
+
+func (t Tx) MoveTask(userID, taskID uuid.UUID, projectID *uuid.UUID) (*model.Task, error) {
+	return moveTask(t.db, userID, taskID, 0, projectID)
+}
+
+func (t Tx) AttachLabel(userID, taskID, labelID uuid.UUID) error {
+	return attachLabel(t.db, userID, taskID, labelID)
+}
@@ -0,0 +1,299 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultTaskPageSize = 50
+	MaxTaskPageSize     = 200
+	DefaultTaskSort     = "created_at"
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSortField = errors.New("invalid sort field")
+)
+
+// cursorTimeLayout is a timezone-less layout PostgreSQL parses into a timestamp column
+const cursorTimeLayout = "2006-01-02T15:04:05.999999"
+
+// TaskFilter narrows, orders and pages the tasks returned by ListTasks.
+// Zero values mean "no constraint".
+type TaskFilter struct {
+	Statuses      []string
+	Priorities    []string
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Completed     *bool
+	// LabelIDs matches tasks carrying any of the labels, or all of them when LabelMatchAll is set
+	LabelIDs      []uuid.UUID
+	LabelMatchAll bool
+	// ParentID restricts results to subtasks of one task; TopLevelOnly to tasks without a parent
+	ParentID     *uuid.UUID
+	TopLevelOnly bool
+	// ProjectID restricts results to one project; NoProject to tasks outside any project
+	ProjectID *uuid.UUID
+	NoProject bool
+
+	SortBy   string
+	SortDesc bool
+	Limit    int
+	Cursor   string
+}
+
+// taskSortField describes how a sortable column is ordered and how a cursor
+// value for it is produced and bound. Nullable columns are coalesced to +/-infinity
+// so NULLs sort last in either direction and still compare in the keyset predicate.
+type taskSortField struct {
+	expr  func(desc bool) string
+	cast  string
+	value func(task model.Task, desc bool) string
+}
+
+func nullableTimeSortField(column string, get func(model.Task) *time.Time) taskSortField {
+	sentinel := func(desc bool) string {
+		if desc {
+			return "-infinity"
+		}
+		return "infinity"
+	}
+
+	return taskSortField{
+		expr: func(desc bool) string {
+			return fmt.Sprintf("COALESCE(%s, '%s'::timestamp)", column, sentinel(desc))
+		},
+		cast: "timestamp",
+		value: func(task model.Task, desc bool) string {
+			if t := get(task); t != nil {
+				return t.Format(cursorTimeLayout)
+			}
+			return sentinel(desc)
+		},
+	}
+}
+
+func timeSortField(column string, get func(model.Task) time.Time) taskSortField {
+	return taskSortField{
+		expr:  func(bool) string { return column },
+		cast:  "timestamp",
+		value: func(task model.Task, _ bool) string { return get(task).Format(cursorTimeLayout) },
+	}
+}
+
+func textSortField(column string, get func(model.Task) string) taskSortField {
+	return taskSortField{
+		expr:  func(bool) string { return column },
+		cast:  "text",
+		value: func(task model.Task, _ bool) string { return get(task) },
+	}
+}
+
+// priorityRank orders priorities by model.ValidPriorities rather than alphabetically
+func priorityRank(priority *string) int {
+	if priority == nil {
+		return 0
+	}
+	for i, p := range model.ValidPriorities {
+		if p == *priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func priorityRankExpr() string {
+	expr := "CASE priority"
+	for i, p := range model.ValidPriorities {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", p, i+1)
+	}
+	return expr + " ELSE 0 END"
+}
+
+var taskSortFields = map[string]taskSortField{
+	"created_at": timeSortField("created_at", func(t model.Task) time.Time { return t.CreatedAt }),
+	"updated_at": timeSortField("updated_at", func(t model.Task) time.Time { return t.UpdatedAt }),
+	"due_date":   nullableTimeSortField("due_date", func(t model.Task) *time.Time { return t.DueDate }),
+	"completed_at": nullableTimeSortField("completed_at", func(t model.Task) *time.Time {
+		return t.CompletedAt
+	}),
+	"status": textSortField("status", func(t model.Task) string { return t.Status }),
+	"title":  textSortField("title", func(t model.Task) string { return t.Title }),
+	// Board order; ranks are only meaningful within one status
+	"rank": textSortField("rank", func(t model.Task) string { return t.Rank }),
+	"priority": {
+		expr:  func(bool) string { return priorityRankExpr() },
+		cast:  "integer",
+		value: func(task model.Task, _ bool) string { return strconv.Itoa(priorityRank(task.Priority)) },
+	},
+}
+
+// IsValidTaskSortField reports whether ListTasks can order by field
+func IsValidTaskSortField(field string) bool {
+	_, ok := taskSortFields[field]
+	return ok
+}
+
+// taskCursor is the opaque position handed back to clients as next_cursor.
+// It records the sort it was produced under so it cannot be replayed against another ordering.
+type taskCursor struct {
+	SortBy   string    `json:"s"`
+	SortDesc bool      `json:"d"`
+	Value    string    `json:"v"`
+	ID       uuid.UUID `json:"id"`
+}
+
+func encodeTaskCursor(c taskCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTaskCursor(s string) (*taskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c taskCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// applyTaskFilter adds the WHERE clauses for every constraint set on filter
+func applyTaskFilter(db *gorm.DB, filter TaskFilter) *gorm.DB {
+	if len(filter.Statuses) > 0 {
+		db = db.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Priorities) > 0 {
+		db = db.Where("priority IN ?", filter.Priorities)
+	}
+	if filter.DueAfter != nil {
+		db = db.Where("due_date >= ?", *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		db = db.Where("due_date < ?", *filter.DueBefore)
+	}
+	if filter.CreatedAfter != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		db = db.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		db = db.Where("updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		db = db.Where("updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.Completed != nil {
+		if *filter.Completed {
+			db = db.Where("completed_at IS NOT NULL")
+		} else {
+			db = db.Where("completed_at IS NULL")
+		}
+	}
+	if len(filter.LabelIDs) > 0 {
+		if filter.LabelMatchAll {
+			db = db.Where(
+				"id IN (SELECT task_id FROM tasks.task_labels WHERE label_id IN ? GROUP BY task_id HAVING COUNT(DISTINCT label_id) = ?)",
+				filter.LabelIDs, len(filter.LabelIDs),
+			)
+		} else {
+			db = db.Where("id IN (SELECT task_id FROM tasks.task_labels WHERE label_id IN ?)", filter.LabelIDs)
+		}
+	}
+	if filter.ParentID != nil {
+		db = db.Where("parent_id = ?", *filter.ParentID)
+	}
+	if filter.TopLevelOnly {
+		db = db.Where("parent_id IS NULL")
+	}
+	if filter.ProjectID != nil {
+		db = db.Where("project_id = ?", *filter.ProjectID)
+	}
+	if filter.NoProject {
+		db = db.Where("project_id IS NULL")
+	}
+	return db
+}
+
+// ListTasks returns one page of userID's tasks matching filter, ordered by
+// filter.SortBy with the task ID as tie-breaker. The returned cursor is empty
+// when there are no further pages.
+func ListTasks(userID uuid.UUID, filter TaskFilter) ([]model.Task, string, error) {
+	if filter.SortBy == "" {
+		filter.SortBy = DefaultTaskSort
+	}
+	sort, ok := taskSortFields[filter.SortBy]
+	if !ok {
+		return nil, "", ErrInvalidSortField
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	if limit > MaxTaskPageSize {
+		limit = MaxTaskPageSize
+	}
+
+	dir, op := "ASC", ">"
+	if filter.SortDesc {
+		dir, op = "DESC", "<"
+	}
+	expr := sort.expr(filter.SortDesc)
+
+	query := applyTaskFilter(DB.Where("user_id = ?", userID), filter)
+
+	if filter.Cursor != "" {
+		cursor, err := decodeTaskCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.SortBy != filter.SortBy || cursor.SortDesc != filter.SortDesc {
+			return nil, "", fmt.Errorf("%w: cursor does not match sort order", ErrInvalidCursor)
+		}
+		query = query.Where(
+			fmt.Sprintf("(%s, id) %s (CAST(? AS text)::%s, ?)", expr, op, sort.cast),
+			cursor.Value, cursor.ID,
+		)
+	}
+
+	var tasks []model.Task
+	// Fetch one extra row to learn whether another page exists
+	result := query.Order(fmt.Sprintf("%s %s, id %s", expr, dir, dir)).Limit(limit + 1).Find(&tasks)
+	if result.Error != nil {
+		return nil, "", result.Error
+	}
+
+	if len(tasks) <= limit {
+		return tasks, "", nil
+	}
+
+	tasks = tasks[:limit]
+	last := tasks[limit-1]
+	next := encodeTaskCursor(taskCursor{
+		SortBy:   filter.SortBy,
+		SortDesc: filter.SortDesc,
+		Value:    sort.value(last, filter.SortDesc),
+		ID:       last.ID,
+	})
+
+	return tasks, next, nil
+}
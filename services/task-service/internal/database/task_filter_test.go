@@ -0,0 +1,128 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var taskColumns = []string{
+	"id", "user_id", "title", "description", "status", "priority",
+	"due_date", "completed_at", "created_at", "updated_at",
+}
+
+func TestListTasks(t *testing.T) {
+	userID := uuid.New()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("defaults to created_at ascending", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND "tasks"."deleted_at" IS NULL ORDER BY created_at ASC, id ASC LIMIT \$2`).
+			WithArgs(userID, DefaultTaskPageSize+1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		tasks, next, err := ListTasks(userID, TaskFilter{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, tasks)
+		assert.Empty(t, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("applies filters", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		completed := false
+		dueBefore := now.Add(48 * time.Hour)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND status IN \(\$2,\$3\) AND priority IN \(\$4\) AND due_date < \$5 AND completed_at IS NULL AND "tasks"."deleted_at" IS NULL ORDER BY`).
+			WithArgs(userID, "todo", "in-progress", "high", dueBefore, 11).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		_, _, err := ListTasks(userID, TaskFilter{
+			Statuses:   []string{"todo", "in-progress"},
+			Priorities: []string{"high"},
+			DueBefore:  &dueBefore,
+			Completed:  &completed,
+			Limit:      10,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns a cursor when more rows exist and resumes from it", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		first, second, third := uuid.New(), uuid.New(), uuid.New()
+		due := now.Add(24 * time.Hour)
+
+		mock.ExpectQuery(`ORDER BY COALESCE\(due_date, '-infinity'::timestamp\) DESC, id DESC LIMIT \$2`).
+			WithArgs(userID, 3).
+			WillReturnRows(sqlmock.NewRows(taskColumns).
+				AddRow(first, userID, "A", nil, "todo", nil, due, nil, now, now).
+				AddRow(second, userID, "B", nil, "todo", nil, nil, nil, now, now).
+				AddRow(third, userID, "C", nil, "todo", nil, nil, nil, now, now))
+
+		filter := TaskFilter{SortBy: "due_date", SortDesc: true, Limit: 2}
+		tasks, next, err := ListTasks(userID, filter)
+
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+		require.NotEmpty(t, next)
+
+		cursor, err := decodeTaskCursor(next)
+		require.NoError(t, err)
+		assert.Equal(t, second, cursor.ID)
+		assert.Equal(t, "-infinity", cursor.Value)
+
+		mock.ExpectQuery(`WHERE user_id = \$1 AND \(COALESCE\(due_date, '-infinity'::timestamp\), id\) < \(CAST\(\$2 AS text\)::timestamp, \$3\) AND "tasks"."deleted_at" IS NULL ORDER BY`).
+			WithArgs(userID, "-infinity", second, 3).
+			WillReturnRows(sqlmock.NewRows(taskColumns).
+				AddRow(third, userID, "C", nil, "todo", nil, nil, nil, now, now))
+
+		filter.Cursor = next
+		tasks, next, err = ListTasks(userID, filter)
+
+		require.NoError(t, err)
+		assert.Len(t, tasks, 1)
+		assert.Empty(t, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects a cursor from a different sort", func(t *testing.T) {
+		cursor := encodeTaskCursor(taskCursor{SortBy: "title", Value: "A", ID: uuid.New()})
+
+		_, _, err := ListTasks(userID, TaskFilter{SortBy: "created_at", Cursor: cursor})
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("rejects a malformed cursor", func(t *testing.T) {
+		_, _, err := ListTasks(userID, TaskFilter{Cursor: "not-a-cursor!"})
+
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("rejects an unknown sort field", func(t *testing.T) {
+		_, _, err := ListTasks(userID, TaskFilter{SortBy: "user_id; DROP TABLE"})
+
+		assert.ErrorIs(t, err, ErrInvalidSortField)
+	})
+}
+
+func TestPriorityRank(t *testing.T) {
+	low, high, bogus := "low", "high", "urgent"
+
+	assert.Equal(t, 0, priorityRank(nil))
+	assert.Equal(t, 0, priorityRank(&bogus))
+	assert.Less(t, priorityRank(&low), priorityRank(&high))
+}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// trackedTaskFields are the task fields whose changes are recorded in the
+// history, keyed by column name
+var trackedTaskFields = []struct {
+	name  string
+	value func(*model.Task) *string
+}{
+	{"title", func(t *model.Task) *string { return &t.Title }},
+	{"description", func(t *model.Task) *string { return t.Description }},
+	{"status", func(t *model.Task) *string { return &t.Status }},
+	{"priority", func(t *model.Task) *string { return t.Priority }},
+	{"due_date", func(t *model.Task) *string { return timeValue(t.DueDate) }},
+	{"completed_at", func(t *model.Task) *string { return timeValue(t.CompletedAt) }},
+	{"project_id", func(t *model.Task) *string { return uuidValue(t.ProjectID) }},
+	{"parent_id", func(t *model.Task) *string { return uuidValue(t.ParentID) }},
+	{"recurrence", func(t *model.Task) *string { return t.Recurrence }},
+	{"recurrence_tz", func(t *model.Task) *string { return t.RecurrenceTimezone }},
+}
+
+func timeValue(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
+
+func uuidValue(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func sameValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// taskChanges returns one event per tracked field that differs between
+// before and after
+func taskChanges(actorID uuid.UUID, action string, before, after *model.Task) []model.TaskEvent {
+	var events []model.TaskEvent
+	for _, field := range trackedTaskFields {
+		oldValue, newValue := field.value(before), field.value(after)
+		if sameValue(oldValue, newValue) {
+			continue
+		}
+
+		name := field.name
+		events = append(events, model.TaskEvent{
+			TaskID:   after.ID,
+			UserID:   actorID,
+			Action:   action,
+			Field:    &name,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	return events
+}
+
+// taskEvent is an event that concerns the task as a whole
+func taskEvent(actorID, taskID uuid.UUID, action string) model.TaskEvent {
+	return model.TaskEvent{TaskID: taskID, UserID: actorID, Action: action}
+}
+
+// recordTaskEvents writes events as part of tx and queues them for the
+// owners' webhooks
+func recordTaskEvents(tx *gorm.DB, events []model.TaskEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := tx.Create(&events).Error; err != nil {
+		return fmt.Errorf("failed to record task history: %w", err)
+	}
+	return enqueueWebhookDeliveries(tx, events)
+}
+
+// GetTaskHistory returns the history of one of userID's tasks, oldest first
+func GetTaskHistory(userID, taskID uuid.UUID) ([]model.TaskEvent, error) {
+	if _, err := GetTask(userID, taskID); err != nil {
+		return nil, err
+	}
+
+	var events []model.TaskEvent
+	if err := DB.Where("task_id = ?", taskID).Order("created_at, id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
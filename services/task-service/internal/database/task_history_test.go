@@ -0,0 +1,72 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var taskEventColumns = []string{"id", "task_id", "user_id", "action", "field", "old_value", "new_value", "created_at"}
+
+func TestTaskChanges(t *testing.T) {
+	actorID := uuid.New()
+	projectID := uuid.New()
+	due := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	description := "Draft"
+
+	before := &model.Task{ID: uuid.New(), Title: "Report", Status: "in-progress", Description: &description}
+	after := *before
+	after.Status = "todo"
+	after.Description = nil
+	after.DueDate = &due
+	after.ProjectID = &projectID
+
+	events := taskChanges(actorID, model.TaskEventUpdated, before, &after)
+
+	require.Len(t, events, 4)
+	fields := map[string]model.TaskEvent{}
+	for _, event := range events {
+		assert.Equal(t, before.ID, event.TaskID)
+		assert.Equal(t, actorID, event.UserID)
+		assert.Equal(t, model.TaskEventUpdated, event.Action)
+		fields[*event.Field] = event
+	}
+
+	assert.Equal(t, "in-progress", *fields["status"].OldValue)
+	assert.Equal(t, "todo", *fields["status"].NewValue)
+	assert.Equal(t, "Draft", *fields["description"].OldValue)
+	assert.Nil(t, fields["description"].NewValue)
+	assert.Nil(t, fields["due_date"].OldValue)
+	assert.Equal(t, "2026-03-01T09:00:00Z", *fields["due_date"].NewValue)
+	assert.Equal(t, projectID.String(), *fields["project_id"].NewValue)
+
+	assert.Empty(t, taskChanges(actorID, model.TaskEventUpdated, before, before), "unchanged tasks record nothing")
+}
+
+func TestGetTaskHistory(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	expectOwnedTask(mock, userID, taskID)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."task_events" WHERE task_id = \$1 ORDER BY created_at, id`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows(taskEventColumns).
+			AddRow(uuid.New(), taskID, userID, "created", nil, nil, nil, now).
+			AddRow(uuid.New(), taskID, userID, "updated", "status", "done", "todo", now))
+
+	events, err := GetTaskHistory(userID, taskID)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "status", *events[1].Field)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
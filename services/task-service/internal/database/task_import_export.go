@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+// importBatchSize keeps each INSERT of an import well under Postgres' limit
+// on bind parameters
+const importBatchSize = 500
+
+// ExportTasks calls fn with each of userID's tasks outside the trash, oldest
+// first. Rows are read as fn consumes them rather than loaded all at once.
+func ExportTasks(userID uuid.UUID, fn func(*model.Task) error) error {
+	rows, err := DB.Model(&model.Task{}).
+		Where("user_id = ?", userID).
+		Order("created_at, id").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("failed to export tasks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var task model.Task
+		if err := DB.ScanRows(rows, &task); err != nil {
+			return fmt.Errorf("failed to export tasks: %w", err)
+		}
+		if err := fn(&task); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ImportTasks creates tasks, which already carry their IDs, in one
+// transaction and records their creation in the history. Parents must come
+// before their subtasks.
+func ImportTasks(tasks []model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&tasks, importBatchSize).Error; err != nil {
+			return err
+		}
+
+		events := make([]model.TaskEvent, len(tasks))
+		for i, task := range tasks {
+			events[i] = taskEvent(task.UserID, task.ID, model.TaskEventCreated)
+		}
+		return recordTaskEvents(tx, events)
+	})
+}
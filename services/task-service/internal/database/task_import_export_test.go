@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+func TestExportTasks(t *testing.T) {
+	userID := uuid.New()
+	now := time.Now()
+	exportRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "title", "status", "created_at", "updated_at"}).
+			AddRow(uuid.New(), userID, "First", "todo", now, now).
+			AddRow(uuid.New(), userID, "Second", "done", now, now)
+	}
+
+	t.Run("streams the user's tasks oldest first", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND "tasks"."deleted_at" IS NULL ORDER BY created_at, id`).
+			WithArgs(userID).
+			WillReturnRows(exportRows())
+
+		var titles []string
+		err := ExportTasks(userID, func(task *model.Task) error {
+			titles = append(titles, task.Title)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"First", "Second"}, titles)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stops when the callback fails", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		errWrite := errors.New("client went away")
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).WillReturnRows(exportRows())
+
+		calls := 0
+		err := ExportTasks(userID, func(task *model.Task) error {
+			calls++
+			return errWrite
+		})
+
+		assert.ErrorIs(t, err, errWrite)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestImportTasks(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	parentID := uuid.New()
+	subtaskID := uuid.New()
+	now := time.Now()
+
+	tasks := []model.Task{
+		{ID: parentID, UserID: userID, Title: "Parent", Status: "todo"},
+		{ID: subtaskID, UserID: userID, ParentID: &parentID, Title: "Subtask", Status: "todo"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"."tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(parentID).AddRow(subtaskID))
+	mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+		WithArgs(parentID, userID, "created", nil, nil, nil, subtaskID, userID, "created", nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+	expectNoWebhooks(mock)
+	mock.ExpectCommit()
+
+	err := ImportTasks(tasks)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
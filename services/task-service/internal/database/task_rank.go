@@ -0,0 +1,231 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/rank"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidNeighbour = errors.New("neighbours must be other tasks in the target status")
+	ErrNeighbourOrder   = errors.New("the task before must be ranked above the task after")
+)
+
+// errRankTie is returned by rankInColumn when both neighbours share a rank,
+// which tasks created at the same moment can
+var errRankTie = errors.New("neighbours share a rank")
+
+// TaskPosition is a place in a board column: in Status, directly after
+// BeforeID and directly before AfterID. Either neighbour may be nil, for the
+// top or bottom of the column; without both the task goes to the bottom.
+type TaskPosition struct {
+	// Status is the column; empty keeps the task's status
+	Status   string
+	BeforeID *uuid.UUID
+	AfterID  *uuid.UUID
+}
+
+// RepositionTask moves one of userID's tasks to pos. Only the task's own row
+// is written: it gets a rank between its new neighbours' and, when pos
+// changes its column, the status change its workflow allows. A non-zero
+// ifVersion makes the move conditional on the task's version.
+func RepositionTask(userID, taskID uuid.UUID, ifVersion int, pos TaskPosition) (*model.Task, error) {
+	return repositionTask(DB, userID, taskID, ifVersion, pos)
+}
+
+func repositionTask(db *gorm.DB, userID, taskID uuid.UUID, ifVersion int, pos TaskPosition) (*model.Task, error) {
+	var task model.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := lockTask(tx, userID, taskID, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		status := pos.Status
+		if status == "" {
+			status = before.Status
+		}
+
+		updates := map[string]interface{}{
+			"updated_at": time.Now(),
+		}
+		if status != before.Status {
+			if err := prepareStatusChange(tx, before, status, updates); err != nil {
+				return err
+			}
+		}
+
+		r, err := rankInColumn(tx, userID, taskID, status, pos)
+		if errors.Is(err, errRankTie) {
+			if err := spreadColumnRanks(tx, userID, status); err != nil {
+				return err
+			}
+			r, err = rankInColumn(tx, userID, taskID, status, pos)
+		}
+		if err != nil {
+			return err
+		}
+		if status == before.Status {
+			updates["rank"] = r
+		}
+
+		if err := tx.Model(&model.Task{}).Scopes(ownedBy(userID, taskID)).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to reposition task: %w", err)
+		}
+
+		// A status change sends the task to the end of its new column (the
+		// rank_moved_task trigger), so its rank is written afterwards
+		if status != before.Status {
+			if err := tx.Model(&model.Task{}).Scopes(ownedBy(userID, taskID)).UpdateColumn("rank", r).Error; err != nil {
+				return fmt.Errorf("failed to reposition task: %w", err)
+			}
+		}
+
+		if err := tx.Scopes(ownedBy(userID, taskID)).First(&task).Error; err != nil {
+			return fmt.Errorf("failed to fetch repositioned task: %w", err)
+		}
+
+		return recordTaskEvents(tx, taskChanges(userID, model.TaskEventUpdated, before, &task))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// rankInColumn works out the rank that puts taskID at pos in userID's status
+// column. A missing neighbour is taken to be the task next to the other one,
+// so the moved task always lands right beside the neighbour it was given.
+func rankInColumn(tx *gorm.DB, userID, taskID uuid.UUID, status string, pos TaskPosition) (string, error) {
+	var lower, upper string
+	var err error
+	if pos.BeforeID != nil {
+		if lower, err = neighbourRank(tx, userID, taskID, status, *pos.BeforeID); err != nil {
+			return "", err
+		}
+	}
+	if pos.AfterID != nil {
+		if upper, err = neighbourRank(tx, userID, taskID, status, *pos.AfterID); err != nil {
+			return "", err
+		}
+	}
+
+	// The rest of the column, without the task being moved
+	column := func() *gorm.DB {
+		return tx.Model(&model.Task{}).Where("user_id = ? AND status = ? AND id <> ?", userID, status, taskID)
+	}
+
+	switch {
+	case pos.BeforeID != nil && pos.AfterID != nil:
+		if lower == upper {
+			return "", errRankTie
+		}
+		if lower > upper {
+			return "", ErrNeighbourOrder
+		}
+	case pos.BeforeID != nil:
+		upper, err = columnRank(column().Where("rank > ?", lower).Order("rank"))
+	case pos.AfterID != nil:
+		lower, err = columnRank(column().Where("rank < ?", upper).Order("rank DESC"))
+	default:
+		lower, err = columnRank(column().Order("rank DESC"))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return rank.Between(lower, upper)
+}
+
+// neighbourRank returns the rank of neighbourID, which must be another of
+// userID's tasks in status
+func neighbourRank(tx *gorm.DB, userID, taskID uuid.UUID, status string, neighbourID uuid.UUID) (string, error) {
+	if neighbourID == taskID {
+		return "", ErrInvalidNeighbour
+	}
+
+	var ranks []string
+	err := tx.Model(&model.Task{}).
+		Where("user_id = ? AND id = ? AND status = ?", userID, neighbourID, status).
+		Pluck("rank", &ranks).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to load neighbour: %w", err)
+	}
+	if len(ranks) == 0 {
+		return "", ErrInvalidNeighbour
+	}
+	return ranks[0], nil
+}
+
+// columnRank returns the first rank of query, or "" when it finds no task
+func columnRank(query *gorm.DB) (string, error) {
+	var ranks []string
+	if err := query.Limit(1).Pluck("rank", &ranks).Error; err != nil {
+		return "", fmt.Errorf("failed to load column ranks: %w", err)
+	}
+	if len(ranks) == 0 {
+		return "", nil
+	}
+	return ranks[0], nil
+}
+
+// spreadColumnRanks gives every task in userID's status column, trashed ones
+// included, a fresh evenly spaced rank in the column's current order. Only
+// ranks change, so the tasks keep their versions and clients' ETags stay good.
+func spreadColumnRanks(tx *gorm.DB, userID uuid.UUID, status string) error {
+	var tasks []model.Task
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "rank").
+		Where("user_id = ? AND status = ?", userID, status).
+		Order("rank, id").
+		Find(&tasks).Error
+	if err != nil {
+		return fmt.Errorf("failed to load column ranks: %w", err)
+	}
+
+	for i, r := range rank.Spread(len(tasks)) {
+		if tasks[i].Rank == r {
+			continue
+		}
+		if err := tx.Unscoped().Model(&tasks[i]).UpdateColumn("rank", r).Error; err != nil {
+			return fmt.Errorf("failed to rerank task: %w", err)
+		}
+	}
+	return nil
+}
+
+// RebalanceTaskRanks respreads up to limit board columns, of any user, that
+// hold a rank longer than maxLength. It returns how many it respread; fewer
+// than limit means none are left.
+func RebalanceTaskRanks(maxLength, limit int) (int, error) {
+	var columns []struct {
+		UserID uuid.UUID
+		Status string
+	}
+	err := DB.Unscoped().Model(&model.Task{}).
+		Distinct("user_id", "status").
+		Where("length(rank) > ?", maxLength).
+		Limit(limit).
+		Scan(&columns).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find long ranks: %w", err)
+	}
+
+	for i, c := range columns {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			return spreadColumnRanks(tx, c.UserID, c.Status)
+		})
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(columns), nil
+}
@@ -0,0 +1,222 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositionTask(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	beforeID := uuid.New()
+	afterID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "rank")
+
+	taskRow := func(status, rank string) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, status, nil, nil, nil, now, now, rank)
+	}
+	rankRows := func(ranks ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"rank"})
+		for _, r := range ranks {
+			rows.AddRow(r)
+		}
+		return rows
+	}
+	expectLock := func(mock sqlmock.Sqlmock, status, rank string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(taskRow(status, rank))
+	}
+	expectNeighbour := func(mock sqlmock.Sqlmock, id uuid.UUID, status string, rows *sqlmock.Rows) {
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks" WHERE \(user_id = \$1 AND id = \$2 AND status = \$3\)`).
+			WithArgs(userID, id, status).
+			WillReturnRows(rows)
+	}
+	expectUpdate := func(mock sqlmock.Sqlmock, rank string) {
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1,"updated_at"=\$2 WHERE \(id = \$3 AND user_id = \$4\)`).
+			WithArgs(rank, sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("todo", rank))
+	}
+
+	t.Run("between two neighbours", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "x")
+		expectNeighbour(mock, beforeID, "todo", rankRows("a"))
+		expectNeighbour(mock, afterID, "todo", rankRows("c"))
+		expectUpdate(mock, "b")
+		mock.ExpectCommit()
+
+		task, err := RepositionTask(userID, taskID, 0, TaskPosition{BeforeID: &beforeID, AfterID: &afterID})
+
+		require.NoError(t, err)
+		assert.Equal(t, "b", task.Rank)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("right after a neighbour", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "x")
+		expectNeighbour(mock, beforeID, "todo", rankRows("a"))
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks" WHERE \(user_id = \$1 AND status = \$2 AND id <> \$3\) AND rank > \$4 AND "tasks"."deleted_at" IS NULL ORDER BY rank LIMIT \$5`).
+			WithArgs(userID, "todo", taskID, "a", 1).
+			WillReturnRows(rankRows("a5"))
+		expectUpdate(mock, "a4")
+		mock.ExpectCommit()
+
+		_, err := RepositionTask(userID, taskID, 0, TaskPosition{BeforeID: &beforeID})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("to the bottom of the column", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "c")
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks" WHERE \(user_id = \$1 AND status = \$2 AND id <> \$3\) AND "tasks"."deleted_at" IS NULL ORDER BY rank DESC LIMIT \$4`).
+			WithArgs(userID, "todo", taskID, 1).
+			WillReturnRows(rankRows("k"))
+		expectUpdate(mock, "l")
+		mock.ExpectCommit()
+
+		_, err := RepositionTask(userID, taskID, 0, TaskPosition{})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("into another column", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "c")
+		expectNeighbour(mock, afterID, "done", rankRows("b"))
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks" .* AND rank < \$4 .* ORDER BY rank DESC`).
+			WithArgs(userID, "done", taskID, "b", 1).
+			WillReturnRows(rankRows())
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,"status"=\$2,"updated_at"=\$3`).
+			WithArgs(sqlmock.AnyArg(), "done", sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		// Set after the status, which alone would send the task to the bottom
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1 WHERE \(id = \$2 AND user_id = \$3\)`).
+			WithArgs("a", taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "done", nil, nil, now, now, now, "a"))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "updated", "status", "todo", "done",
+				taskID, userID, "updated", "completed_at", nil, sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		task, err := RepositionTask(userID, taskID, 0, TaskPosition{Status: "done", AfterID: &afterID})
+
+		require.NoError(t, err)
+		assert.Equal(t, "done", task.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("neighbour outside the column", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "c")
+		expectNeighbour(mock, beforeID, "todo", rankRows())
+		mock.ExpectRollback()
+
+		_, err := RepositionTask(userID, taskID, 0, TaskPosition{BeforeID: &beforeID})
+
+		assert.ErrorIs(t, err, ErrInvalidNeighbour)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("neighbours out of order", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "x")
+		expectNeighbour(mock, beforeID, "todo", rankRows("c"))
+		expectNeighbour(mock, afterID, "todo", rankRows("a"))
+		mock.ExpectRollback()
+
+		_, err := RepositionTask(userID, taskID, 0, TaskPosition{BeforeID: &beforeID, AfterID: &afterID})
+
+		assert.ErrorIs(t, err, ErrNeighbourOrder)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("neighbours sharing a rank are respread first", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectLock(mock, "todo", "x")
+		expectNeighbour(mock, beforeID, "todo", rankRows("b"))
+		expectNeighbour(mock, afterID, "todo", rankRows("b"))
+		mock.ExpectQuery(`SELECT "id","rank" FROM "tasks"."tasks" WHERE user_id = \$1 AND status = \$2 ORDER BY rank, id FOR UPDATE`).
+			WithArgs(userID, "todo").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).
+				AddRow(beforeID, "b").
+				AddRow(afterID, "b").
+				AddRow(taskID, "x"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1 WHERE "id" = \$2`).
+			WithArgs("9", beforeID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1 WHERE "id" = \$2`).
+			WithArgs("i", afterID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1 WHERE "id" = \$2`).
+			WithArgs("r", taskID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectNeighbour(mock, beforeID, "todo", rankRows("9"))
+		expectNeighbour(mock, afterID, "todo", rankRows("i"))
+		expectUpdate(mock, "e")
+		mock.ExpectCommit()
+
+		_, err := RepositionTask(userID, taskID, 0, TaskPosition{BeforeID: &beforeID, AfterID: &afterID})
+
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRebalanceTaskRanks(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	userID := uuid.New()
+	taskID := uuid.New()
+
+	mock.ExpectQuery(`SELECT DISTINCT "user_id","status" FROM "tasks"."tasks" WHERE length\(rank\) > \$1 LIMIT \$2`).
+		WithArgs(16, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "status"}).AddRow(userID, "todo"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id","rank" FROM "tasks"."tasks" WHERE user_id = \$1 AND status = \$2 ORDER BY rank, id FOR UPDATE`).
+		WithArgs(userID, "todo").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "rank"}).AddRow(taskID, "zzzzzzzzzzzzzzzzzi"))
+	mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1 WHERE "id" = \$2`).
+		WithArgs("i", taskID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := RebalanceTaskRanks(16, 10)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -8,6 +8,30 @@ import (
 	"github.com/google/uuid"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTaskNotFound is returned when a task does not exist or is not owned by the caller.
+// The two cases are deliberately indistinguishable so other users' task IDs are never leaked.
+var ErrTaskNotFound = errors.New("task not found")
+
+var (
+	ErrParentNotFound = errors.New("parent task not found")
+	ErrSubtaskDepth   = errors.New("subtasks cannot have subtasks of their own")
+	ErrOpenSubtasks   = errors.New("task has open subtasks")
+	// ErrVersionConflict is returned by writes made for a version of a task
+	// that is no longer current
+	ErrVersionConflict = errors.New("task has changed since that version")
+)
+
+// SubtaskPolicy controls what CompleteTask does when the task still has open subtasks
+type SubtaskPolicy int
+
+const (
+	// SubtaskPolicyRefuse fails with ErrOpenSubtasks
+	SubtaskPolicyRefuse SubtaskPolicy = iota
+	// SubtaskPolicyCascade completes the open subtasks along with the parent
+	SubtaskPolicyCascade
 )
 
 func CreateTask(task *model.Task) error {
@@ -15,94 +39,331 @@ func CreateTask(task *model.Task) error {
 		return fmt.Errorf("task cannot be nil")
 	}
 
-	if err := DB.Create(task).Error; err != nil {
-		return err
-	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return err
+		}
 
-	return nil
+		return recordTaskEvents(tx, []model.TaskEvent{taskEvent(task.UserID, task.ID, model.TaskEventCreated)})
+	})
 }
 
-func GetTasksByUserID(userID uuid.UUID) ([]model.Task, error) {
-	var tasks []model.Task
-
-	result := DB.Where("user_id = ?", userID).Find(&tasks)
-	if result.Error != nil {
-		return nil, result.Error
+// ownedBy scopes a query to a single task belonging to userID
+func ownedBy(userID, taskID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND user_id = ?", taskID, userID)
 	}
+}
 
-	if len(tasks) == 0 {
-		return []model.Task{}, nil // Return empty slice, not error
+func GetTask(userID, taskID uuid.UUID) (*model.Task, error) {
+	return getTask(DB, userID, taskID)
+}
+
+func getTask(db *gorm.DB, userID, taskID uuid.UUID) (*model.Task, error) {
+	var task model.Task
+	if err := db.Scopes(ownedBy(userID, taskID)).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
 	}
 
-	return tasks, nil
+	return &task, nil
 }
 
-func GetTask(taskID uuid.UUID) (*model.Task, error) {
+// lockTask loads one of userID's tasks and locks its row until tx ends. A
+// non-zero ifVersion is the version the caller expects the task to be at;
+// finding any other fails with ErrVersionConflict.
+func lockTask(tx *gorm.DB, userID, taskID uuid.UUID, ifVersion int) (*model.Task, error) {
 	var task model.Task
-	if err := DB.First(&task, taskID).Error; err != nil {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(ownedBy(userID, taskID)).First(&task).Error
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("task not found with id: %s", taskID)
+			return nil, ErrTaskNotFound
 		}
 		return nil, err
 	}
 
+	if ifVersion != 0 && task.Version != ifVersion {
+		return nil, ErrVersionConflict
+	}
+
 	return &task, nil
 }
 
-func UpdateTask(taskID uuid.UUID, updates map[string]interface{}) (*model.Task, error) {
-	result := DB.Model(&model.Task{}).Where("id = ?", taskID).Updates(updates)
+// UpdateTask applies updates to one of userID's tasks and records each
+// changed field in its history. A non-zero ifVersion makes the update
+// conditional on the task still being at that version.
+func UpdateTask(userID, taskID uuid.UUID, ifVersion int, updates map[string]interface{}) (*model.Task, error) {
+	return updateTask(DB, userID, taskID, ifVersion, updates)
+}
 
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to update task: %w", result.Error)
+func updateTask(db *gorm.DB, userID, taskID uuid.UUID, ifVersion int, updates map[string]interface{}) (*model.Task, error) {
+	var task model.Task
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before, err := lockTask(tx, userID, taskID, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.Task{}).Scopes(ownedBy(userID, taskID)).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+
+		if err := tx.Scopes(ownedBy(userID, taskID)).First(&task).Error; err != nil {
+			return fmt.Errorf("failed to fetch updated task: %w", err)
+		}
+
+		return recordTaskEvents(tx, taskChanges(userID, model.TaskEventUpdated, before, &task))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if result.RowsAffected == 0 {
-		return nil, fmt.Errorf("task not found")
+	return &task, nil
+}
+
+// DeleteTask moves one of userID's tasks and its subtasks to the trash and
+// records the deletion of each in their history. Subtasks share the parent's
+// deleted_at so RestoreTask can bring them back together. A non-zero
+// ifVersion makes the deletion conditional on the task's version.
+func DeleteTask(userID, taskID uuid.UUID, ifVersion int) error {
+	return deleteTask(DB, userID, taskID, ifVersion)
+}
+
+func deleteTask(db *gorm.DB, userID, taskID uuid.UUID, ifVersion int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockTask(tx, userID, taskID, ifVersion); err != nil {
+			return err
+		}
+
+		var subtaskIDs []uuid.UUID
+		if err := tx.Model(&model.Task{}).Where("user_id = ? AND parent_id = ?", userID, taskID).Pluck("id", &subtaskIDs).Error; err != nil {
+			return fmt.Errorf("failed to load subtasks: %w", err)
+		}
+
+		err := tx.Model(&model.Task{}).
+			Where("user_id = ? AND (id = ? OR parent_id = ?)", userID, taskID, taskID).
+			Update("deleted_at", time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+
+		events := []model.TaskEvent{taskEvent(userID, taskID, model.TaskEventDeleted)}
+		for _, id := range subtaskIDs {
+			events = append(events, taskEvent(userID, id, model.TaskEventDeleted))
+		}
+
+		return recordTaskEvents(tx, events)
+	})
+}
+
+// ValidateParent checks that parentID can hold subtasks for userID: it must be
+// owned by the user and must not itself be a subtask (subtasks are one level deep).
+// It returns the parent so callers can inherit from it.
+func ValidateParent(userID, parentID uuid.UUID) (*model.Task, error) {
+	parent, err := GetTask(userID, parentID)
+	if err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return nil, ErrParentNotFound
+		}
+		return nil, err
 	}
 
-	var task model.Task
-	if err := DB.First(&task, taskID).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch updated task: %w", err)
+	if parent.ParentID != nil {
+		return nil, ErrSubtaskDepth
 	}
 
-	return &task, nil
+	return parent, nil
 }
 
-func DeleteTask(taskID uuid.UUID) error {
-	result := DB.Delete(&model.Task{}, taskID)
+func GetSubtasks(userID, parentID uuid.UUID) ([]model.Task, error) {
+	var tasks []model.Task
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete task: %w", result.Error)
+	err := DB.Where("user_id = ? AND parent_id = ?", userID, parentID).
+		Order("created_at, id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("task not found")
+	return tasks, nil
+}
+
+// openSubtasksOf scopes a query to the unfinished subtasks of taskID
+func openSubtasksOf(userID, taskID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("user_id = ? AND parent_id = ? AND completed_at IS NULL", userID, taskID)
 	}
+}
 
-	return nil
+// CompleteTask marks a task done. Open subtasks are handled according to policy.
+// Completing an open recurring task also creates its next occurrence, which
+// is returned as next; next is nil otherwise. A non-zero ifVersion makes the
+// completion conditional on the task's version.
+func CompleteTask(userID, taskID uuid.UUID, ifVersion int, policy SubtaskPolicy) (task, next *model.Task, err error) {
+	return completeTask(DB, userID, taskID, ifVersion, policy)
 }
 
-func CompleteTask(taskID uuid.UUID) (*model.Task, error) {
-	updates := map[string]interface{}{
-		"status":       "done",
-		"completed_at": time.Now(),
-		"updated_at":   time.Now(),
+func completeTask(db *gorm.DB, userID, taskID uuid.UUID, ifVersion int, policy SubtaskPolicy) (task, next *model.Task, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Lock before reading CompletedAt so concurrent completions of a
+		// recurring task cannot both schedule its next occurrence
+		task, err = lockTask(tx, userID, taskID, ifVersion)
+		if err != nil {
+			return err
+		}
+
+		// Completing moves the task to the first done status of its workflow,
+		// unless it already sits in a done status. Transition rules do not apply.
+		wf, err := workflowFor(tx, task.ProjectID)
+		if err != nil {
+			return err
+		}
+		status := task.Status
+		if !wf.IsDone(status) {
+			done, _ := wf.FirstInCategory(model.StatusCategoryDone)
+			status = done.Key
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":       status,
+			"completed_at": now,
+			"updated_at":   now,
+		}
+
+		var open []model.Task
+		if err := tx.Scopes(openSubtasksOf(userID, taskID)).Find(&open).Error; err != nil {
+			return fmt.Errorf("failed to load open subtasks: %w", err)
+		}
+
+		var events []model.TaskEvent
+		if len(open) > 0 {
+			if policy != SubtaskPolicyCascade {
+				return ErrOpenSubtasks
+			}
+			if err := tx.Model(&model.Task{}).Scopes(openSubtasksOf(userID, taskID)).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to complete subtasks: %w", err)
+			}
+
+			for i := range open {
+				done := open[i]
+				done.Status = status
+				done.CompletedAt = &now
+				events = append(events, taskChanges(userID, model.TaskEventCompleted, &open[i], &done)...)
+			}
+		}
+
+		before := *task
+		result := tx.Model(&model.Task{}).Scopes(ownedBy(userID, taskID)).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to complete task: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrTaskNotFound
+		}
+
+		if err := tx.Scopes(ownedBy(userID, taskID)).First(task).Error; err != nil {
+			return fmt.Errorf("failed to fetch completed task: %w", err)
+		}
+
+		events = append(events, taskChanges(userID, model.TaskEventCompleted, &before, task)...)
+		if err := recordTaskEvents(tx, events); err != nil {
+			return err
+		}
+
+		// Completing an occurrence again must not schedule another one
+		if before.CompletedAt != nil {
+			return nil
+		}
+		next, err = createNextOccurrence(tx, task, wf)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	result := DB.Model(&model.Task{}).Where("id = ?", taskID).Updates(updates)
+	return task, next, nil
+}
 
-	if result.Error != nil {
-		return nil, fmt.Errorf("failed to complete task: %w", result.Error)
+// createNextOccurrence adds the occurrence of a recurring task that follows
+// task, with the same details, labels and reminders, due on the next date of
+// its rule.
+// It returns nil if task does not recur or its series has ended.
+func createNextOccurrence(tx *gorm.DB, task *model.Task, wf *model.Workflow) (*model.Task, error) {
+	dueDate, ok, err := task.NextDueDate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule next occurrence: %w", err)
+	}
+	if !ok {
+		return nil, nil
 	}
 
-	if result.RowsAffected == 0 {
-		return nil, fmt.Errorf("task not found")
+	initial, _ := wf.FirstInCategory(model.StatusCategoryNotStarted)
+	next := model.Task{
+		UserID:             task.UserID,
+		ProjectID:          task.ProjectID,
+		ParentID:           task.ParentID,
+		Title:              task.Title,
+		Description:        task.Description,
+		Status:             initial.Key,
+		Priority:           task.Priority,
+		DueDate:            &dueDate,
+		Recurrence:         task.Recurrence,
+		RecurrenceTimezone: task.RecurrenceTimezone,
+		RecurrenceStart:    task.RecurrenceStart,
+		Occurrence:         max(task.Occurrence, 1) + 1,
+	}
+	if err := tx.Create(&next).Error; err != nil {
+		return nil, fmt.Errorf("failed to create next occurrence: %w", err)
 	}
 
-	var task model.Task
-	if err := DB.First(&task, taskID).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch completed task: %w", err)
+	err = tx.Exec(`INSERT INTO tasks.task_labels (task_id, label_id)
+		SELECT ?, label_id FROM tasks.task_labels WHERE task_id = ?`, next.ID, task.ID).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy labels to next occurrence: %w", err)
+	}
+	if err := copyReminders(tx, task.ID, next.ID); err != nil {
+		return nil, err
 	}
 
-	return &task, nil
+	if err := recordTaskEvents(tx, []model.TaskEvent{taskEvent(task.UserID, next.ID, model.TaskEventCreated)}); err != nil {
+		return nil, err
+	}
+
+	return &next, nil
+}
+
+// GetTaskProgress counts finished checklist items and subtasks for each of taskIDs.
+// Tasks with neither are omitted from the result.
+func GetTaskProgress(taskIDs []uuid.UUID) (map[uuid.UUID]model.TaskProgress, error) {
+	progress := make(map[uuid.UUID]model.TaskProgress, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return progress, nil
+	}
+
+	var rows []model.TaskProgress
+	err := DB.Table("tasks.tasks AS t").
+		Select(`t.id AS task_id,
+			(SELECT COUNT(*) FROM tasks.checklist_items c WHERE c.task_id = t.id AND c.done) AS checklist_done,
+			(SELECT COUNT(*) FROM tasks.checklist_items c WHERE c.task_id = t.id) AS checklist_total,
+			(SELECT COUNT(*) FROM tasks.tasks s WHERE s.parent_id = t.id AND s.deleted_at IS NULL AND s.completed_at IS NOT NULL) AS subtasks_done,
+			(SELECT COUNT(*) FROM tasks.tasks s WHERE s.parent_id = t.id AND s.deleted_at IS NULL) AS subtasks_total`).
+		Where("t.id IN ?", taskIDs).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		row.Done = row.ChecklistDone + row.SubtasksDone
+		row.Total = row.ChecklistTotal + row.SubtasksTotal
+		if row.Total > 0 {
+			progress[row.TaskID] = row
+		}
+	}
+
+	return progress, nil
 }
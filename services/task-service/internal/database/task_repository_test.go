@@ -7,6 +7,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -50,16 +51,28 @@ func TestCreateTask(t *testing.T) {
 		WithArgs(
 			sqlmock.AnyArg(), // id
 			sqlmock.AnyArg(), // user_id
+			sqlmock.AnyArg(), // project_id
+			sqlmock.AnyArg(), // parent_id
 			sqlmock.AnyArg(), // title
 			sqlmock.AnyArg(), // description
 			sqlmock.AnyArg(), // status
 			sqlmock.AnyArg(), // priority
 			sqlmock.AnyArg(), // due_date
 			sqlmock.AnyArg(), // completed_at
+			sqlmock.AnyArg(), // recurrence
+			sqlmock.AnyArg(), // recurrence_tz
+			sqlmock.AnyArg(), // recurrence_start
+			sqlmock.AnyArg(), // occurrence
+			sqlmock.AnyArg(), // version
+			sqlmock.AnyArg(), // deleted_at
 			sqlmock.AnyArg(), // created_at
 			sqlmock.AnyArg(), // updated_at
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(taskID))
+	mock.ExpectQuery(`INSERT INTO "tasks"."task_events" \("task_id","user_id","action","field","old_value","new_value"\)`).
+		WithArgs(taskID, userID, "created", nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+	expectNoWebhooks(mock)
 	mock.ExpectCommit()
 
 	err := CreateTask(&task)
@@ -87,11 +100,11 @@ func TestGetTask(t *testing.T) {
 			nil, nil, now, now,
 		)
 
-		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
-			WithArgs(taskID, 1).
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
 			WillReturnRows(rows)
 
-		task, err := GetTask(taskID)
+		task, err := GetTask(userID, taskID)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, task)
@@ -103,14 +116,27 @@ func TestGetTask(t *testing.T) {
 
 	t.Run("task not found", func(t *testing.T) {
 		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
-			WithArgs(taskID, 1).
+			WithArgs(taskID, userID, 1).
 			WillReturnError(gorm.ErrRecordNotFound)
 
-		task, err := GetTask(taskID)
+		task, err := GetTask(userID, taskID)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
 		assert.Nil(t, task)
-		assert.Contains(t, err.Error(), "task not found")
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		otherUserID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, otherUserID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		task, err := GetTask(otherUserID, taskID)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -130,121 +156,466 @@ func TestUpdateTask(t *testing.T) {
 
 	t.Run("successful update", func(t *testing.T) {
 		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+				taskID, userID, "Old Title", nil, "todo", nil, nil, nil, now, now,
+			))
 		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
 			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
-
-		rows := sqlmock.NewRows([]string{
-			"id", "user_id", "title", "description", "status", "priority",
-			"due_date", "completed_at", "created_at", "updated_at",
-		}).AddRow(
-			taskID, userID, "Updated Title", nil, "in-progress", nil,
-			nil, nil, now, now,
-		)
-
 		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
-			WithArgs(taskID, 1).
-			WillReturnRows(rows)
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+				taskID, userID, "Updated Title", nil, "in-progress", nil, nil, nil, now, now,
+			))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "updated", "title", "Old Title", "Updated Title",
+				taskID, userID, "updated", "status", "todo", "in-progress",
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
 
-		task, err := UpdateTask(taskID, updates)
+		task, err := UpdateTask(userID, taskID, 0, updates)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, task)
 		assert.Equal(t, "Updated Title", task.Title)
 		assert.Equal(t, "in-progress", task.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("task not found", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
-			WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
 
-		task, err := UpdateTask(taskID, updates)
+		task, err := UpdateTask(userID, taskID, 0, updates)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
 		assert.Nil(t, task)
-		assert.Contains(t, err.Error(), "task not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		otherUserID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, otherUserID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
+
+		task, err := UpdateTask(otherUserID, taskID, 0, updates)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL ORDER BY "tasks"."id" LIMIT \$3 FOR UPDATE`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(append(taskColumns, "version")).AddRow(
+				taskID, userID, "Old Title", nil, "todo", nil, nil, nil, now, now, 3,
+			))
+		mock.ExpectRollback()
+
+		task, err := UpdateTask(userID, taskID, 2, updates)
+
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
 func TestDeleteTask(t *testing.T) {
-	gormDB, mock := setupMockDB(t)
-	DB = gormDB
-
 	taskID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+
+	t.Run("moves the task and its subtasks to the trash", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		subtaskID := uuid.New()
 
-	t.Run("successful delete", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectExec(`DELETE FROM "tasks"."tasks"`).
-			WithArgs(taskID).
-			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT "id" FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(userID, taskID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(subtaskID))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE \(user_id = \$3 AND \(id = \$4 OR parent_id = \$5\)\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), userID, taskID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "deleted", nil, nil, nil,
+				subtaskID, userID, "deleted", nil, nil, nil,
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
 		mock.ExpectCommit()
 
-		err := DeleteTask(taskID)
+		err := DeleteTask(userID, taskID, 0)
 
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("task not found", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
 		mock.ExpectBegin()
-		mock.ExpectExec(`DELETE FROM "tasks"."tasks"`).
-			WithArgs(taskID).
-			WillReturnResult(sqlmock.NewResult(0, 0))
-		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
 
-		err := DeleteTask(taskID)
+		err := DeleteTask(userID, taskID, 0)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "task not found")
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		otherUserID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\)`).
+			WithArgs(taskID, otherUserID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
+
+		err := DeleteTask(otherUserID, taskID, 0)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
 func TestCompleteTask(t *testing.T) {
-	gormDB, mock := setupMockDB(t)
-	DB = gormDB
-
 	taskID := uuid.New()
 	userID := uuid.New()
 	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "project_id")
 
-	t.Run("successful completion", func(t *testing.T) {
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
-			WillReturnResult(sqlmock.NewResult(0, 1))
-		mock.ExpectCommit()
-
-		rows := sqlmock.NewRows([]string{
-			"id", "user_id", "title", "description", "status", "priority",
-			"due_date", "completed_at", "created_at", "updated_at",
-		}).AddRow(
-			taskID, userID, "Test Task", nil, "done", nil,
+	openRows := func(projectID interface{}) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(
+			taskID, userID, "Test Task", nil, "todo", nil,
+			nil, nil, now, now, projectID,
+		)
+	}
+	subtaskIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	openSubtaskRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(taskColumns).
+			AddRow(subtaskIDs[0], userID, "Sub 1", nil, "todo", nil, nil, nil, now, now).
+			AddRow(subtaskIDs[1], userID, "Sub 2", nil, "in-progress", nil, nil, nil, now, now)
+	}
+	completedRows := func(status string) *sqlmock.Rows {
+		return sqlmock.NewRows(taskColumns).AddRow(
+			taskID, userID, "Test Task", nil, status, nil,
 			nil, now, now, now,
 		)
+	}
 
+	t.Run("successful completion", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL .* FOR UPDATE`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(openRows(nil))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2 AND completed_at IS NULL\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(userID, taskID).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET .* WHERE \(id = \$\d+ AND user_id = \$\d+\)`).
+			WithArgs(sqlmock.AnyArg(), "done", sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
-			WithArgs(taskID, 1).
-			WillReturnRows(rows)
+			WillReturnRows(completedRows("done"))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "completed", "status", "todo", "done",
+				taskID, userID, "completed", "completed_at", nil, sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
 
-		task, err := CompleteTask(taskID)
+		task, next, err := CompleteTask(userID, taskID, 0, SubtaskPolicyRefuse)
 
 		assert.NoError(t, err)
+		assert.Nil(t, next)
 		assert.NotNil(t, task)
 		assert.Equal(t, "done", task.Status)
 		assert.NotNil(t, task.CompletedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("task not found", func(t *testing.T) {
+	t.Run("creates the next occurrence of a recurring task", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		nextID := uuid.New()
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		// The clocks go forward between the two Mondays; the task stays at 09:00
+		due := time.Date(2026, 3, 23, 9, 0, 0, 0, berlin)
+		recurringColumns := append(append([]string{}, taskColumns...), "recurrence", "recurrence_tz", "occurrence")
+		recurringRows := func(status string, completedAt interface{}) *sqlmock.Rows {
+			return sqlmock.NewRows(recurringColumns).AddRow(
+				taskID, userID, "Water plants", nil, status, nil,
+				due, completedAt, now, now, "FREQ=WEEKLY;BYDAY=MO", "Europe/Berlin", 3,
+			)
+		}
+
 		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(recurringRows("todo", nil))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET .* WHERE \(id = \$\d+ AND user_id = \$\d+\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(recurringRows("done", now))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectQuery(`INSERT INTO "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(nextID))
+		mock.ExpectExec(`INSERT INTO tasks.task_labels \(task_id, label_id\)\s+SELECT \$1, label_id FROM tasks.task_labels WHERE task_id = \$2`).
+			WithArgs(nextID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO tasks.reminders \(task_id, user_id, email, offset_minutes\)\s+SELECT \$1, user_id, email, offset_minutes FROM tasks.reminders WHERE task_id = \$2`).
+			WithArgs(nextID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(nextID, userID, "created", nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		task, next, err := CompleteTask(userID, taskID, 0, SubtaskPolicyRefuse)
+
+		require.NoError(t, err)
+		assert.Equal(t, "done", task.Status)
+		require.NotNil(t, next)
+		assert.Equal(t, nextID, next.ID)
+		assert.Equal(t, "todo", next.Status)
+		assert.Equal(t, 4, next.Occurrence)
+		assert.Nil(t, next.CompletedAt)
+		assert.Equal(t, time.Date(2026, 3, 30, 9, 0, 0, 0, berlin), next.DueDate.In(berlin))
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO", *next.Recurrence)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("does not schedule again when the task was already done", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		due := time.Date(2026, 3, 23, 9, 0, 0, 0, time.UTC)
+		recurringColumns := append(append([]string{}, taskColumns...), "recurrence")
+		doneRows := func() *sqlmock.Rows {
+			return sqlmock.NewRows(recurringColumns).AddRow(
+				taskID, userID, "Water plants", nil, "done", nil,
+				due, now, now, now, "FREQ=DAILY",
+			)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(doneRows())
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(doneRows())
+		mock.ExpectCommit()
+
+		_, next, err := CompleteTask(userID, taskID, 0, SubtaskPolicyRefuse)
+
+		require.NoError(t, err)
+		assert.Nil(t, next)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("uses the first done status of the project workflow", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		projectID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(openRows(projectID))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses" WHERE project_id = \$1 ORDER BY position`).
+			WithArgs(projectID).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns).
+				AddRow(uuid.New(), projectID, "todo", "To Do", "not-started", 0).
+				AddRow(uuid.New(), projectID, "shipped", "Shipped", "done", 1).
+				AddRow(uuid.New(), projectID, "wont-do", "Won't do", "done", 2))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_transitions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"project_id", "from_status", "to_status"}))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
 		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
-			WillReturnResult(sqlmock.NewResult(0, 0))
+			WithArgs(sqlmock.AnyArg(), "shipped", sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(completedRows("shipped"))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
 		mock.ExpectCommit()
 
-		task, err := CompleteTask(taskID)
+		task, _, err := CompleteTask(userID, taskID, 0, SubtaskPolicyRefuse)
+
+		require.NoError(t, err)
+		assert.Equal(t, "shipped", task.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		otherUserID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, otherUserID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
+
+		task, _, err := CompleteTask(otherUserID, taskID, 0, SubtaskPolicyRefuse)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses with open subtasks", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(openRows(nil))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(openSubtaskRows())
+		mock.ExpectRollback()
+
+		task, _, err := CompleteTask(userID, taskID, 0, SubtaskPolicyRefuse)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrOpenSubtasks)
 		assert.Nil(t, task)
-		assert.Contains(t, err.Error(), "task not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cascades to open subtasks", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(openRows(nil))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(openSubtaskRows())
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET .* WHERE \(user_id = \$\d+ AND parent_id = \$\d+ AND completed_at IS NULL\)`).
+			WithArgs(sqlmock.AnyArg(), "done", sqlmock.AnyArg(), userID, taskID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET .* WHERE \(id = \$\d+ AND user_id = \$\d+\)`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(completedRows("done"))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				subtaskIDs[0], userID, "completed", "status", "todo", "done",
+				subtaskIDs[0], userID, "completed", "completed_at", nil, sqlmock.AnyArg(),
+				subtaskIDs[1], userID, "completed", "status", "in-progress", "done",
+				subtaskIDs[1], userID, "completed", "completed_at", nil, sqlmock.AnyArg(),
+				taskID, userID, "completed", "status", "todo", "done",
+				taskID, userID, "completed", "completed_at", nil, sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
+				AddRow(uuid.New(), now).AddRow(uuid.New(), now).AddRow(uuid.New(), now).
+				AddRow(uuid.New(), now).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		task, _, err := CompleteTask(userID, taskID, 0, SubtaskPolicyCascade)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "done", task.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestValidateParent(t *testing.T) {
+	userID := uuid.New()
+	parentID := uuid.New()
+	grandparentID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "parent_id")
+
+	t.Run("top-level parent", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(parentID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(parentID, userID, "P", nil, "todo", nil, nil, nil, now, now, nil))
+
+		parent, err := ValidateParent(userID, parentID)
+		assert.NoError(t, err)
+		assert.Equal(t, parentID, parent.ID)
 	})
+
+	t.Run("parent is itself a subtask", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(parentID, userID, "P", nil, "todo", nil, nil, nil, now, now, grandparentID))
+
+		_, err := ValidateParent(userID, parentID)
+		assert.ErrorIs(t, err, ErrSubtaskDepth)
+	})
+
+	t.Run("parent owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := ValidateParent(userID, parentID)
+		assert.ErrorIs(t, err, ErrParentNotFound)
+	})
+}
+
+func TestGetTaskProgress(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	withItems, empty := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`SELECT t.id AS task_id,.* FROM tasks.tasks AS t WHERE t.id IN \(\$1,\$2\)`).
+		WithArgs(withItems, empty).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "checklist_done", "checklist_total", "subtasks_done", "subtasks_total"}).
+			AddRow(withItems, 2, 3, 1, 2).
+			AddRow(empty, 0, 0, 0, 0))
+
+	progress, err := GetTaskProgress([]uuid.UUID{withItems, empty})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, progress[withItems].Done)
+	assert.Equal(t, 5, progress[withItems].Total)
+	assert.NotContains(t, progress, empty)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var ErrEmptySearchQuery = errors.New("search query must contain at least one letter or digit")
+
+const (
+	searchHighlightStart = "<mark>"
+	searchHighlightStop  = "</mark>"
+)
+
+// TaskSearchResult is a task matched by SearchTasks with its relevance and highlighted snippets
+type TaskSearchResult struct {
+	model.Task
+	SearchRank           float64 `gorm:"column:search_rank"`
+	TitleHighlight       string  `gorm:"column:title_highlight"`
+	DescriptionHighlight *string `gorm:"column:description_highlight"`
+}
+
+// prefixTSQuery turns free text into a to_tsquery expression where every term
+// must match and each term also matches as a prefix ("desig rev" -> "desig:* & rev:*").
+// Anything other than letters and digits is dropped so user input can never
+// inject tsquery operators.
+func prefixTSQuery(q string) string {
+	terms := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term) + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// SearchTasks runs a full-text search over userID's task titles and descriptions,
+// narrowed by the same filters as ListTasks and ordered by relevance
+func SearchTasks(userID uuid.UUID, q string, filter TaskFilter, offset int) ([]TaskSearchResult, error) {
+	tsquery := prefixTSQuery(q)
+	if tsquery == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultTaskPageSize
+	}
+	if limit > MaxTaskPageSize {
+		limit = MaxTaskPageSize
+	}
+
+	headline := "StartSel=" + searchHighlightStart + ", StopSel=" + searchHighlightStop
+	selectClause := "tasks.*, " +
+		"ts_rank_cd(search_vector, query) AS search_rank, " +
+		"ts_headline('english', title, query, '" + headline + ", HighlightAll=true') AS title_highlight, " +
+		"ts_headline('english', description, query, '" + headline + ", MaxFragments=2, MinWords=5, MaxWords=25') AS description_highlight"
+
+	// Unscoped because GORM would qualify the soft-delete check with the
+	// query alias; trashed tasks are excluded explicitly instead
+	query := DB.Unscoped().Table("tasks.tasks, to_tsquery('english', ?) AS query", tsquery).
+		Select(selectClause).
+		Where("user_id = ?", userID).
+		Where("tasks.deleted_at IS NULL").
+		Where("search_vector @@ query")
+	query = applyTaskFilter(query, filter)
+
+	var results []TaskSearchResult
+	err := query.Order("search_rank DESC, updated_at DESC, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
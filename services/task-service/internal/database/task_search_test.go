@@ -0,0 +1,83 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"design", "design:*"},
+		{"  Design   Review ", "design:* & review:*"},
+		{"fix: login (bug) & !crash", "fix:* & login:* & bug:* & crash:*"},
+		{"café 2025", "café:* & 2025:*"},
+		{"'; DROP TABLE --", "drop:* & table:*"},
+		{"&|!():*", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, prefixTSQuery(tt.input))
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("ranks and highlights matches scoped to the user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		taskID := uuid.New()
+		now := time.Now()
+		columns := append(append([]string{}, taskColumns...), "rank", "search_rank", "title_highlight", "description_highlight")
+
+		mock.ExpectQuery(`SELECT tasks\.\*, ts_rank_cd\(search_vector, query\) AS search_rank, .* FROM tasks.tasks, to_tsquery\('english', \$1\) AS query WHERE user_id = \$2 AND tasks.deleted_at IS NULL AND search_vector @@ query AND status IN \(\$3\) ORDER BY search_rank DESC, updated_at DESC, id LIMIT \$4`).
+			WithArgs("design:* & rev:*", userID, "todo", 20).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(
+				taskID, userID, "Design review", nil, "todo", nil, nil, nil, now, now,
+				"a0", 0.6, "<mark>Design</mark> <mark>review</mark>", nil,
+			))
+
+		results, err := SearchTasks(userID, "design rev", TaskFilter{Statuses: []string{"todo"}, Limit: 20}, 0)
+
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, taskID, results[0].ID)
+		assert.Equal(t, 0.6, results[0].SearchRank)
+		assert.Equal(t, "a0", results[0].Rank, "the board rank is not overwritten by the search score")
+		assert.Equal(t, "<mark>Design</mark> <mark>review</mark>", results[0].TitleHighlight)
+		assert.Nil(t, results[0].DescriptionHighlight)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("applies offset", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`LIMIT \$3 OFFSET \$4`).
+			WithArgs("design:*", userID, DefaultTaskPageSize, 40).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		_, err := SearchTasks(userID, "design", TaskFilter{}, 40)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects queries without searchable terms", func(t *testing.T) {
+		_, err := SearchTasks(userID, "!!!", TaskFilter{}, 0)
+
+		assert.ErrorIs(t, err, ErrEmptySearchQuery)
+	})
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/stream"
+)
+
+// GetTaskStreamEvents returns the user's task changes after afterID, oldest
+// first, for a stream resuming from afterID. Events recorded together for a
+// task and action collapse into one, like the live notifications do.
+func GetTaskStreamEvents(userID uuid.UUID, afterID int64, limit int) ([]stream.Event, error) {
+	var events []stream.Event
+	err := DB.Raw(`
+		SELECT max(seq) AS id, user_id, task_id, action
+		FROM tasks.task_events
+		WHERE user_id = ? AND seq > ?
+		GROUP BY user_id, task_id, action, created_at
+		ORDER BY id
+		LIMIT ?`, userID, afterID, limit).
+		Scan(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch task stream events: %w", err)
+	}
+
+	return events, nil
+}
+
+// LatestTaskStreamEventID returns the ID of the user's latest task change, or
+// 0 if there is none, for a new stream to resume from later
+func LatestTaskStreamEventID(userID uuid.UUID) (int64, error) {
+	var id int64
+	err := DB.Raw(`SELECT COALESCE(max(seq), 0) FROM tasks.task_events WHERE user_id = ?`, userID).
+		Scan(&id).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch latest task stream event: %w", err)
+	}
+
+	return id, nil
+}
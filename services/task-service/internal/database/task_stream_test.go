@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/stream"
+)
+
+func TestGetTaskStreamEvents(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+	taskID := uuid.New()
+
+	mock.ExpectQuery(`SELECT max\(seq\) AS id, user_id, task_id, action FROM tasks.task_events WHERE user_id = \$1 AND seq > \$2 GROUP BY user_id, task_id, action, created_at ORDER BY id LIMIT \$3`).
+		WithArgs(userID, int64(40), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "task_id", "action"}).
+			AddRow(43, userID, taskID, "updated").
+			AddRow(44, userID, taskID, "deleted"))
+
+	events, err := GetTaskStreamEvents(userID, 40, 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, []stream.Event{
+		{ID: 43, UserID: userID, TaskID: taskID, Action: "updated"},
+		{ID: 44, UserID: userID, TaskID: taskID, Action: "deleted"},
+	}, events)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLatestTaskStreamEventID(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT COALESCE\(max\(seq\), 0\) FROM tasks.task_events WHERE user_id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(17))
+
+	id, err := LatestTaskStreamEventID(userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(17), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
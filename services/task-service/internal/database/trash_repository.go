@@ -0,0 +1,210 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var ErrParentTrashed = errors.New("the parent task is in the trash; restore it first")
+
+// trashedBy scopes a query to userID's tasks in the trash
+func trashedBy(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	}
+}
+
+// GetTrash returns userID's trashed tasks, most recently deleted first
+func GetTrash(userID uuid.UUID) ([]model.Task, error) {
+	var tasks []model.Task
+	if err := DB.Scopes(trashedBy(userID)).Order("deleted_at DESC, id").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// getTrashedTask returns one of userID's trashed tasks. Tasks that are not in
+// the trash are reported as ErrTaskNotFound.
+func getTrashedTask(db *gorm.DB, userID, taskID uuid.UUID) (*model.Task, error) {
+	var task model.Task
+	if err := db.Scopes(trashedBy(userID)).Where("id = ?", taskID).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// RestoreTask takes one of userID's tasks out of the trash, along with the
+// subtasks that were trashed with it. A subtask can only be restored on its
+// own while its parent is live.
+func RestoreTask(userID, taskID uuid.UUID) (*model.Task, error) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		task, err := getTrashedTask(tx, userID, taskID)
+		if err != nil {
+			return err
+		}
+
+		if task.ParentID != nil {
+			var parent model.Task
+			if err := tx.Unscoped().Scopes(ownedBy(userID, *task.ParentID)).First(&parent).Error; err != nil {
+				return fmt.Errorf("failed to load parent task: %w", err)
+			}
+			if parent.DeletedAt.Valid {
+				return ErrParentTrashed
+			}
+		}
+
+		family := tx.Unscoped().Model(&model.Task{}).
+			Where("user_id = ? AND (id = ? OR (parent_id = ? AND deleted_at = ?))", userID, taskID, taskID, task.DeletedAt.Time)
+
+		var ids []uuid.UUID
+		if err := family.Session(&gorm.Session{}).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to load tasks to restore: %w", err)
+		}
+
+		if err := family.Session(&gorm.Session{}).Update("deleted_at", nil).Error; err != nil {
+			return fmt.Errorf("failed to restore task: %w", err)
+		}
+
+		events := make([]model.TaskEvent, len(ids))
+		for i, id := range ids {
+			events[i] = taskEvent(userID, id, model.TaskEventRestored)
+		}
+
+		return recordTaskEvents(tx, events)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetTask(userID, taskID)
+}
+
+// PurgeTask permanently deletes one of userID's trashed tasks and its
+// subtasks. It returns the storage keys of their attachments, which the
+// caller must delete from the blob store.
+func PurgeTask(userID, taskID uuid.UUID) ([]string, error) {
+	var keys []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		task, err := getTrashedTask(tx, userID, taskID)
+		if err != nil {
+			return err
+		}
+
+		keys, err = purgeTasks(tx, []model.Task{*task})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// EmptyTrash permanently deletes all of userID's trashed tasks. It returns
+// the storage keys of their attachments.
+func EmptyTrash(userID uuid.UUID) ([]string, error) {
+	var keys []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var tasks []model.Task
+		if err := tx.Scopes(trashedBy(userID)).Find(&tasks).Error; err != nil {
+			return fmt.Errorf("failed to load trash: %w", err)
+		}
+
+		var err error
+		keys, err = purgeTasks(tx, tasks)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// PurgeExpiredTrash permanently deletes up to limit tasks of any user that
+// were trashed before cutoff. It returns the storage keys of their
+// attachments and how many trashed tasks were selected; fewer than limit
+// means the expired trash is empty.
+func PurgeExpiredTrash(cutoff time.Time, limit int) ([]string, int, error) {
+	var keys []string
+	var count int
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var tasks []model.Task
+		err := tx.Unscoped().
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Order("deleted_at, id").
+			Limit(limit).
+			Find(&tasks).Error
+		if err != nil {
+			return fmt.Errorf("failed to load expired trash: %w", err)
+		}
+		count = len(tasks)
+
+		keys, err = purgeTasks(tx, tasks)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, count, nil
+}
+
+// purgeTasks hard-deletes tasks and, through the foreign keys, their
+// subtasks, comments, checklists and attachment rows. A purge event is
+// recorded for every deleted task on behalf of its owner.
+func purgeTasks(tx *gorm.DB, tasks []model.Task) ([]string, error) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	var subtasks []model.Task
+	if err := tx.Unscoped().Select("id", "user_id").Where("parent_id IN ?", ids).Find(&subtasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load subtasks: %w", err)
+	}
+
+	var keys []string
+	err := tx.Model(&model.Attachment{}).
+		Joins("JOIN tasks.tasks AS t ON t.id = attachments.task_id").
+		Where("t.id IN ? OR t.parent_id IN ?", ids, ids).
+		Pluck("attachments.storage_key", &keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load attachments: %w", err)
+	}
+
+	if err := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Task{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to purge tasks: %w", err)
+	}
+
+	// A subtask purged along with its parent may also be in tasks itself
+	var events []model.TaskEvent
+	seen := make(map[uuid.UUID]bool, len(tasks)+len(subtasks))
+	for _, task := range append(append([]model.Task{}, tasks...), subtasks...) {
+		if seen[task.ID] {
+			continue
+		}
+		seen[task.ID] = true
+		events = append(events, taskEvent(task.UserID, task.ID, model.TaskEventPurged))
+	}
+
+	if err := recordTaskEvents(tx, events); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var trashedTaskColumns = append(append([]string{}, taskColumns...), "deleted_at")
+
+func TestGetTrash(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	userID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(trashedTaskColumns).
+			AddRow(uuid.New(), userID, "Trashed", nil, "todo", nil, nil, nil, now, now, now))
+
+	tasks, err := GetTrash(userID)
+
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.True(t, tasks[0].DeletedAt.Valid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTask(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	t.Run("restores the task and the subtasks trashed with it", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		subtaskID := uuid.New()
+		deletedAt := now.Add(-time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(trashedTaskColumns).
+				AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, deletedAt))
+		mock.ExpectQuery(`SELECT "id" FROM "tasks"."tasks" WHERE user_id = \$1 AND \(id = \$2 OR \(parent_id = \$3 AND deleted_at = \$4\)\)`).
+			WithArgs(userID, taskID, taskID, deletedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(taskID).AddRow(subtaskID))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE user_id = \$3 AND \(id = \$4 OR \(parent_id = \$5 AND deleted_at = \$6\)\)`).
+			WithArgs(nil, sqlmock.AnyArg(), userID, taskID, taskID, deletedAt).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "restored", nil, nil, nil,
+				subtaskID, userID, "restored", nil, nil, nil,
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+
+		task, err := RestoreTask(userID, taskID)
+
+		require.NoError(t, err)
+		assert.Equal(t, taskID, task.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses while the parent is in the trash", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		parentID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(append(trashedTaskColumns, "parent_id")).
+				AddRow(taskID, userID, "Subtask", nil, "todo", nil, nil, nil, now, now, now, parentID))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(parentID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(trashedTaskColumns).
+				AddRow(parentID, userID, "Parent", nil, "todo", nil, nil, nil, now, now, now))
+		mock.ExpectRollback()
+
+		task, err := RestoreTask(userID, taskID)
+
+		assert.ErrorIs(t, err, ErrParentTrashed)
+		assert.Nil(t, task)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task not in the trash", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(trashedTaskColumns))
+		mock.ExpectRollback()
+
+		_, err := RestoreTask(userID, taskID)
+
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	ownerA, ownerB := uuid.New(), uuid.New()
+	taskA, taskB := uuid.New(), uuid.New()
+	now := time.Now()
+	key := "tasks/" + taskA.String() + "/" + uuid.NewString()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE deleted_at IS NOT NULL AND deleted_at < \$1 ORDER BY deleted_at, id LIMIT \$2`).
+		WithArgs(cutoff, 2).
+		WillReturnRows(sqlmock.NewRows(trashedTaskColumns).
+			AddRow(taskA, ownerA, "A", nil, "todo", nil, nil, nil, now, now, cutoff.Add(-time.Hour)).
+			AddRow(taskB, ownerB, "B", nil, "todo", nil, nil, nil, now, now, cutoff.Add(-time.Minute)))
+	mock.ExpectQuery(`SELECT "id","user_id" FROM "tasks"."tasks" WHERE parent_id IN \(\$1,\$2\)`).
+		WithArgs(taskA, taskB).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}))
+	mock.ExpectQuery(`SELECT "attachments"."storage_key" FROM "tasks"."attachments" JOIN tasks.tasks AS t`).
+		WithArgs(taskA, taskB, taskA, taskB).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow(key))
+	mock.ExpectExec(`DELETE FROM "tasks"."tasks" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(taskA, taskB).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+		WithArgs(
+			taskA, ownerA, "purged", nil, nil, nil,
+			taskB, ownerB, "purged", nil, nil, nil,
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+	expectNoWebhooks(mock)
+	mock.ExpectCommit()
+
+	keys, count, err := PurgeExpiredTrash(cutoff, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{key}, keys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
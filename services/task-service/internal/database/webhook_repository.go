@@ -0,0 +1,320 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const (
+	DefaultWebhookDeliveryPageSize = 50
+	MaxWebhookDeliveryPageSize     = 200
+)
+
+// webhookOwnedBy scopes a query to a single webhook belonging to userID
+func webhookOwnedBy(userID, webhookID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("id = ? AND user_id = ?", webhookID, userID)
+	}
+}
+
+func CreateWebhook(webhook *model.Webhook) error {
+	if webhook == nil {
+		return fmt.Errorf("webhook cannot be nil")
+	}
+
+	return DB.Create(webhook).Error
+}
+
+func GetWebhooks(userID uuid.UUID) ([]model.Webhook, error) {
+	var webhooks []model.Webhook
+	if err := DB.Where("user_id = ?", userID).Order("created_at, id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func GetWebhook(userID, webhookID uuid.UUID) (*model.Webhook, error) {
+	var webhook model.Webhook
+	if err := DB.Scopes(webhookOwnedBy(userID, webhookID)).First(&webhook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func UpdateWebhook(userID, webhookID uuid.UUID, updates map[string]interface{}) (*model.Webhook, error) {
+	result := DB.Model(&model.Webhook{}).Scopes(webhookOwnedBy(userID, webhookID)).Updates(updates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	return GetWebhook(userID, webhookID)
+}
+
+// DeleteWebhook removes a webhook; its deliveries are removed by ON DELETE
+// CASCADE
+func DeleteWebhook(userID, webhookID uuid.UUID) error {
+	result := DB.Scopes(webhookOwnedBy(userID, webhookID)).Delete(&model.Webhook{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete webhook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the latest limit deliveries of one of userID's
+// webhooks, newest first
+func GetWebhookDeliveries(userID, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := GetWebhook(userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	var deliveries []model.WebhookDelivery
+	err := DB.Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// QueueTestEvent queues a webhook.test event for one of userID's webhooks,
+// whether or not it is active
+func QueueTestEvent(userID, webhookID uuid.UUID) (*model.WebhookDelivery, error) {
+	if _, err := GetWebhook(userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	event := model.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      model.WebhookEventTest,
+		CreatedAt: time.Now().UTC(),
+		Data:      model.WebhookEventData{Message: "This is a test event."},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := model.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: event.CreatedAt,
+	}
+	if err := DB.Create(&delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue test event: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// enqueueWebhookDeliveries queues, as part of tx, one webhook event per task
+// and action in events for every active webhook of the task's owner that
+// subscribes to it. Queuing in the same transaction as the change means an
+// event is sent if and only if the change is committed.
+func enqueueWebhookDeliveries(tx *gorm.DB, events []model.TaskEvent) error {
+	type key struct {
+		taskID uuid.UUID
+		action string
+	}
+	var keys []key
+	grouped := map[key][]model.TaskEvent{}
+	var userIDs []uuid.UUID
+	seenUsers := map[uuid.UUID]bool{}
+
+	for _, event := range events {
+		k := key{event.TaskID, event.Action}
+		if _, ok := grouped[k]; !ok {
+			keys = append(keys, k)
+		}
+		grouped[k] = append(grouped[k], event)
+
+		if !seenUsers[event.UserID] {
+			seenUsers[event.UserID] = true
+			userIDs = append(userIDs, event.UserID)
+		}
+	}
+
+	var webhooks []model.Webhook
+	if err := tx.Where("user_id IN ? AND active", userIDs).Find(&webhooks).Error; err != nil {
+		return fmt.Errorf("failed to look up webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	// Work out which events anyone subscribes to before loading their tasks
+	subscribers := map[key][]model.Webhook{}
+	var taskIDs []uuid.UUID
+	for _, k := range keys {
+		eventType := model.WebhookEventType(k.action)
+		owner := grouped[k][0].UserID
+		for _, webhook := range webhooks {
+			if webhook.UserID == owner && webhook.Events.Has(eventType) {
+				subscribers[k] = append(subscribers[k], webhook)
+			}
+		}
+		if len(subscribers[k]) > 0 {
+			taskIDs = append(taskIDs, k.taskID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	// Deleted tasks are still in the table; purged ones are gone and are
+	// sent without a task
+	var tasks []model.Task
+	if err := tx.Unscoped().Where("id IN ?", taskIDs).Find(&tasks).Error; err != nil {
+		return fmt.Errorf("failed to load tasks for webhooks: %w", err)
+	}
+	byID := make(map[uuid.UUID]*model.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	now := time.Now().UTC()
+	var deliveries []model.WebhookDelivery
+	for _, k := range keys {
+		if len(subscribers[k]) == 0 {
+			continue
+		}
+
+		taskID := k.taskID
+		event := model.WebhookEvent{
+			ID:        uuid.New(),
+			Type:      model.WebhookEventType(k.action),
+			CreatedAt: now,
+			Data:      model.WebhookEventData{TaskID: &taskID, Task: byID[taskID]},
+		}
+		for _, e := range grouped[k] {
+			if e.Field != nil {
+				event.Data.Changes = append(event.Data.Changes, model.FieldChange{
+					Field:    *e.Field,
+					OldValue: e.OldValue,
+					NewValue: e.NewValue,
+				})
+			}
+		}
+
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		for _, webhook := range subscribers[k] {
+			deliveries = append(deliveries, model.WebhookDelivery{
+				WebhookID:     webhook.ID,
+				EventID:       event.ID,
+				EventType:     event.Type,
+				Payload:       payload,
+				Status:        model.WebhookDeliveryPending,
+				NextAttemptAt: now,
+			})
+		}
+	}
+
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+	}
+	return nil
+}
+
+// DueWebhookDelivery is a claimed delivery together with where to send it
+type DueWebhookDelivery struct {
+	model.WebhookDelivery
+	URL    string `gorm:"column:url"`
+	Secret string `gorm:"column:secret"`
+}
+
+// claimWebhookDeliveriesSQL leases up to @limit pending deliveries of active
+// webhooks whose next attempt is due at @now to @owner, by moving their next
+// attempt to @lease_until. SKIP LOCKED keeps concurrent claims from blocking
+// on or taking the same rows.
+const claimWebhookDeliveriesSQL = `
+UPDATE tasks.webhook_deliveries AS d
+SET lease_owner = @owner, next_attempt_at = @lease_until, attempts = d.attempts + 1
+FROM tasks.webhooks AS w
+WHERE w.id = d.webhook_id AND d.id IN (
+	SELECT q.id FROM tasks.webhook_deliveries AS q
+	JOIN tasks.webhooks AS qw ON qw.id = q.webhook_id
+	WHERE q.status = 'pending' AND q.next_attempt_at <= @now
+		AND (qw.active OR q.event_type = 'webhook.test')
+	ORDER BY q.next_attempt_at
+	LIMIT @limit
+	FOR UPDATE OF q SKIP LOCKED
+)
+RETURNING d.*, w.url, w.secret`
+
+// ClaimWebhookDeliveries leases up to limit due deliveries to owner for
+// lease, so no other dispatcher replica sends them while owner does
+func ClaimWebhookDeliveries(owner string, now time.Time, lease time.Duration, limit int) ([]DueWebhookDelivery, error) {
+	var due []DueWebhookDelivery
+	err := DB.Raw(claimWebhookDeliveriesSQL, map[string]interface{}{
+		"owner":       owner,
+		"lease_until": now.Add(lease),
+		"now":         now,
+		"limit":       limit,
+	}).Scan(&due).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return due, nil
+}
+
+// MarkWebhookDelivered records that the receiver of a delivery owner had
+// claimed accepted it with responseStatus
+func MarkWebhookDelivered(deliveryID uuid.UUID, owner string, responseStatus int, now time.Time) error {
+	return DB.Model(&model.WebhookDelivery{}).Scopes(leasedBy(deliveryID, owner)).Updates(map[string]interface{}{
+		"status":          model.WebhookDeliverySucceeded,
+		"response_status": responseStatus,
+		"last_error":      nil,
+		"delivered_at":    now,
+		"lease_owner":     nil,
+	}).Error
+}
+
+// MarkWebhookFailed records a failed attempt and releases the lease. The
+// delivery is retried from retryAt, or, if retryAt is nil, marked failed for
+// good. responseStatus is nil when the receiver could not be reached.
+func MarkWebhookFailed(deliveryID uuid.UUID, owner string, responseStatus *int, deliveryErr error, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"response_status": responseStatus,
+		"last_error":      deliveryErr.Error(),
+		"lease_owner":     nil,
+	}
+	if retryAt != nil {
+		updates["next_attempt_at"] = *retryAt
+	} else {
+		updates["status"] = model.WebhookDeliveryFailed
+	}
+
+	return DB.Model(&model.WebhookDelivery{}).Scopes(leasedBy(deliveryID, owner)).Updates(updates).Error
+}
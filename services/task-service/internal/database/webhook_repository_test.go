@@ -0,0 +1,212 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var webhookColumns = []string{"id", "user_id", "url", "secret", "events", "active", "created_at", "updated_at"}
+
+// expectNoWebhooks expects the webhook lookup that follows recording task
+// events, for a user without webhooks
+func expectNoWebhooks(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks" WHERE user_id IN \(.+\) AND active`).
+		WillReturnRows(sqlmock.NewRows(webhookColumns))
+}
+
+// capturePayload is an argument matcher that keeps the payload it is given
+type capturePayload struct {
+	payload *model.WebhookEvent
+}
+
+func (c capturePayload) Match(v driver.Value) bool {
+	raw, ok := v.(string)
+	return ok && json.Unmarshal([]byte(raw), c.payload) == nil
+}
+
+func TestEnqueueWebhookDeliveries(t *testing.T) {
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+	title := "title"
+	oldTitle, newTitle := "Old", "New"
+
+	events := []model.TaskEvent{
+		{TaskID: taskID, UserID: userID, Action: model.TaskEventUpdated, Field: &title, OldValue: &oldTitle, NewValue: &newTitle},
+	}
+
+	t.Run("queues a signed-up event with the task and its changes", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		subscribed, other := uuid.New(), uuid.New()
+		var payload model.WebhookEvent
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks" WHERE user_id IN \(\$1\) AND active`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).
+				AddRow(subscribed, userID, "https://example.com/hook", "0123456789abcdef", "task.created,task.updated", true, now, now).
+				AddRow(other, userID, "https://example.com/other", "0123456789abcdef", "task.completed", true, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id IN \(\$1\)`).
+			WithArgs(taskID).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "New", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."webhook_deliveries"`).
+			WithArgs(subscribed, sqlmock.AnyArg(), "task.updated", capturePayload{&payload}, "pending", 0, nil, nil, nil, nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at", "updated_at"}).AddRow(uuid.New(), now, now, now))
+		mock.ExpectCommit()
+
+		err := enqueueWebhookDeliveries(DB, events)
+
+		require.NoError(t, err)
+		assert.Equal(t, "task.updated", payload.Type)
+		assert.Equal(t, taskID, *payload.Data.TaskID)
+		require.NotNil(t, payload.Data.Task)
+		assert.Equal(t, "New", payload.Data.Task.Title)
+		require.Len(t, payload.Data.Changes, 1)
+		assert.Equal(t, "title", payload.Data.Changes[0].Field)
+		assert.Equal(t, "Old", *payload.Data.Changes[0].OldValue)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing is queued without a subscriber", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).
+				AddRow(uuid.New(), userID, "https://example.com/hook", "0123456789abcdef", "task.deleted", true, now, now))
+
+		require.NoError(t, enqueueWebhookDeliveries(DB, events))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetWebhookDeliveries(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	userID := uuid.New()
+	webhookID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(webhookID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(webhookColumns).
+			AddRow(webhookID, userID, "https://example.com/hook", "0123456789abcdef", "task.created", true, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."webhook_deliveries" WHERE webhook_id = \$1 ORDER BY created_at DESC, id LIMIT \$2`).
+		WithArgs(webhookID, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_type", "payload", "status"}).
+			AddRow(uuid.New(), webhookID, "task.created", `{"type":"task.created"}`, "succeeded"))
+
+	deliveries, err := GetWebhookDeliveries(userID, webhookID, 20)
+
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.JSONEq(t, `{"type":"task.created"}`, string(deliveries[0].Payload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueueTestEvent(t *testing.T) {
+	userID := uuid.New()
+	webhookID := uuid.New()
+
+	t.Run("queues a webhook.test delivery", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		now := time.Now()
+		var payload model.WebhookEvent
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).
+				AddRow(webhookID, userID, "https://example.com/hook", "0123456789abcdef", "task.created", false, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."webhook_deliveries"`).
+			WithArgs(webhookID, sqlmock.AnyArg(), "webhook.test", capturePayload{&payload}, "pending", 0, nil, nil, nil, nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at", "updated_at"}).AddRow(uuid.New(), now, now, now))
+		mock.ExpectCommit()
+
+		delivery, err := QueueTestEvent(userID, webhookID)
+
+		require.NoError(t, err)
+		assert.Equal(t, "webhook.test", delivery.EventType)
+		assert.Equal(t, delivery.EventID, payload.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("webhook owned by another user", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns))
+
+		_, err := QueueTestEvent(uuid.New(), webhookID)
+
+		assert.ErrorIs(t, err, ErrWebhookNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	gormDB, mock := setupMockDB(t)
+	DB = gormDB
+	now := time.Now()
+	deliveryID := uuid.New()
+
+	mock.ExpectQuery(`UPDATE tasks.webhook_deliveries AS d SET lease_owner = \$1, next_attempt_at = \$2, attempts = d.attempts \+ 1 .* FOR UPDATE OF q SKIP LOCKED \) RETURNING d.\*, w.url, w.secret`).
+		WithArgs("replica-1", now.Add(time.Minute), now, 25).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_type", "payload", "attempts", "url", "secret"}).
+			AddRow(deliveryID, uuid.New(), "task.created", `{}`, 1, "https://example.com/hook", "0123456789abcdef"))
+
+	claimed, err := ClaimWebhookDeliveries("replica-1", now, time.Minute, 25)
+
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, deliveryID, claimed[0].ID)
+	assert.Equal(t, "https://example.com/hook", claimed[0].URL)
+	assert.Equal(t, "0123456789abcdef", claimed[0].Secret)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkWebhookFailed(t *testing.T) {
+	deliveryID := uuid.New()
+	status := 503
+	sendErr := errors.New("receiver responded 503 Service Unavailable")
+
+	t.Run("schedules a retry", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		retryAt := time.Now().Add(time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."webhook_deliveries" SET "last_error"=\$1,"lease_owner"=\$2,"next_attempt_at"=\$3,"response_status"=\$4,"updated_at"=\$5 WHERE id = \$6 AND lease_owner = \$7`).
+			WithArgs(sendErr.Error(), nil, retryAt, &status, sqlmock.AnyArg(), deliveryID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, MarkWebhookFailed(deliveryID, "replica-1", &status, sendErr, &retryAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."webhook_deliveries" SET "last_error"=\$1,"lease_owner"=\$2,"response_status"=\$3,"status"=\$4,"updated_at"=\$5 WHERE id = \$6 AND lease_owner = \$7`).
+			WithArgs(sendErr.Error(), nil, &status, "failed", sqlmock.AnyArg(), deliveryID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, MarkWebhookFailed(deliveryID, "replica-1", &status, sendErr, nil))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
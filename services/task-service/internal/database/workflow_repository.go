@@ -0,0 +1,215 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidStatus        = errors.New("invalid status")
+	ErrTransitionNotAllowed = errors.New("status transition not allowed")
+	ErrStatusInUse          = errors.New("statuses are still used by tasks; map them to a new status")
+)
+
+// GetWorkflow returns the workflow that applies to tasks in projectID. Tasks
+// outside a project, and projects without statuses of their own, use the default workflow.
+func GetWorkflow(userID uuid.UUID, projectID *uuid.UUID) (*model.Workflow, error) {
+	if projectID == nil {
+		wf := model.DefaultWorkflow()
+		return &wf, nil
+	}
+
+	if _, err := GetProject(userID, *projectID); err != nil {
+		return nil, err
+	}
+
+	return loadWorkflow(DB, *projectID)
+}
+
+// workflowFor is GetWorkflow for a project whose ownership is already established
+func workflowFor(db *gorm.DB, projectID *uuid.UUID) (*model.Workflow, error) {
+	if projectID == nil {
+		wf := model.DefaultWorkflow()
+		return &wf, nil
+	}
+	return loadWorkflow(db, *projectID)
+}
+
+func loadWorkflow(db *gorm.DB, projectID uuid.UUID) (*model.Workflow, error) {
+	var statuses []model.WorkflowStatus
+	if err := db.Where("project_id = ?", projectID).Order("position").Find(&statuses).Error; err != nil {
+		return nil, err
+	}
+
+	if len(statuses) == 0 {
+		wf := model.DefaultWorkflow()
+		return &wf, nil
+	}
+
+	transitions := []model.WorkflowTransition{}
+	if err := db.Where("project_id = ?", projectID).Order("from_status, to_status").Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.Workflow{Statuses: statuses, Transitions: transitions}, nil
+}
+
+// ReplaceWorkflow swaps projectID's workflow for wf. Tasks whose status is
+// dropped are moved to remap[status]; if a status in use has no mapping the
+// workflow is left unchanged and ErrStatusInUse is returned.
+// completed_at is brought in line with the new status categories.
+func ReplaceWorkflow(userID, projectID uuid.UUID, wf model.Workflow, remap map[string]string) (*model.Workflow, error) {
+	if _, err := GetProject(userID, projectID); err != nil {
+		return nil, err
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		// Trashed tasks are remapped too so they can be restored later
+		var inUse []string
+		if err := tx.Unscoped().Model(&model.Task{}).Where("project_id = ?", projectID).Distinct().Pluck("status", &inUse).Error; err != nil {
+			return fmt.Errorf("failed to load statuses in use: %w", err)
+		}
+
+		var unmapped []string
+		for _, key := range inUse {
+			if _, ok := wf.Status(key); ok {
+				continue
+			}
+			target, ok := remap[key]
+			if !ok || wf.ValidateStatus(target) != nil {
+				unmapped = append(unmapped, key)
+				continue
+			}
+			if err := tx.Unscoped().Model(&model.Task{}).
+				Where("project_id = ? AND status = ?", projectID, key).
+				Update("status", target).Error; err != nil {
+				return fmt.Errorf("failed to remap status %s: %w", key, err)
+			}
+		}
+		if len(unmapped) > 0 {
+			return fmt.Errorf("%w: %s", ErrStatusInUse, strings.Join(unmapped, ", "))
+		}
+
+		// Transitions go first: they reference statuses
+		if err := tx.Where("project_id = ?", projectID).Delete(&model.WorkflowTransition{}).Error; err != nil {
+			return fmt.Errorf("failed to clear transitions: %w", err)
+		}
+		if err := tx.Where("project_id = ?", projectID).Delete(&model.WorkflowStatus{}).Error; err != nil {
+			return fmt.Errorf("failed to clear statuses: %w", err)
+		}
+
+		statuses := make([]model.WorkflowStatus, len(wf.Statuses))
+		for i, s := range wf.Statuses {
+			statuses[i] = model.WorkflowStatus{ProjectID: projectID, Key: s.Key, Name: s.Name, Category: s.Category, Position: i}
+		}
+		if err := tx.Create(&statuses).Error; err != nil {
+			return fmt.Errorf("failed to save statuses: %w", err)
+		}
+
+		if len(wf.Transitions) > 0 {
+			transitions := make([]model.WorkflowTransition, len(wf.Transitions))
+			for i, t := range wf.Transitions {
+				transitions[i] = model.WorkflowTransition{ProjectID: projectID, From: t.From, To: t.To}
+			}
+			if err := tx.Create(&transitions).Error; err != nil {
+				return fmt.Errorf("failed to save transitions: %w", err)
+			}
+		}
+
+		return syncCompletedAt(tx, projectID, wf)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loadWorkflow(DB, projectID)
+}
+
+// syncCompletedAt sets completed_at on projectID's tasks in a done status and
+// clears it everywhere else
+func syncCompletedAt(tx *gorm.DB, projectID uuid.UUID, wf model.Workflow) error {
+	var done []string
+	for _, s := range wf.Statuses {
+		if s.Category == model.StatusCategoryDone {
+			done = append(done, s.Key)
+		}
+	}
+
+	if err := tx.Unscoped().Model(&model.Task{}).
+		Where("project_id = ? AND status IN ? AND completed_at IS NULL", projectID, done).
+		Update("completed_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to complete tasks: %w", err)
+	}
+
+	if err := tx.Unscoped().Model(&model.Task{}).
+		Where("project_id = ? AND status NOT IN ? AND completed_at IS NOT NULL", projectID, done).
+		Update("completed_at", nil).Error; err != nil {
+		return fmt.Errorf("failed to reopen tasks: %w", err)
+	}
+
+	return nil
+}
+
+// PrepareStatusChange checks that task may move to status under its project's
+// workflow and adds the status to updates, along with the completed_at change
+// its category implies. A completed_at already present in updates is kept.
+func PrepareStatusChange(task *model.Task, status string, updates map[string]interface{}) error {
+	return prepareStatusChange(DB, task, status, updates)
+}
+
+func prepareStatusChange(db *gorm.DB, task *model.Task, status string, updates map[string]interface{}) error {
+	wf, err := workflowFor(db, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if err := wf.ValidateStatus(status); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidStatus, err)
+	}
+
+	if !wf.CanTransition(task.Status, status) {
+		return fmt.Errorf("%w: %s -> %s", ErrTransitionNotAllowed, task.Status, status)
+	}
+
+	// The database puts the task at the end of its new column
+	updates["status"] = status
+	if _, ok := updates["completed_at"]; !ok {
+		switch {
+		case wf.IsDone(status) && !wf.IsDone(task.Status):
+			updates["completed_at"] = time.Now()
+		case !wf.IsDone(status):
+			updates["completed_at"] = nil
+		}
+	}
+
+	return nil
+}
+
+// remapStatusesForMove returns, for each status used by the tasks being moved
+// that the target workflow lacks, the target status of the same category
+func remapStatusesForMove(used []string, from, to *model.Workflow) map[string]string {
+	remap := map[string]string{}
+	for _, key := range used {
+		if _, ok := to.Status(key); ok {
+			continue
+		}
+
+		category := model.StatusCategoryNotStarted
+		if s, ok := from.Status(key); ok {
+			category = s.Category
+		}
+
+		target, ok := to.FirstInCategory(category)
+		if !ok {
+			target, _ = to.FirstInCategory(model.StatusCategoryNotStarted)
+		}
+		remap[key] = target.Key
+	}
+	return remap
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var workflowStatusColumns = []string{"id", "project_id", "key", "name", "category", "position"}
+
+func reviewWorkflow() model.Workflow {
+	return model.Workflow{
+		Statuses: []model.WorkflowStatus{
+			{Key: "backlog", Name: "Backlog", Category: model.StatusCategoryNotStarted},
+			{Key: "in-review", Name: "In Review", Category: model.StatusCategoryActive},
+			{Key: "shipped", Name: "Shipped", Category: model.StatusCategoryDone},
+		},
+		Transitions: []model.WorkflowTransition{
+			{From: "backlog", To: "in-review"},
+			{From: "in-review", To: "shipped"},
+		},
+	}
+}
+
+func TestReplaceWorkflow(t *testing.T) {
+	userID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+
+	expectProject := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(projectID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", false, now, now))
+	}
+
+	t.Run("remaps dropped statuses and syncs completed_at", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectProject(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT DISTINCT "status" FROM "tasks"."tasks" WHERE project_id = \$1`).
+			WithArgs(projectID).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("todo").AddRow("done"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "status"=\$1,.* WHERE project_id = \$\d+ AND status = \$\d+`).
+			WithArgs("backlog", sqlmock.AnyArg(), projectID, "todo").
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "status"=\$1,.* WHERE project_id = \$\d+ AND status = \$\d+`).
+			WithArgs("shipped", sqlmock.AnyArg(), projectID, "done").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM "tasks"."workflow_transitions" WHERE project_id = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM "tasks"."workflow_statuses" WHERE project_id = \$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "tasks"."workflow_statuses"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()).AddRow(uuid.New()))
+		mock.ExpectExec(`INSERT INTO "tasks"."workflow_transitions"`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,.* WHERE project_id = \$\d+ AND status IN \(\$\d+\) AND completed_at IS NULL`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), projectID, "shipped").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,.* WHERE project_id = \$\d+ AND status NOT IN \(\$\d+\) AND completed_at IS NOT NULL`).
+			WithArgs(nil, sqlmock.AnyArg(), projectID, "shipped").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses"`).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns).
+				AddRow(uuid.New(), projectID, "backlog", "Backlog", "not-started", 0).
+				AddRow(uuid.New(), projectID, "in-review", "In Review", "active", 1).
+				AddRow(uuid.New(), projectID, "shipped", "Shipped", "done", 2))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_transitions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"project_id", "from_status", "to_status"}).
+				AddRow(projectID, "backlog", "in-review").
+				AddRow(projectID, "in-review", "shipped"))
+
+		wf, err := ReplaceWorkflow(userID, projectID, reviewWorkflow(), map[string]string{"todo": "backlog", "done": "shipped"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"backlog", "in-review", "shipped"}, wf.Keys())
+		assert.Len(t, wf.Transitions, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses to drop statuses in use without a mapping", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+
+		expectProject(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT DISTINCT "status" FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("todo").AddRow("in-progress"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "status"=\$1`).
+			WithArgs("backlog", sqlmock.AnyArg(), projectID, "todo").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		wf, err := ReplaceWorkflow(userID, projectID, reviewWorkflow(), map[string]string{"todo": "backlog"})
+
+		assert.ErrorIs(t, err, ErrStatusInUse)
+		assert.ErrorContains(t, err, "in-progress")
+		assert.Nil(t, wf)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPrepareStatusChange(t *testing.T) {
+	projectID := uuid.New()
+	completedAt := time.Now()
+
+	expectReviewWorkflow := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses"`).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns).
+				AddRow(uuid.New(), projectID, "backlog", "Backlog", "not-started", 0).
+				AddRow(uuid.New(), projectID, "in-review", "In Review", "active", 1).
+				AddRow(uuid.New(), projectID, "shipped", "Shipped", "done", 2))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_transitions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"project_id", "from_status", "to_status"}).
+				AddRow(projectID, "backlog", "in-review").
+				AddRow(projectID, "in-review", "shipped"))
+	}
+
+	t.Run("allowed transition into done sets completed_at", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		expectReviewWorkflow(mock)
+
+		updates := map[string]interface{}{}
+		err := PrepareStatusChange(&model.Task{ProjectID: &projectID, Status: "in-review"}, "shipped", updates)
+
+		require.NoError(t, err)
+		assert.Equal(t, "shipped", updates["status"])
+		assert.IsType(t, time.Time{}, updates["completed_at"])
+	})
+
+	t.Run("leaving done clears completed_at", func(t *testing.T) {
+		updates := map[string]interface{}{}
+		err := PrepareStatusChange(&model.Task{Status: "done", CompletedAt: &completedAt}, "todo", updates)
+
+		require.NoError(t, err)
+		assert.Nil(t, updates["completed_at"])
+		assert.Contains(t, updates, "completed_at")
+	})
+
+	t.Run("disallowed transition", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		expectReviewWorkflow(mock)
+
+		err := PrepareStatusChange(&model.Task{ProjectID: &projectID, Status: "backlog"}, "shipped", map[string]interface{}{})
+
+		assert.ErrorIs(t, err, ErrTransitionNotAllowed)
+	})
+
+	t.Run("status from another workflow", func(t *testing.T) {
+		gormDB, mock := setupMockDB(t)
+		DB = gormDB
+		expectReviewWorkflow(mock)
+
+		err := PrepareStatusChange(&model.Task{ProjectID: &projectID, Status: "backlog"}, "done", map[string]interface{}{})
+
+		assert.ErrorIs(t, err, ErrInvalidStatus)
+		assert.ErrorContains(t, err, "status must be one of: backlog, in-review, shipped")
+	})
+}
+
+func TestRemapStatusesForMove(t *testing.T) {
+	from := model.DefaultWorkflow()
+	to := reviewWorkflow()
+
+	remap := remapStatusesForMove([]string{"todo", "in-progress", "done"}, &from, &to)
+
+	assert.Equal(t, map[string]string{"todo": "backlog", "in-progress": "in-review", "done": "shipped"}, remap)
+}
@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/storage"
+)
+
+const (
+	// multipartOverhead is the room left for form boundaries and part headers
+	// on top of the file itself
+	multipartOverhead = 1 << 20
+	// multipartMemory is how much of an upload is buffered in memory before
+	// spilling to a temporary file
+	multipartMemory = 8 << 20
+)
+
+// writeAttachmentError maps repository errors to HTTP responses
+func writeAttachmentError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrAttachmentNotFound), errors.Is(err, storage.ErrBlobNotFound):
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// detectContentType sniffs the upload's media type. Sniffing only recognises
+// a handful of formats, so when it falls back to a generic type the type the
+// client declared is used instead, as long as it is one we accept.
+func detectContentType(file multipart.File, header *multipart.FileHeader) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	declared, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+
+	switch sniffed {
+	case "application/octet-stream", "text/plain", "application/zip":
+		if model.IsAllowedAttachmentType(declared) {
+			return declared, nil
+		}
+	}
+
+	return sniffed, nil
+}
+
+func ListAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	attachments, err := database.GetAttachments(userID, taskID)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to fetch attachments")
+		return
+	}
+
+	if attachments == nil {
+		attachments = []model.Attachment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// UploadAttachment accepts a multipart/form-data upload with the file in the
+// "file" field
+func UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	// Check ownership before accepting the body so strangers cannot fill the store
+	if _, err := database.GetTask(userID, taskID); err != nil {
+		writeAttachmentError(w, err, "Failed to upload attachment")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, model.MaxAttachmentSize+multipartOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("file must be %d bytes or less", model.MaxAttachmentSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart upload", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > model.MaxAttachmentSize {
+		http.Error(w, fmt.Sprintf("file must be %d bytes or less", model.MaxAttachmentSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType, err := detectContentType(file, header)
+	if err != nil {
+		http.Error(w, "Failed to read upload", http.StatusBadRequest)
+		return
+	}
+
+	attachment := model.Attachment{
+		ID:          uuid.New(),
+		TaskID:      taskID,
+		Filename:    header.Filename,
+		ContentType: contentType,
+		Size:        header.Size,
+	}
+	if err := attachment.Validate(); err != nil {
+		status := http.StatusBadRequest
+		if !model.IsAllowedAttachmentType(contentType) {
+			status = http.StatusUnsupportedMediaType
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	attachment.StorageKey = storage.TaskBlobKey(taskID, attachment.ID)
+
+	if err := storage.Blobs.Put(r.Context(), attachment.StorageKey, file, attachment.Size, contentType); err != nil {
+		log.Printf("Failed to store attachment %s: %v", attachment.StorageKey, err)
+		http.Error(w, "Failed to upload attachment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := database.CreateAttachment(userID, &attachment); err != nil {
+		storage.DeleteAll(r.Context(), []string{attachment.StorageKey})
+		writeAttachmentError(w, err, "Failed to upload attachment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// DownloadAttachment streams the file back as a download. Files are never
+// rendered inline so an uploaded document cannot run in the app's origin.
+func DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	attachmentID, ok := parseURLUUID(w, r, "attachmentID", "attachment")
+	if !ok {
+		return
+	}
+
+	attachment, err := database.GetAttachment(userID, taskID, attachmentID)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to fetch attachment")
+		return
+	}
+
+	blob, err := storage.Blobs.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if !errors.Is(err, storage.ErrBlobNotFound) {
+			log.Printf("Failed to read attachment %s: %v", attachment.StorageKey, err)
+		}
+		writeAttachmentError(w, err, "Failed to fetch attachment")
+		return
+	}
+	defer blob.Close()
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("Failed to stream attachment %s: %v", attachment.StorageKey, err)
+	}
+}
+
+func DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	attachmentID, ok := parseURLUUID(w, r, "attachmentID", "attachment")
+	if !ok {
+		return
+	}
+
+	attachment, err := database.DeleteAttachment(userID, taskID, attachmentID)
+	if err != nil {
+		writeAttachmentError(w, err, "Failed to delete attachment")
+		return
+	}
+
+	storage.DeleteAll(r.Context(), []string{attachment.StorageKey})
+
+	w.WriteHeader(http.StatusNoContent)
+}
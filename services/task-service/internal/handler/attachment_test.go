@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/storage"
+)
+
+var attachmentColumns = []string{"id", "task_id", "user_id", "filename", "content_type", "size", "storage_key", "created_at"}
+
+// pngHeader is enough of a PNG file for content sniffing to recognise it
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// setupBlobStore points the handlers at an empty local store for the test
+func setupBlobStore(t *testing.T) storage.BlobStore {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	previous := storage.Blobs
+	storage.Blobs = store
+	t.Cleanup(func() { storage.Blobs = previous })
+	return store
+}
+
+func newUploadRequest(t *testing.T, target, filename, contentType string, content []byte, userID uuid.UUID) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	header := make(map[string][]string)
+	header["Content-Disposition"] = []string{`form-data; name="file"; filename="` + filename + `"`}
+	header["Content-Type"] = []string{contentType}
+	part, err := form.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := newAuthedRequest(t, "POST", target, body.Bytes(), userID)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadAttachment(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	path := "/tasks/" + taskID.String() + "/attachments"
+	now := time.Now()
+
+	expectTask := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	}
+
+	t.Run("stores the file and records it", func(t *testing.T) {
+		mock := setupMockDB(t)
+		store := setupBlobStore(t)
+
+		expectTask(mock)
+		expectTask(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."attachments"`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newUploadRequest(t, path, "diagram.png", "application/octet-stream", pngHeader, userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var response model.Attachment
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "diagram.png", response.Filename)
+		assert.Equal(t, "image/png", response.ContentType, "sniffed type wins over a generic declared one")
+		assert.Equal(t, int64(len(pngHeader)), response.Size)
+		assert.NotContains(t, rr.Body.String(), "storage_key")
+
+		blob, err := store.Get(context.Background(), storage.TaskBlobKey(taskID, response.ID))
+		require.NoError(t, err)
+		stored, _ := io.ReadAll(blob)
+		blob.Close()
+		assert.Equal(t, pngHeader, stored)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("declared type is used when sniffing is inconclusive", func(t *testing.T) {
+		mock := setupMockDB(t)
+		setupBlobStore(t)
+
+		expectTask(mock)
+		expectTask(mock)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."attachments"`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newUploadRequest(t, path, "data.csv", "text/csv", []byte("a,b\n1,2\n"), userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"content_type":"text/csv"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("disguised HTML is rejected", func(t *testing.T) {
+		mock := setupMockDB(t)
+		setupBlobStore(t)
+
+		expectTask(mock)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newUploadRequest(t, path, "notes.txt", "text/plain", []byte("<html><script>alert(1)</script></html>"), userID))
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		assert.Contains(t, rr.Body.String(), "text/html is not allowed")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("file over the size limit", func(t *testing.T) {
+		mock := setupMockDB(t)
+		setupBlobStore(t)
+		defer func(limit int64) { model.MaxAttachmentSize = limit }(model.MaxAttachmentSize)
+		model.MaxAttachmentSize = 8
+
+		expectTask(mock)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newUploadRequest(t, path, "big.txt", "text/plain", []byte(strings.Repeat("a", 9)), userID))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing file field", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		expectTask(mock)
+
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("name", "value")
+		form.Close()
+		req := newAuthedRequest(t, "POST", path, body.Bytes(), userID)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "file field is required")
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newUploadRequest(t, path, "diagram.png", "image/png", pngHeader, uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDownloadAttachment(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	attachmentID := uuid.New()
+	key := storage.TaskBlobKey(taskID, attachmentID)
+	path := "/tasks/" + taskID.String() + "/attachments/" + attachmentID.String()
+	now := time.Now()
+
+	mock := setupMockDB(t)
+	store := setupBlobStore(t)
+	require.NoError(t, store.Put(context.Background(), key, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."attachments" WHERE id = \$1 AND task_id = \$2`).
+		WithArgs(attachmentID, taskID, 1).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).
+			AddRow(attachmentID, taskID, userID, "Q3 diagram.png", "image/png", len(pngHeader), key, now))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", path, nil, userID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, pngHeader, rr.Body.Bytes())
+	assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="Q3 diagram.png"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAttachment(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	attachmentID := uuid.New()
+	key := storage.TaskBlobKey(taskID, attachmentID)
+	now := time.Now()
+
+	mock := setupMockDB(t)
+	store := setupBlobStore(t)
+	require.NoError(t, store.Put(context.Background(), key, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."attachments"`).
+		WillReturnRows(sqlmock.NewRows(attachmentColumns).
+			AddRow(attachmentID, taskID, userID, "diagram.png", "image/png", len(pngHeader), key, now))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "tasks"."attachments"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/"+taskID.String()+"/attachments/"+attachmentID.String(), nil, userID))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	_, err := store.Get(context.Background(), key)
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeTaskRemovesAttachmentBlobs(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	subtaskID := uuid.New()
+	now := time.Now()
+	keys := []string{storage.TaskBlobKey(taskID, uuid.New()), storage.TaskBlobKey(subtaskID, uuid.New())}
+
+	mock := setupMockDB(t)
+	store := setupBlobStore(t)
+	for _, key := range keys {
+		require.NoError(t, store.Put(context.Background(), key, bytes.NewReader(pngHeader), int64(len(pngHeader)), "image/png"))
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+		WithArgs(taskID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT "id","user_id" FROM "tasks"."tasks" WHERE parent_id IN \(\$1\)`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(subtaskID, userID))
+	mock.ExpectQuery(`SELECT "attachments"."storage_key" FROM "tasks"."attachments" JOIN tasks.tasks AS t ON t.id = attachments.task_id WHERE t.id IN \(\$1\) OR t.parent_id IN \(\$2\)`).
+		WithArgs(taskID, taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow(keys[0]).AddRow(keys[1]))
+	mock.ExpectExec(`DELETE FROM "tasks"."tasks" WHERE id IN \(\$1\)`).
+		WithArgs(taskID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+	expectNoWebhooks(mock)
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/trash/"+taskID.String(), nil, userID))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	for _, key := range keys {
+		_, err := store.Get(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound, key)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
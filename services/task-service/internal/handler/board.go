@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+)
+
+// RepositionTaskRequest places a task in a board column, directly after
+// BeforeID and directly before AfterID. A missing neighbour means the top or
+// bottom of the column; with neither the task goes to the bottom.
+type RepositionTaskRequest struct {
+	// Status is the target column; omitted keeps the task's status
+	Status   string     `json:"status"`
+	BeforeID *uuid.UUID `json:"before_id"`
+	AfterID  *uuid.UUID `json:"after_id"`
+}
+
+// RepositionTask handles a drag and drop on the board. Moving to another
+// column follows the workflow like any status change.
+func RepositionTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	var req RepositionTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ifVersion, _, ok := taskPrecondition(w, r, userID, taskID)
+	if !ok {
+		return
+	}
+
+	task, err := database.RepositionTask(userID, taskID, ifVersion, database.TaskPosition{
+		Status:   req.Status,
+		BeforeID: req.BeforeID,
+		AfterID:  req.AfterID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrTaskNotFound):
+			http.Error(w, "Task not found", http.StatusNotFound)
+		case errors.Is(err, database.ErrVersionConflict):
+			writeTaskConflict(w, userID, taskID)
+		case errors.Is(err, database.ErrInvalidNeighbour),
+			errors.Is(err, database.ErrNeighbourOrder),
+			errors.Is(err, database.ErrInvalidStatus):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, database.ErrTransitionNotAllowed):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to move task", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", resp.ETag)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
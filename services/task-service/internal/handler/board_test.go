@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositionTask(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	beforeID := uuid.New()
+	afterID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "rank", "version")
+
+	t.Run("invalid request payload", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/move", []byte("{"), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("places the task between its neighbours", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, "x", 1))
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks"`).
+			WithArgs(userID, beforeID, "todo").
+			WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow("a"))
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks"`).
+			WithArgs(userID, afterID, "todo").
+			WillReturnRows(sqlmock.NewRows([]string{"rank"}).AddRow("c"))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "rank"=\$1`).
+			WithArgs("b", sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, "b", 2))
+		mock.ExpectCommit()
+		expectTaskEnrichment(mock, nil)
+
+		body, _ := json.Marshal(RepositionTaskRequest{BeforeID: &beforeID, AfterID: &afterID})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/move", body, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+		var resp GetTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "b", resp.Rank)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("neighbour in another column", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, "x", 1))
+		mock.ExpectQuery(`SELECT "rank" FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"rank"}))
+		mock.ExpectRollback()
+
+		body, _ := json.Marshal(RepositionTaskRequest{BeforeID: &beforeID})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/move", body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "neighbours must be other tasks")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now, "x", 1))
+		mock.ExpectRollback()
+
+		body, _ := json.Marshal(RepositionTaskRequest{Status: "shipped"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/move", body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// Bulk modes
+const (
+	// BulkModeAllOrNothing commits the batch only if every item succeeds
+	BulkModeAllOrNothing = "all_or_nothing"
+	// BulkModeBestEffort commits the items that succeed and reports the rest
+	BulkModeBestEffort = "best_effort"
+)
+
+// Bulk operations
+const (
+	BulkOpUpdate   = "update"
+	BulkOpComplete = "complete"
+	BulkOpDelete   = "delete"
+	BulkOpMove     = "move"
+	BulkOpAddLabel = "add_label"
+)
+
+// Bulk item statuses
+const (
+	BulkItemSucceeded = "succeeded"
+	BulkItemFailed    = "failed"
+	// BulkItemRolledBack is an item that succeeded in a batch that failed
+	BulkItemRolledBack = "rolled_back"
+	// BulkItemSkipped is an item not tried because the batch had already failed
+	BulkItemSkipped = "skipped"
+)
+
+// maxBulkTaskItems caps the operations times tasks in one request
+const maxBulkTaskItems = 500
+
+var errBulkAborted = errors.New("bulk operation aborted")
+
+type BulkTaskRequest struct {
+	// Mode is all_or_nothing (the default) or best_effort
+	Mode       string              `json:"mode"`
+	Operations []BulkTaskOperation `json:"operations"`
+}
+
+// BulkTaskOperation applies one operation to each of TaskIDs in turn
+type BulkTaskOperation struct {
+	// Op is update, complete, delete, move or add_label
+	Op      string      `json:"op"`
+	TaskIDs []uuid.UUID `json:"task_ids"`
+	// Fields are the changes update makes, as for PUT /tasks/{id}
+	Fields *UpdateTaskRequest `json:"fields,omitempty"`
+	// Subtasks is complete's open subtask policy: refuse (the default) or cascade
+	Subtasks string `json:"subtasks,omitempty"`
+	// ProjectID is the project move puts the tasks in; null takes them out
+	ProjectID *uuid.UUID `json:"project_id,omitempty"`
+	// LabelID is the label add_label attaches
+	LabelID *uuid.UUID `json:"label_id,omitempty"`
+}
+
+// BulkTaskResult is the outcome of one operation on one task
+type BulkTaskResult struct {
+	// Operation is the index of the operation in the request
+	Operation int       `json:"operation"`
+	TaskID    uuid.UUID `json:"task_id"`
+	Status    string    `json:"status"`
+	// Code and Error are what the single-task endpoint would have answered a
+	// failed item with
+	Code  int    `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type BulkTaskResponse struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkTaskResult `json:"results"`
+}
+
+// validate checks the request as a whole before anything is applied
+func (req *BulkTaskRequest) validate() error {
+	switch req.Mode {
+	case "":
+		req.Mode = BulkModeAllOrNothing
+	case BulkModeAllOrNothing, BulkModeBestEffort:
+	default:
+		return fmt.Errorf("mode must be %s or %s", BulkModeAllOrNothing, BulkModeBestEffort)
+	}
+
+	if len(req.Operations) == 0 {
+		return errors.New("operations must list at least one operation")
+	}
+
+	items := 0
+	for i, op := range req.Operations {
+		if err := op.validate(); err != nil {
+			return fmt.Errorf("operations[%d]: %w", i, err)
+		}
+		items += len(op.TaskIDs)
+	}
+	if items > maxBulkTaskItems {
+		return fmt.Errorf("bulk requests are limited to %d task operations, got %d", maxBulkTaskItems, items)
+	}
+
+	return nil
+}
+
+func (op BulkTaskOperation) validate() error {
+	if len(op.TaskIDs) == 0 {
+		return errors.New("task_ids must list at least one task")
+	}
+
+	switch op.Op {
+	case BulkOpUpdate:
+		if op.Fields == nil {
+			return errors.New("fields are required for update")
+		}
+	case BulkOpComplete:
+		if _, err := parseSubtaskPolicy(op.Subtasks); err != nil {
+			return err
+		}
+	case BulkOpDelete, BulkOpMove:
+	case BulkOpAddLabel:
+		if op.LabelID == nil {
+			return errors.New("label_id is required for add_label")
+		}
+	default:
+		return fmt.Errorf("unknown op %q: must be one of update, complete, delete, move, add_label", op.Op)
+	}
+
+	return nil
+}
+
+// BulkTasks applies a list of operations to many tasks in a single
+// transaction. In all_or_nothing mode the first failure rolls everything
+// back and the response carries that item's status code; in best_effort mode
+// each item is undone on its own if it fails and the rest are committed.
+func BulkTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req BulkTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	bestEffort := req.Mode == BulkModeBestEffort
+	resp := BulkTaskResponse{Mode: req.Mode, Results: []BulkTaskResult{}}
+
+	err := database.Transaction(func(tx database.Tx) error {
+		for i, op := range req.Operations {
+			for _, taskID := range op.TaskIDs {
+				apply := func(tx database.Tx) error { return applyBulkOperation(tx, userID, op, taskID) }
+
+				var err error
+				if bestEffort {
+					err = tx.Savepoint(apply)
+				} else {
+					err = apply(tx)
+				}
+
+				result := BulkTaskResult{Operation: i, TaskID: taskID, Status: BulkItemSucceeded}
+				if err != nil {
+					result.Status = BulkItemFailed
+					result.Code, result.Error = bulkItemError(err)
+				}
+				resp.Results = append(resp.Results, result)
+
+				if err != nil && !bestEffort {
+					return errBulkAborted
+				}
+			}
+		}
+		return nil
+	})
+
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, errBulkAborted):
+		status = abortBulkResults(&resp, req.Operations)
+	case err != nil:
+		http.Error(w, "Failed to apply bulk operations", http.StatusInternalServerError)
+		return
+	default:
+		resp.Committed = true
+	}
+
+	for _, result := range resp.Results {
+		switch result.Status {
+		case BulkItemSucceeded:
+			resp.Succeeded++
+		case BulkItemFailed:
+			resp.Failed++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// abortBulkResults marks the items of a rolled back batch and returns the
+// failed item's status code
+func abortBulkResults(resp *BulkTaskResponse, ops []BulkTaskOperation) int {
+	failed := resp.Results[len(resp.Results)-1]
+	for i := range resp.Results[:len(resp.Results)-1] {
+		resp.Results[i].Status = BulkItemRolledBack
+	}
+
+	// Every item after the failed one was skipped
+	tried := len(resp.Results)
+	n := 0
+	for i, op := range ops {
+		for _, taskID := range op.TaskIDs {
+			if n++; n > tried {
+				resp.Results = append(resp.Results, BulkTaskResult{Operation: i, TaskID: taskID, Status: BulkItemSkipped})
+			}
+		}
+	}
+
+	return failed.Code
+}
+
+func applyBulkOperation(tx database.Tx, userID uuid.UUID, op BulkTaskOperation, taskID uuid.UUID) error {
+	switch op.Op {
+	case BulkOpUpdate:
+		updates, err := taskUpdates(*op.Fields,
+			func() (*model.Task, error) { return tx.GetTask(userID, taskID) },
+			tx.PrepareStatusChange)
+		if err != nil {
+			return err
+		}
+		_, err = tx.UpdateTask(userID, taskID, updates)
+		return err
+	case BulkOpComplete:
+		policy, _ := parseSubtaskPolicy(op.Subtasks)
+		_, _, err := tx.CompleteTask(userID, taskID, policy)
+		return err
+	case BulkOpDelete:
+		return tx.DeleteTask(userID, taskID)
+	case BulkOpMove:
+		_, err := tx.MoveTask(userID, taskID, op.ProjectID)
+		return err
+	case BulkOpAddLabel:
+		return tx.AttachLabel(userID, taskID, *op.LabelID)
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// bulkItemError maps the error of a failed item to the status code and
+// message the single-task endpoints use for it
+func bulkItemError(err error) (int, string) {
+	var updateErr *taskUpdateError
+	switch {
+	case errors.As(err, &updateErr):
+		return updateErr.status, updateErr.message
+	case errors.Is(err, database.ErrTaskNotFound):
+		return http.StatusNotFound, "Task not found"
+	case errors.Is(err, database.ErrLabelNotFound):
+		return http.StatusNotFound, "Label not found"
+	case errors.Is(err, database.ErrProjectNotFound):
+		return http.StatusNotFound, "Project not found"
+	case errors.Is(err, database.ErrOpenSubtasks):
+		return http.StatusConflict, "Task has open subtasks; complete them first or use subtasks: cascade"
+	case errors.Is(err, database.ErrProjectArchived):
+		return http.StatusConflict, err.Error()
+	case errors.Is(err, database.ErrSubtaskProject):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, "Failed to apply operation"
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkTasksValidation(t *testing.T) {
+	router := setupTestRouter()
+	taskID := uuid.New().String()
+	tooMany := strings.TrimSuffix(strings.Repeat(`"`+taskID+`",`, maxBulkTaskItems+1), ",")
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"unknown mode", `{"mode":"mostly","operations":[{"op":"delete","task_ids":["` + taskID + `"]}]}`, "mode must be all_or_nothing or best_effort"},
+		{"no operations", `{"operations":[]}`, "operations must list at least one operation"},
+		{"unknown op", `{"operations":[{"op":"archive","task_ids":["` + taskID + `"]}]}`, `operations[0]: unknown op "archive"`},
+		{"no tasks", `{"operations":[{"op":"delete","task_ids":[]}]}`, "operations[0]: task_ids must list at least one task"},
+		{"update without fields", `{"operations":[{"op":"update","task_ids":["` + taskID + `"]}]}`, "operations[0]: fields are required for update"},
+		{"add_label without label", `{"operations":[{"op":"delete","task_ids":["` + taskID + `"]},{"op":"add_label","task_ids":["` + taskID + `"]}]}`, "operations[1]: label_id is required for add_label"},
+		{"bad subtask policy", `{"operations":[{"op":"complete","task_ids":["` + taskID + `"],"subtasks":"ignore"}]}`, "operations[0]: subtasks must be refuse or cascade"},
+		{"too many tasks", `{"operations":[{"op":"delete","task_ids":[` + tooMany + `]}]}`, "bulk requests are limited to 500 task operations, got 501"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/bulk", []byte(tt.body), uuid.New()))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestBulkTasks(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	labelID := uuid.New()
+	found, missing, untried := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	expectTaskLookup := func(mock sqlmock.Sqlmock, taskID uuid.UUID, exists bool) {
+		rows := sqlmock.NewRows(taskColumns)
+		if exists {
+			rows.AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now)
+		}
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(rows)
+	}
+	expectLabelAttached := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns[:6]).AddRow(labelID, userID, "sprint-12", "#0366d6", now, now))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_labels" .* ON CONFLICT DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(now))
+	}
+	body := func(mode string, taskIDs ...uuid.UUID) []byte {
+		b, _ := json.Marshal(BulkTaskRequest{
+			Mode:       mode,
+			Operations: []BulkTaskOperation{{Op: BulkOpAddLabel, TaskIDs: taskIDs, LabelID: &labelID}},
+		})
+		return b
+	}
+
+	t.Run("best effort commits what succeeds", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT sp\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectTaskLookup(mock, found, true)
+		expectLabelAttached(mock)
+		mock.ExpectExec(`SAVEPOINT sp\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectTaskLookup(mock, missing, false)
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp\d+`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/bulk", body(BulkModeBestEffort, found, missing), userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp BulkTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.True(t, resp.Committed)
+		assert.Equal(t, 1, resp.Succeeded)
+		assert.Equal(t, 1, resp.Failed)
+		assert.Equal(t, []BulkTaskResult{
+			{Operation: 0, TaskID: found, Status: BulkItemSucceeded},
+			{Operation: 0, TaskID: missing, Status: BulkItemFailed, Code: http.StatusNotFound, Error: "Task not found"},
+		}, resp.Results)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("all or nothing rolls back on the first failure", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		expectTaskLookup(mock, found, true)
+		expectLabelAttached(mock)
+		expectTaskLookup(mock, missing, false)
+		mock.ExpectRollback()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/bulk", body("", found, missing, untried), userID))
+
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		var resp BulkTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, BulkModeAllOrNothing, resp.Mode)
+		assert.False(t, resp.Committed)
+		assert.Equal(t, 0, resp.Succeeded)
+		assert.Equal(t, 1, resp.Failed)
+		assert.Equal(t, []BulkTaskResult{
+			{Operation: 0, TaskID: found, Status: BulkItemRolledBack},
+			{Operation: 0, TaskID: missing, Status: BulkItemFailed, Code: http.StatusNotFound, Error: "Task not found"},
+			{Operation: 0, TaskID: untried, Status: BulkItemSkipped},
+		}, resp.Results)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("updates are validated per task", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		expectTaskLookup(mock, found, true)
+		mock.ExpectRollback()
+
+		b, _ := json.Marshal(BulkTaskRequest{Operations: []BulkTaskOperation{{
+			Op:      BulkOpUpdate,
+			TaskIDs: []uuid.UUID{found},
+			Fields:  &UpdateTaskRequest{Status: stringPtr("shipped")},
+		}}})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/bulk", b, userID))
+
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"code":400`)
+		assert.Contains(t, rr.Body.String(), "invalid status")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
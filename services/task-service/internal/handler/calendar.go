@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/ical"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// CreateCalendarFeedResponse is the only response that includes the token
+type CreateCalendarFeedResponse struct {
+	model.CalendarFeed
+	Token string `json:"token"`
+	// Path is where calendar apps subscribe to the feed, relative to the API
+	Path string `json:"path"`
+}
+
+// calendarPriorities maps task priorities onto iCalendar's 1 (highest) to 9
+// (lowest) scale
+var calendarPriorities = map[string]string{
+	"high":   "1",
+	"medium": "5",
+	"low":    "9",
+}
+
+func calendarFeedPath(token string) string {
+	return "/feeds/" + token + ".ics"
+}
+
+// writeCalendarFeedError maps repository errors to HTTP responses
+func writeCalendarFeedError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrCalendarFeedNotFound):
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	feed, err := database.GetCalendarFeed(userID)
+	if err != nil {
+		writeCalendarFeedError(w, err, "Failed to fetch calendar feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(feed)
+}
+
+// CreateCalendarFeed issues a new feed token, revoking the caller's previous
+// one if they had a feed already
+func CreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	feed, token, err := database.CreateCalendarFeed(userID)
+	if err != nil {
+		writeCalendarFeedError(w, err, "Failed to create calendar feed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateCalendarFeedResponse{
+		CalendarFeed: *feed,
+		Token:        token,
+		Path:         calendarFeedPath(token),
+	})
+}
+
+// DeleteCalendarFeed revokes the caller's feed token
+func DeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteCalendarFeed(userID); err != nil {
+		writeCalendarFeedError(w, err, "Failed to revoke calendar feed")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeCalendarFeed serves the iCalendar feed of the user the token in the URL
+// belongs to, with a VTODO for each task that has a due date. It is the one
+// task route that takes no JWT: calendar apps cannot send one.
+func ServeCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if !strings.HasPrefix(token, model.CalendarFeedTokenPrefix) {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+
+	feed, err := database.AccessCalendarFeed(token)
+	if err != nil {
+		writeCalendarFeedError(w, err, "Failed to fetch calendar feed")
+		return
+	}
+
+	tasks, err := database.GetCalendarTasks(feed.UserID)
+	if err != nil {
+		http.Error(w, "Failed to fetch tasks", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := writeTaskCalendar(w, tasks); err != nil {
+		log.Printf("Error writing calendar feed: %v", err)
+	}
+}
+
+// writeTaskCalendar writes tasks as a VCALENDAR of VTODOs
+func writeTaskCalendar(w io.Writer, tasks []model.Task) error {
+	cw := ical.NewWriter(w)
+	cw.Begin("VCALENDAR")
+	cw.Raw("VERSION", "2.0")
+	cw.Raw("PRODID", "-//Task Management App//Task Service//EN")
+	cw.Raw("CALSCALE", "GREGORIAN")
+	cw.Raw("METHOD", "PUBLISH")
+	cw.Text("X-WR-CALNAME", "Tasks")
+
+	for _, task := range tasks {
+		cw.Begin("VTODO")
+		cw.Raw("UID", task.ID.String())
+		cw.Time("DTSTAMP", task.UpdatedAt)
+		cw.Time("CREATED", task.CreatedAt)
+		cw.Time("LAST-MODIFIED", task.UpdatedAt)
+		cw.Text("SUMMARY", task.Title)
+		if task.Description != nil && *task.Description != "" {
+			cw.Text("DESCRIPTION", *task.Description)
+		}
+		if task.DueDate != nil {
+			cw.Time("DUE", *task.DueDate)
+		}
+		if task.Priority != nil {
+			if priority, ok := calendarPriorities[*task.Priority]; ok {
+				cw.Raw("PRIORITY", priority)
+			}
+		}
+		// CompletedAt is set exactly when the task is in a done status
+		if task.CompletedAt != nil {
+			cw.Raw("STATUS", "COMPLETED")
+			cw.Time("COMPLETED", *task.CompletedAt)
+		} else {
+			cw.Raw("STATUS", "NEEDS-ACTION")
+		}
+		cw.End("VTODO")
+	}
+
+	cw.End("VCALENDAR")
+	return cw.Flush()
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var calendarFeedColumns = []string{"id", "user_id", "token_hash", "last_accessed_at", "created_at"}
+
+func TestCreateCalendarFeed(t *testing.T) {
+	router := setupTestRouter()
+	mock := setupMockDB(t)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"."calendar_feeds" .* ON CONFLICT \("user_id"\) DO UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/calendar/feed", nil, userID))
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp CreateCalendarFeedResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.True(t, strings.HasPrefix(resp.Token, model.CalendarFeedTokenPrefix))
+	assert.Equal(t, "/feeds/"+resp.Token+".ics", resp.Path)
+	assert.NotContains(t, rr.Body.String(), "token_hash")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCalendarFeed(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+
+	t.Run("revokes the feed", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."calendar_feeds" WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/calendar/feed", nil, userID))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no feed", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."calendar_feeds"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/calendar/feed", nil, userID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Calendar feed not found")
+	})
+}
+
+func TestServeCalendarFeed(t *testing.T) {
+	router := setupTestRouter()
+	token := model.NewCalendarFeedToken()
+
+	t.Run("serves a VTODO per task with a due date", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		openID := uuid.New()
+		doneID := uuid.New()
+		created := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+		due := time.Date(2026, 4, 10, 17, 0, 0, 0, time.UTC)
+		completed := time.Date(2026, 4, 9, 12, 30, 0, 0, time.UTC)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."calendar_feeds" WHERE token_hash = \$1`).
+			WithArgs(model.HashCalendarFeedToken(token), 1).
+			WillReturnRows(sqlmock.NewRows(calendarFeedColumns).
+				AddRow(uuid.New(), userID, model.HashCalendarFeedToken(token), nil, created))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."calendar_feeds" SET "last_accessed_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND due_date IS NOT NULL\)`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows(taskColumns).
+				AddRow(openID, userID, "Renew passport", "Bring photos, forms", "todo", "high", due, nil, created, created).
+				AddRow(doneID, userID, "File taxes", nil, "done", nil, due, completed, created, completed))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/feeds/"+token+".ics", nil))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+
+		body := rr.Body.String()
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+		assert.Equal(t, 2, strings.Count(body, "BEGIN:VTODO\r\n"))
+		assert.Contains(t, body, "BEGIN:VTODO\r\n"+
+			"UID:"+openID.String()+"\r\n"+
+			"DTSTAMP:20260401T080000Z\r\n"+
+			"CREATED:20260401T080000Z\r\n"+
+			"LAST-MODIFIED:20260401T080000Z\r\n"+
+			"SUMMARY:Renew passport\r\n"+
+			"DESCRIPTION:Bring photos\\, forms\r\n"+
+			"DUE:20260410T170000Z\r\n"+
+			"PRIORITY:1\r\n"+
+			"STATUS:NEEDS-ACTION\r\n"+
+			"END:VTODO\r\n")
+		assert.Contains(t, body, "STATUS:COMPLETED\r\nCOMPLETED:20260409T123000Z\r\n")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revoked token", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."calendar_feeds"`).WillReturnRows(sqlmock.NewRows(calendarFeedColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/feeds/"+token+".ics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("a JWT is not a feed token", func(t *testing.T) {
+		mock := setupMockDB(t)
+		jwt := strings.TrimPrefix(bearerToken(t, uuid.New()), "Bearer ")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/feeds/"+jwt+".ics", nil))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+type CreateChecklistItemRequest struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type UpdateChecklistItemRequest struct {
+	Text     *string `json:"text,omitempty"`
+	Done     *bool   `json:"done,omitempty"`
+	Position *int    `json:"position,omitempty"`
+}
+
+// writeChecklistError maps repository errors to HTTP responses
+func writeChecklistError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrChecklistItemNotFound):
+		http.Error(w, "Checklist item not found", http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func ListChecklistItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	items, err := database.GetChecklist(userID, taskID)
+	if err != nil {
+		writeChecklistError(w, err, "Failed to fetch checklist")
+		return
+	}
+
+	if items == nil {
+		items = []model.ChecklistItem{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(items)
+}
+
+func CreateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	var req CreateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	item := model.ChecklistItem{
+		TaskID: taskID,
+		Text:   strings.TrimSpace(req.Text),
+		Done:   req.Done,
+	}
+
+	if err := item.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateChecklistItem(userID, &item); err != nil {
+		writeChecklistError(w, err, "Failed to create checklist item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+func UpdateChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	itemID, ok := parseURLUUID(w, r, "itemID", "checklist item")
+	if !ok {
+		return
+	}
+
+	var req UpdateChecklistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	// Build updates map with only provided fields
+	updates := map[string]interface{}{}
+	if req.Text != nil {
+		text := strings.TrimSpace(*req.Text)
+		if err := (model.ChecklistItem{Text: text}).Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		updates["text"] = text
+	}
+	if req.Done != nil {
+		updates["done"] = *req.Done
+	}
+	if req.Position != nil && *req.Position < 0 {
+		http.Error(w, "position cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	item, err := database.UpdateChecklistItem(userID, taskID, itemID, updates, req.Position)
+	if err != nil {
+		writeChecklistError(w, err, "Failed to update checklist item")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(item)
+}
+
+func DeleteChecklistItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	itemID, ok := parseURLUUID(w, r, "itemID", "checklist item")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteChecklistItem(userID, taskID, itemID); err != nil {
+		writeChecklistError(w, err, "Failed to delete checklist item")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+func TestCreateChecklistItem(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	t.Run("successful creation", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."checklist_items"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`INSERT INTO "tasks"."checklist_items"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/checklist", []byte(`{"text":" Buy milk "}`), userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var item model.ChecklistItem
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &item))
+		assert.Equal(t, "Buy milk", item.Text)
+		assert.Equal(t, 1, item.Position)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty text", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/checklist", []byte(`{"text":"  "}`), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/checklist", []byte(`{"text":"x"}`), uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Task not found")
+	})
+}
+
+func TestUpdateChecklistItem(t *testing.T) {
+	router := setupTestRouter()
+	path := "/tasks/" + uuid.New().String() + "/checklist/" + uuid.New().String()
+
+	t.Run("negative position", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", path, []byte(`{"position":-1}`), uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid item ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+uuid.New().String()+"/checklist/nope", []byte(`{}`), uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid checklist item ID")
+	})
+}
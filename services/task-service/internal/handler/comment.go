@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// CommentRequest is the body of both creating and editing a comment
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// writeCommentError maps repository errors to HTTP responses
+func writeCommentError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrCommentNotFound):
+		http.Error(w, "Comment not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrNotCommentAuthor):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func ListComments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	comments, err := database.GetComments(userID, taskID)
+	if err != nil {
+		writeCommentError(w, err, "Failed to fetch comments")
+		return
+	}
+
+	if comments == nil {
+		comments = []model.Comment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(comments)
+}
+
+func CreateComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	comment := model.Comment{TaskID: taskID, Body: req.Body}
+	if err := comment.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateComment(userID, &comment); err != nil {
+		writeCommentError(w, err, "Failed to create comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	commentID, ok := parseURLUUID(w, r, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := (model.Comment{Body: req.Body}).Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	comment, err := database.UpdateComment(userID, taskID, commentID, req.Body)
+	if err != nil {
+		writeCommentError(w, err, "Failed to update comment")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	commentID, ok := parseURLUUID(w, r, "commentID", "comment")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteComment(userID, taskID, commentID); err != nil {
+		writeCommentError(w, err, "Failed to delete comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
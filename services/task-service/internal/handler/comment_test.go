@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var commentColumns = []string{"id", "task_id", "user_id", "body", "edited_at", "created_at", "updated_at", "deleted_at"}
+
+func TestCreateComment(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	path := "/tasks/" + taskID.String() + "/comments"
+	now := time.Now()
+
+	t.Run("caller becomes the author", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."comments"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", path, []byte(`{"body":"Looks good"}`), userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var comment model.Comment
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &comment))
+		assert.Equal(t, userID, comment.UserID)
+		assert.Equal(t, taskID, comment.TaskID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("blank body", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", path, []byte(`{"body":"   "}`), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "body field is required")
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	commentID := uuid.New()
+	now := time.Now()
+
+	t.Run("not the author", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."comments"`).
+			WillReturnRows(sqlmock.NewRows(commentColumns).AddRow(commentID, taskID, uuid.New(), "Theirs", nil, now, now, nil))
+
+		path := "/tasks/" + taskID.String() + "/comments/" + commentID.String()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", path, []byte(`{"body":"edited"}`), userID))
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("comment not found", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."comments"`).
+			WillReturnRows(sqlmock.NewRows(commentColumns))
+
+		path := "/tasks/" + taskID.String() + "/comments/" + commentID.String()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", path, []byte(`{"body":"edited"}`), userID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Comment not found")
+	})
+}
+
+func TestGetTaskCommentCount(t *testing.T) {
+	router := setupTestRouter()
+	mock := setupMockDB(t)
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT labels\.\*, task_labels\.task_id FROM "tasks"."labels"`).
+		WillReturnRows(sqlmock.NewRows(labelColumns))
+	mock.ExpectQuery(`SELECT t\.id AS task_id`).
+		WillReturnRows(sqlmock.NewRows(progressColumns))
+	mock.ExpectQuery(`SELECT task_id, COUNT\(\*\) AS count FROM "tasks"."comments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "count"}).AddRow(taskID, 4))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/"+taskID.String(), nil, userID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var resp GetTaskResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, 4, resp.CommentCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// ListTaskHistory returns every recorded change to a task, oldest first
+func ListTaskHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	events, err := database.GetTaskHistory(userID, taskID)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch task history", http.StatusInternalServerError)
+		return
+	}
+
+	if events == nil {
+		events = []model.TaskEvent{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(events)
+}
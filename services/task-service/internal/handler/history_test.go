@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var taskEventColumns = []string{"id", "task_id", "user_id", "action", "field", "old_value", "new_value", "created_at"}
+
+func TestListTaskHistory(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+		WithArgs(taskID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."task_events" WHERE task_id = \$1`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows(taskEventColumns).
+			AddRow(uuid.New(), taskID, userID, "created", nil, nil, nil, now).
+			AddRow(uuid.New(), taskID, userID, "updated", "status", "done", "todo", now))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/"+taskID.String()+"/history", nil, userID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var events []model.TaskEvent
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &events))
+	require.Len(t, events, 2)
+	assert.Equal(t, model.TaskEventCreated, events[0].Action)
+	assert.Nil(t, events[0].Field)
+	assert.Equal(t, "status", *events[1].Field)
+	assert.Equal(t, "done", *events[1].OldValue)
+	assert.Equal(t, "todo", *events[1].NewValue)
+	assert.Equal(t, userID, events[1].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,670 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// Import and export formats
+const (
+	TaskFormatCSV  = "csv"
+	TaskFormatJSON = "json"
+)
+
+// Import limits
+const (
+	maxImportTasks = 5000
+	maxImportBytes = 10 << 20
+)
+
+// taskRecordColumns are the CSV columns of an export, in order. Imports may
+// give them in any order and leave out all but title; created_at and
+// updated_at are ignored.
+var taskRecordColumns = []string{
+	"id", "project_id", "parent_id", "title", "description", "status", "priority",
+	"due_date", "completed_at", "recurrence", "recurrence_timezone", "created_at", "updated_at",
+}
+
+// TaskRecord is a task as it is exported and imported. On import the id only
+// links subtasks to a parent in the same file; imported tasks get new IDs.
+type TaskRecord struct {
+	ID                 *uuid.UUID `json:"id,omitempty"`
+	ProjectID          *uuid.UUID `json:"project_id,omitempty"`
+	ParentID           *uuid.UUID `json:"parent_id,omitempty"`
+	Title              string     `json:"title"`
+	Description        *string    `json:"description,omitempty"`
+	Status             string     `json:"status,omitempty"`
+	Priority           *string    `json:"priority,omitempty"`
+	DueDate            *time.Time `json:"due_date,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	Recurrence         *string    `json:"recurrence,omitempty"`
+	RecurrenceTimezone *string    `json:"recurrence_timezone,omitempty"`
+	CreatedAt          *time.Time `json:"created_at,omitempty"`
+	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+}
+
+func newTaskRecord(task *model.Task) TaskRecord {
+	id, createdAt, updatedAt := task.ID, task.CreatedAt, task.UpdatedAt
+	return TaskRecord{
+		ID:                 &id,
+		ProjectID:          task.ProjectID,
+		ParentID:           task.ParentID,
+		Title:              task.Title,
+		Description:        task.Description,
+		Status:             task.Status,
+		Priority:           task.Priority,
+		DueDate:            task.DueDate,
+		CompletedAt:        task.CompletedAt,
+		Recurrence:         task.Recurrence,
+		RecurrenceTimezone: task.RecurrenceTimezone,
+		CreatedAt:          &createdAt,
+		UpdatedAt:          &updatedAt,
+	}
+}
+
+// csvFields returns the record as a CSV row in taskRecordColumns order
+func (rec TaskRecord) csvFields() []string {
+	return []string{
+		formatCSVUUID(rec.ID),
+		formatCSVUUID(rec.ProjectID),
+		formatCSVUUID(rec.ParentID),
+		rec.Title,
+		formatCSVString(rec.Description),
+		rec.Status,
+		formatCSVString(rec.Priority),
+		formatCSVTime(rec.DueDate),
+		formatCSVTime(rec.CompletedAt),
+		formatCSVString(rec.Recurrence),
+		formatCSVString(rec.RecurrenceTimezone),
+		formatCSVTime(rec.CreatedAt),
+		formatCSVTime(rec.UpdatedAt),
+	}
+}
+
+// setCSVField sets the field of one of taskRecordColumns from a CSV cell.
+// Empty cells leave optional fields unset.
+func (rec *TaskRecord) setCSVField(column, value string) error {
+	var err error
+	switch column {
+	case "id":
+		rec.ID, err = parseCSVUUID(value)
+	case "project_id":
+		rec.ProjectID, err = parseCSVUUID(value)
+	case "parent_id":
+		rec.ParentID, err = parseCSVUUID(value)
+	case "title":
+		rec.Title = value
+	case "description":
+		rec.Description = parseCSVString(value)
+	case "status":
+		rec.Status = value
+	case "priority":
+		rec.Priority = parseCSVString(value)
+	case "due_date":
+		rec.DueDate, err = parseCSVTime(value)
+	case "completed_at":
+		rec.CompletedAt, err = parseCSVTime(value)
+	case "recurrence":
+		rec.Recurrence = parseCSVString(value)
+	case "recurrence_timezone":
+		rec.RecurrenceTimezone = parseCSVString(value)
+	}
+	if err != nil {
+		return fmt.Errorf("%s %w", column, err)
+	}
+	return nil
+}
+
+func formatCSVUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatCSVString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseCSVUUID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, errors.New("must be a UUID")
+	}
+	return &id, nil
+}
+
+func parseCSVString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func parseCSVTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("must be an RFC 3339 timestamp")
+	}
+	return &t, nil
+}
+
+// taskFormat returns the format query parameter, falling back to CSV for
+// text/csv bodies and JSON otherwise
+func taskFormat(r *http.Request) (string, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "text/csv" {
+			return TaskFormatCSV, nil
+		}
+		return TaskFormatJSON, nil
+	}
+	if format != TaskFormatCSV && format != TaskFormatJSON {
+		return "", fmt.Errorf("format must be %s or %s", TaskFormatCSV, TaskFormatJSON)
+	}
+	return format, nil
+}
+
+// taskRecordWriter writes an export one task at a time
+type taskRecordWriter interface {
+	Write(TaskRecord) error
+	// Close finishes the document and flushes it
+	Close() error
+}
+
+type csvTaskWriter struct {
+	w *csv.Writer
+}
+
+func newCSVTaskWriter(w io.Writer) *csvTaskWriter {
+	cw := csv.NewWriter(w)
+	cw.Write(taskRecordColumns)
+	return &csvTaskWriter{w: cw}
+}
+
+func (tw *csvTaskWriter) Write(rec TaskRecord) error {
+	return tw.w.Write(rec.csvFields())
+}
+
+func (tw *csvTaskWriter) Close() error {
+	tw.w.Flush()
+	return tw.w.Error()
+}
+
+// jsonTaskWriter writes a JSON array of records
+type jsonTaskWriter struct {
+	w       *bufio.Writer
+	written int
+}
+
+func newJSONTaskWriter(w io.Writer) *jsonTaskWriter {
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('[')
+	return &jsonTaskWriter{w: bw}
+}
+
+func (tw *jsonTaskWriter) Write(rec TaskRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if tw.written > 0 {
+		tw.w.WriteByte(',')
+	}
+	tw.written++
+	_, err = tw.w.Write(data)
+	return err
+}
+
+func (tw *jsonTaskWriter) Close() error {
+	tw.w.WriteString("]\n")
+	return tw.w.Flush()
+}
+
+// exportWriter notes whether any of the export has reached the client, after
+// which the response status can no longer change
+type exportWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(p)
+}
+
+// ExportTasks streams all of the caller's tasks outside the trash as CSV or
+// a JSON array
+func ExportTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := taskFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out := &exportWriter{ResponseWriter: w}
+	var records taskRecordWriter
+	if format == TaskFormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		records = newCSVTaskWriter(out)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		records = newJSONTaskWriter(out)
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+
+	err = database.ExportTasks(userID, func(task *model.Task) error {
+		return records.Write(newTaskRecord(task))
+	})
+	if err == nil {
+		err = records.Close()
+	}
+	if err != nil {
+		if !out.started {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Failed to export tasks", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Export of tasks for user %s stopped early: %v", userID, err)
+	}
+}
+
+// importRow is one task of an import file, or why it could not be read
+type importRow struct {
+	record TaskRecord
+	err    error
+}
+
+var errEmptyImport = errors.New("the import has no tasks")
+
+// readCSVImport reads an import with a header row naming its columns
+func readCSVImport(body io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(body)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errEmptyImport
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if !slices.Contains(taskRecordColumns, name) {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if slices.Contains(columns[:i], name) {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[i] = name
+	}
+	if !slices.Contains(columns, "title") {
+		return nil, errors.New("the title column is required")
+	}
+
+	var rows []importRow
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxImportTasks {
+			return nil, fmt.Errorf("an import can hold at most %d tasks", maxImportTasks)
+		}
+
+		var row importRow
+		for i, value := range fields {
+			if err := row.record.setCSVField(columns[i], value); err != nil {
+				row.err = err
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// readJSONImport reads an import holding an array of records. Records that
+// do not match TaskRecord fail on their own row.
+func readJSONImport(body io.Reader) ([]importRow, error) {
+	dec := json.NewDecoder(body)
+
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, errors.New("a JSON import must be an array of tasks")
+	}
+
+	var rows []importRow
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if len(rows) == maxImportTasks {
+			return nil, fmt.Errorf("an import can hold at most %d tasks", maxImportTasks)
+		}
+
+		var row importRow
+		recordDec := json.NewDecoder(bytes.NewReader(raw))
+		recordDec.DisallowUnknownFields()
+		row.err = recordDec.Decode(&row.record)
+		rows = append(rows, row)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// taskImporter turns import records into tasks for one user, caching the
+// lookups that rows share
+type taskImporter struct {
+	userID uuid.UUID
+	// inFile holds the records by their id in the file, and newIDs the ID
+	// each of them is imported with
+	inFile     map[uuid.UUID]TaskRecord
+	newIDs     map[uuid.UUID]uuid.UUID
+	duplicates map[uuid.UUID]bool
+
+	parents   map[uuid.UUID]*model.Task
+	projects  map[uuid.UUID]error
+	workflows map[uuid.UUID]*model.Workflow
+}
+
+func newTaskImporter(userID uuid.UUID, rows []importRow) *taskImporter {
+	imp := &taskImporter{
+		userID:     userID,
+		inFile:     make(map[uuid.UUID]TaskRecord),
+		newIDs:     make(map[uuid.UUID]uuid.UUID),
+		duplicates: make(map[uuid.UUID]bool),
+		parents:    make(map[uuid.UUID]*model.Task),
+		projects:   make(map[uuid.UUID]error),
+		workflows:  make(map[uuid.UUID]*model.Workflow),
+	}
+	for _, row := range rows {
+		if row.err != nil || row.record.ID == nil {
+			continue
+		}
+		id := *row.record.ID
+		if _, seen := imp.inFile[id]; seen {
+			imp.duplicates[id] = true
+			continue
+		}
+		imp.inFile[id] = row.record
+		imp.newIDs[id] = uuid.New()
+	}
+	return imp
+}
+
+// task builds the task to import for rec. invalid explains why rec cannot be
+// imported; err is a failure to check it.
+func (imp *taskImporter) task(rec TaskRecord) (task model.Task, invalid error, err error) {
+	if rec.ID != nil && imp.duplicates[*rec.ID] {
+		return task, fmt.Errorf("id %s appears more than once", rec.ID), nil
+	}
+
+	// Subtasks hang off a top-level task, either earlier in the file or
+	// already owned by the caller, and share its project
+	projectID := rec.ProjectID
+	var parentID *uuid.UUID
+	if rec.ParentID != nil {
+		var parentProjectID *uuid.UUID
+		if parent, ok := imp.inFile[*rec.ParentID]; ok {
+			if parent.ParentID != nil {
+				return task, database.ErrSubtaskDepth, nil
+			}
+			id := imp.newIDs[*rec.ParentID]
+			parentID, parentProjectID = &id, parent.ProjectID
+		} else {
+			parent, err := imp.parent(*rec.ParentID)
+			if errors.Is(err, database.ErrParentNotFound) || errors.Is(err, database.ErrSubtaskDepth) {
+				return task, err, nil
+			}
+			if err != nil {
+				return task, nil, err
+			}
+			parentID, parentProjectID = &parent.ID, parent.ProjectID
+		}
+		if projectID != nil && (parentProjectID == nil || *projectID != *parentProjectID) {
+			return task, database.ErrSubtaskProject, nil
+		}
+		projectID = parentProjectID
+	} else if projectID != nil {
+		err := imp.validateProject(*projectID)
+		if errors.Is(err, database.ErrProjectNotFound) || errors.Is(err, database.ErrProjectArchived) {
+			return task, err, nil
+		}
+		if err != nil {
+			return task, nil, err
+		}
+	}
+
+	wf, err := imp.workflow(projectID)
+	if errors.Is(err, database.ErrProjectNotFound) {
+		return task, err, nil
+	}
+	if err != nil {
+		return task, nil, err
+	}
+
+	task = model.Task{
+		ID:          uuid.New(),
+		UserID:      imp.userID,
+		ProjectID:   projectID,
+		ParentID:    parentID,
+		Title:       rec.Title,
+		Description: rec.Description,
+		Status:      rec.Status,
+		Priority:    rec.Priority,
+		DueDate:     rec.DueDate,
+		CompletedAt: rec.CompletedAt,
+	}
+	if rec.ID != nil {
+		task.ID = imp.newIDs[*rec.ID]
+	}
+	if task.Status == "" {
+		initial, _ := wf.FirstInCategory(model.StatusCategoryNotStarted)
+		task.Status = initial.Key
+	}
+	if rec.Recurrence != nil && *rec.Recurrence != "" {
+		task.Recurrence = rec.Recurrence
+		task.RecurrenceTimezone = rec.RecurrenceTimezone
+		task.RecurrenceStart = rec.DueDate
+		task.Occurrence = 1
+	}
+
+	if err := task.ValidateInWorkflow(*wf); err != nil {
+		return task, err, nil
+	}
+
+	return task, nil, nil
+}
+
+func (imp *taskImporter) parent(parentID uuid.UUID) (*model.Task, error) {
+	if parent, ok := imp.parents[parentID]; ok {
+		return parent, nil
+	}
+	parent, err := database.ValidateParent(imp.userID, parentID)
+	if err != nil {
+		return nil, err
+	}
+	imp.parents[parentID] = parent
+	return parent, nil
+}
+
+func (imp *taskImporter) validateProject(projectID uuid.UUID) error {
+	if err, ok := imp.projects[projectID]; ok {
+		return err
+	}
+	err := database.ValidateProject(imp.userID, projectID)
+	if err == nil || errors.Is(err, database.ErrProjectNotFound) || errors.Is(err, database.ErrProjectArchived) {
+		imp.projects[projectID] = err
+	}
+	return err
+}
+
+func (imp *taskImporter) workflow(projectID *uuid.UUID) (*model.Workflow, error) {
+	if projectID == nil {
+		return database.GetWorkflow(imp.userID, nil)
+	}
+	if wf, ok := imp.workflows[*projectID]; ok {
+		return wf, nil
+	}
+	wf, err := database.GetWorkflow(imp.userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	imp.workflows[*projectID] = wf
+	return wf, nil
+}
+
+// ImportTaskError is a row that cannot be imported. Rows count from 1 and do
+// not include the CSV header.
+type ImportTaskError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportTasksResponse struct {
+	DryRun   bool              `json:"dry_run"`
+	Rows     int               `json:"rows"`
+	Imported int               `json:"imported"`
+	Errors   []ImportTaskError `json:"errors"`
+}
+
+// ImportTasks creates tasks from a CSV or JSON file in the export format.
+// Every row is validated first and nothing is imported unless all of them
+// pass; with dry_run=true the rows are only validated.
+func ImportTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	format, err := taskFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			http.Error(w, "dry_run must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []importRow
+	if format == TaskFormatCSV {
+		rows, err = readCSVImport(body)
+	} else {
+		rows, err = readJSONImport(body)
+	}
+	if err == nil && len(rows) == 0 {
+		err = errEmptyImport
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Imports are limited to %d bytes", maxImportBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid import: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imp := newTaskImporter(userID, rows)
+	resp := ImportTasksResponse{DryRun: dryRun, Rows: len(rows), Errors: []ImportTaskError{}}
+	// Parents in the file are created before their subtasks
+	var topLevel, subtasks []model.Task
+	for i, row := range rows {
+		var task model.Task
+		invalid := row.err
+		if invalid == nil {
+			task, invalid, err = imp.task(row.record)
+			if err != nil {
+				http.Error(w, "Failed to validate import", http.StatusInternalServerError)
+				return
+			}
+		}
+		switch {
+		case invalid != nil:
+			resp.Errors = append(resp.Errors, ImportTaskError{Row: i + 1, Error: invalid.Error()})
+		case task.ParentID == nil:
+			topLevel = append(topLevel, task)
+		default:
+			subtasks = append(subtasks, task)
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case len(resp.Errors) > 0:
+		status = http.StatusUnprocessableEntity
+	case !dryRun:
+		tasks := slices.Concat(topLevel, subtasks)
+		if err := database.ImportTasks(tasks); err != nil {
+			http.Error(w, "Failed to import tasks", http.StatusInternalServerError)
+			return
+		}
+		resp.Imported = len(tasks)
+		status = http.StatusCreated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Error encoding response: %v", err)
+	}
+}
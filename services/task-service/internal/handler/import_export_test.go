@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+)
+
+func TestExportTasks(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	created := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	due := time.Date(2026, 3, 8, 17, 0, 0, 0, time.UTC)
+
+	exportRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(taskColumns).
+			AddRow(taskID, userID, "Write report", "Quarterly, with \"charts\"", "todo", "high", due, nil, created, created)
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND "tasks"."deleted_at" IS NULL ORDER BY created_at, id`).
+			WithArgs(userID).
+			WillReturnRows(exportRows())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/export?format=csv", nil, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="tasks.csv"`, rr.Header().Get("Content-Disposition"))
+
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, taskRecordColumns, records[0])
+		assert.Equal(t, []string{
+			taskID.String(), "", "", "Write report", "Quarterly, with \"charts\"", "todo", "high",
+			"2026-03-08T17:00:00Z", "", "", "", "2026-03-01T09:30:00Z", "2026-03-01T09:30:00Z",
+		}, records[1])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).WillReturnRows(exportRows())
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/export?format=json", nil, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `attachment; filename="tasks.json"`, rr.Header().Get("Content-Disposition"))
+
+		var records []TaskRecord
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &records))
+		require.Len(t, records, 1)
+		assert.Equal(t, taskID, *records[0].ID)
+		assert.Equal(t, "Write report", records[0].Title)
+		assert.Equal(t, "high", *records[0].Priority)
+		assert.True(t, due.Equal(*records[0].DueDate))
+		assert.Nil(t, records[0].CompletedAt)
+	})
+
+	t.Run("no tasks", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/export", nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "[]\n", rr.Body.String())
+	})
+
+	t.Run("database failure before anything is sent", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).WillReturnError(fmt.Errorf("connection reset"))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/export?format=csv", nil, userID))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Empty(t, rr.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/export?format=xml", nil, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "format must be csv or json")
+	})
+}
+
+func TestImportTasks(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	due := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+
+	decodeImport := func(t *testing.T, rr *httptest.ResponseRecorder) ImportTasksResponse {
+		t.Helper()
+		var resp ImportTasksResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("dry run reports row errors", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		body := "title,priority,due_date,status\n" +
+			"Valid task,high," + due + ",\n" +
+			",low,,\n" +
+			"Urgent,critical,,\n" +
+			"Someday,,next week,\n" +
+			"Past,,2020-01-01T00:00:00Z,\n"
+
+		req := newAuthedRequest(t, "POST", "/tasks/import?format=csv&dry_run=true", []byte(body), userID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		resp := decodeImport(t, rr)
+		assert.True(t, resp.DryRun)
+		assert.Equal(t, 5, resp.Rows)
+		assert.Equal(t, 0, resp.Imported)
+		assert.Equal(t, []ImportTaskError{
+			{Row: 2, Error: "title field is required"},
+			{Row: 3, Error: "priority must be one of: low, medium, high"},
+			{Row: 4, Error: "due_date must be an RFC 3339 timestamp"},
+			{Row: 5, Error: "due date cannot be in the past"},
+		}, resp.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("dry run of a valid file writes nothing", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		body := `[{"title":"One"},{"title":"Two","status":"done","completed_at":"2026-01-05T10:00:00Z"}]`
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/import?dry_run=true", []byte(body), userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		resp := decodeImport(t, rr)
+		assert.Equal(t, 2, resp.Rows)
+		assert.Empty(t, resp.Errors)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("imports subtasks after their parents", func(t *testing.T) {
+		mock := setupMockDB(t)
+		fileParentID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()).AddRow(uuid.New()))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()).AddRow(uuid.New(), time.Now()))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		body := fmt.Sprintf(`[{"parent_id":"%s","title":"Subtask"},{"id":"%s","title":"Parent"}]`, fileParentID, fileParentID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/import?format=json", []byte(body), userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+		resp := decodeImport(t, rr)
+		assert.Equal(t, 2, resp.Imported)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		mock := setupMockDB(t)
+		parentID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\)`).
+			WithArgs(parentID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		body := fmt.Sprintf(`[{"parent_id":"%s","title":"Orphan"},{"title":"Fine","priority":7}]`, parentID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/import", []byte(body), userID))
+
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		resp := decodeImport(t, rr)
+		require.Len(t, resp.Errors, 2)
+		assert.Equal(t, ImportTaskError{Row: 1, Error: "parent task not found"}, resp.Errors[0])
+		assert.Equal(t, 2, resp.Errors[1].Row)
+		assert.Contains(t, resp.Errors[1].Error, "priority")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("format from the content type", func(t *testing.T) {
+		setupMockDB(t)
+
+		req := newAuthedRequest(t, "POST", "/tasks/import?dry_run=1", []byte("title\nFrom CSV\n"), userID)
+		req.Header.Set("Content-Type", "text/csv")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 1, decodeImport(t, rr).Rows)
+	})
+
+	t.Run("unreadable files", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			target string
+			body   string
+			want   string
+		}{
+			{"unknown column", "/tasks/import?format=csv", "title,owner\nA,bob\n", `unknown column "owner"`},
+			{"no title column", "/tasks/import?format=csv", "status\ntodo\n", "the title column is required"},
+			{"empty csv", "/tasks/import?format=csv", "", "the import has no tasks"},
+			{"ragged csv", "/tasks/import?format=csv", "title,status\nA\n", "wrong number of fields"},
+			{"not an array", "/tasks/import", `{"title":"A"}`, "a JSON import must be an array of tasks"},
+			{"empty array", "/tasks/import", `[]`, "the import has no tasks"},
+			{"bad dry_run", "/tasks/import?dry_run=maybe", `[{"title":"A"}]`, "dry_run must be true or false"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, newAuthedRequest(t, "POST", tt.target, []byte(tt.body), userID))
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				assert.Contains(t, rr.Body.String(), tt.want)
+			})
+		}
+	})
+
+	t.Run("too many tasks", func(t *testing.T) {
+		body := "title\n" + strings.Repeat("Task\n", maxImportTasks+1)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/import?format=csv", []byte(body), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "an import can hold at most 5000 tasks")
+	})
+}
+
+func TestTaskImporter(t *testing.T) {
+	userID := uuid.New()
+	fileParentID := uuid.New()
+
+	t.Run("links subtasks to parents in the file", func(t *testing.T) {
+		rows := []importRow{
+			{record: TaskRecord{ParentID: &fileParentID, Title: "Subtask"}},
+			{record: TaskRecord{ID: &fileParentID, Title: "Parent"}},
+		}
+		imp := newTaskImporter(userID, rows)
+
+		subtask, invalid, err := imp.task(rows[0].record)
+		require.NoError(t, err)
+		require.NoError(t, invalid)
+		parent, invalid, err := imp.task(rows[1].record)
+		require.NoError(t, err)
+		require.NoError(t, invalid)
+
+		assert.NotEqual(t, fileParentID, parent.ID, "imported tasks get new IDs")
+		assert.Equal(t, parent.ID, *subtask.ParentID)
+		assert.Equal(t, "todo", subtask.Status)
+		assert.Equal(t, userID, subtask.UserID)
+	})
+
+	t.Run("rejects every row sharing an id", func(t *testing.T) {
+		rows := []importRow{
+			{record: TaskRecord{ID: &fileParentID, Title: "Parent"}},
+			{record: TaskRecord{ID: &fileParentID, Title: "Copy"}},
+		}
+		imp := newTaskImporter(userID, rows)
+
+		for _, row := range rows {
+			_, invalid, err := imp.task(row.record)
+			require.NoError(t, err)
+			assert.EqualError(t, invalid, fmt.Sprintf("id %s appears more than once", fileParentID))
+		}
+	})
+
+	t.Run("rejects subtasks of subtasks", func(t *testing.T) {
+		childID := uuid.New()
+		rows := []importRow{
+			{record: TaskRecord{ID: &fileParentID, Title: "Parent"}},
+			{record: TaskRecord{ID: &childID, ParentID: &fileParentID, Title: "Child"}},
+			{record: TaskRecord{ParentID: &childID, Title: "Grandchild"}},
+		}
+		imp := newTaskImporter(userID, rows)
+
+		_, invalid, err := imp.task(rows[2].record)
+		require.NoError(t, err)
+		assert.ErrorIs(t, invalid, database.ErrSubtaskDepth)
+	})
+}
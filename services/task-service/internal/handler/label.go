@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+type CreateLabelRequest struct {
+	Name  string  `json:"name"`
+	Color *string `json:"color"`
+}
+
+type UpdateLabelRequest struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// taskResponsePointers returns pointers into resps so they can be filled in place
+func taskResponsePointers(resps []GetTaskResponse) []*GetTaskResponse {
+	ptrs := make([]*GetTaskResponse, len(resps))
+	for i := range resps {
+		ptrs[i] = &resps[i]
+	}
+	return ptrs
+}
+
+// attachTaskLabels loads the labels for every task in resps with a single query
+func attachTaskLabels(userID uuid.UUID, resps []*GetTaskResponse) error {
+	if len(resps) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]uuid.UUID, len(resps))
+	for i, resp := range resps {
+		taskIDs[i] = resp.ID
+	}
+
+	labels, err := database.GetLabelsForTasks(userID, taskIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, resp := range resps {
+		if l, ok := labels[resp.ID]; ok {
+			resp.Labels = l
+		}
+	}
+
+	return nil
+}
+
+// writeLabelError maps repository errors to HTTP responses
+func writeLabelError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrLabelNotFound):
+		http.Error(w, "Label not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrLabelNameTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func parseURLUUID(w http.ResponseWriter, r *http.Request, param, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		http.Error(w, "Invalid "+name+" ID", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func ListLabels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	labels, err := database.GetLabelsByUserID(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch labels", http.StatusInternalServerError)
+		return
+	}
+
+	if labels == nil {
+		labels = []model.Label{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(labels)
+}
+
+func CreateLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	label := model.Label{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Color:  model.DefaultLabelColor,
+	}
+	if req.Color != nil {
+		label.Color = *req.Color
+	}
+
+	if err := label.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateLabel(&label); err != nil {
+		writeLabelError(w, err, "Failed to create label")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(label)
+}
+
+func GetLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	labelID, ok := parseURLUUID(w, r, "labelID", "label")
+	if !ok {
+		return
+	}
+
+	label, err := database.GetLabel(userID, labelID)
+	if err != nil {
+		writeLabelError(w, err, "Failed to fetch label")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(label)
+}
+
+func UpdateLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	labelID, ok := parseURLUUID(w, r, "labelID", "label")
+	if !ok {
+		return
+	}
+
+	var req UpdateLabelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetLabel(userID, labelID)
+	if err != nil {
+		writeLabelError(w, err, "Failed to fetch label")
+		return
+	}
+
+	// Validate the label as it will look after the update
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		current.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = current.Name
+	}
+	if req.Color != nil {
+		current.Color = *req.Color
+		updates["color"] = current.Color
+	}
+
+	if err := current.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	label := current
+	if len(updates) > 0 {
+		label, err = database.UpdateLabel(userID, labelID, updates)
+		if err != nil {
+			writeLabelError(w, err, "Failed to update label")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(label)
+}
+
+func DeleteLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	labelID, ok := parseURLUUID(w, r, "labelID", "label")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteLabel(userID, labelID); err != nil {
+		writeLabelError(w, err, "Failed to delete label")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func AttachTaskLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	labelID, ok := parseURLUUID(w, r, "labelID", "label")
+	if !ok {
+		return
+	}
+
+	if err := database.AttachLabel(userID, taskID, labelID); err != nil {
+		writeLabelError(w, err, "Failed to attach label")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func DetachTaskLabel(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	labelID, ok := parseURLUUID(w, r, "labelID", "label")
+	if !ok {
+		return
+	}
+
+	if err := database.DetachLabel(userID, taskID, labelID); err != nil {
+		writeLabelError(w, err, "Failed to detach label")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+func TestCreateLabel(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("successful creation with default color", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."labels"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/labels", []byte(`{"name":" bug "}`), userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var label model.Label
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &label))
+		assert.Equal(t, "bug", label.Name)
+		assert.Equal(t, model.DefaultLabelColor, label.Color)
+		assert.Equal(t, userID, label.UserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/labels", []byte(`{"name":"bug"}`), uuid.New()))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("invalid color", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/labels", []byte(`{"name":"bug","color":"red"}`), uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "color must be")
+	})
+}
+
+func TestUpdateLabel(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	labelID := uuid.New()
+	now := time.Now()
+
+	t.Run("rename", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns[:6]).AddRow(labelID, userID, "bug", "#d73a4a", now, now))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."labels"`).
+			WithArgs(userID, "defect", labelID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."labels" SET .* WHERE id = \$\d+ AND user_id = \$\d+`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels"`).
+			WillReturnRows(sqlmock.NewRows(labelColumns[:6]).AddRow(labelID, userID, "defect", "#d73a4a", now, now))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/labels/"+labelID.String(), []byte(`{"name":"defect"}`), userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"name":"defect"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("another user's label", func(t *testing.T) {
+		mock := setupMockDB(t)
+		intruderID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, intruderID, 1).
+			WillReturnRows(sqlmock.NewRows(labelColumns[:6]))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/labels/"+labelID.String(), []byte(`{"name":"mine"}`), intruderID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteLabel(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("invalid label ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/labels/not-a-uuid", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid label ID")
+	})
+
+	t.Run("successful delete", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		labelID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."labels" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(labelID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/labels/"+labelID.String(), nil, userID))
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAttachTaskLabel(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+		intruderID := uuid.New()
+		taskID := uuid.New()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, intruderID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String()+"/labels/"+uuid.New().String(), nil, intruderID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Task not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid label ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/"+uuid.New().String()+"/labels/bug", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid label ID")
+	})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// RequireIfMatch makes writes to a task without an If-Match header fail with
+// 428 Precondition Required instead of applying unconditionally
+var RequireIfMatch bool
+
+// taskETag is the strong entity tag of a task at version
+func taskETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// matchesETag reports whether the If-Match value header lists etag. Weak tags
+// never match: If-Match uses the strong comparison.
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// taskPrecondition evaluates the If-Match header of a write to one of userID's
+// tasks. It returns the version the write must be made against, 0 when any
+// version will do, and the task it loaded to check the header, if any. When
+// ok is false a 404, 412 or 428 response has been written.
+func taskPrecondition(w http.ResponseWriter, r *http.Request, userID, taskID uuid.UUID) (ifVersion int, current *model.Task, ok bool) {
+	header := strings.Join(r.Header.Values("If-Match"), ",")
+	if strings.TrimSpace(header) == "" {
+		if RequireIfMatch {
+			http.Error(w, "If-Match header required", http.StatusPreconditionRequired)
+			return 0, nil, false
+		}
+		return 0, nil, true
+	}
+
+	current, err := database.GetTask(userID, taskID)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return 0, nil, false
+		}
+		http.Error(w, "Failed to fetch task", http.StatusInternalServerError)
+		return 0, nil, false
+	}
+
+	if !matchesETag(header, taskETag(current.Version)) {
+		writeTaskPreconditionFailed(w, userID, current)
+		return 0, nil, false
+	}
+
+	return current.Version, current, true
+}
+
+// writeTaskConflict answers a write that lost a race with another one after
+// passing its If-Match check, with the task as it is now
+func writeTaskConflict(w http.ResponseWriter, userID, taskID uuid.UUID) {
+	task, err := database.GetTask(userID, taskID)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch task", http.StatusInternalServerError)
+		return
+	}
+	writeTaskPreconditionFailed(w, userID, task)
+}
+
+// writeTaskPreconditionFailed writes a 412 with the current representation of
+// task, so the client can merge its change and retry with the new ETag
+func writeTaskPreconditionFailed(w http.ResponseWriter, userID uuid.UUID, task *model.Task) {
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", resp.ETag)
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(resp)
+}
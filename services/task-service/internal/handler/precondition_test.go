@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesETag(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"3"`, true},
+		{`"2"`, false},
+		{`"1", "3"`, true},
+		{`"1","2"`, false},
+		{`*`, true},
+		{`W/"3"`, false},
+		{`3`, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchesETag(tt.header, `"3"`), tt.header)
+	}
+}
+
+func TestTaskPreconditions(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "version")
+
+	taskRow := func(title string, version int) *sqlmock.Rows {
+		return sqlmock.NewRows(columns).AddRow(taskID, userID, title, nil, "todo", nil, nil, nil, now, now, version)
+	}
+
+	t.Run("GET returns the task's ETag", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("Task", 3))
+		expectTaskEnrichment(mock, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/"+taskID.String(), nil, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3"`, rr.Header().Get("ETag"))
+		var resp GetTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, 3, resp.Version)
+		assert.Equal(t, `"3"`, resp.ETag)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stale If-Match returns the current task", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("Their Title", 4))
+		expectTaskEnrichment(mock, nil)
+
+		body, _ := json.Marshal(UpdateTaskRequest{Title: stringPtr("My Title")})
+		req := newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), body, userID)
+		req.Header.Set("If-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+		var resp GetTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "Their Title", resp.Title)
+		assert.Equal(t, 4, resp.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("matching If-Match applies the update", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("Old Title", 3))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WillReturnRows(taskRow("Old Title", 3))
+		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("New Title", 4))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		body, _ := json.Marshal(UpdateTaskRequest{Title: stringPtr("New Title")})
+		req := newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), body, userID)
+		req.Header.Set("If-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a write that loses the race returns the current task", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("Task", 3))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" .* FOR UPDATE`).
+			WillReturnRows(taskRow("Renamed", 4))
+		mock.ExpectRollback()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(taskRow("Renamed", 4))
+		expectTaskEnrichment(mock, nil)
+
+		req := newAuthedRequest(t, "DELETE", "/tasks/"+taskID.String(), nil, userID)
+		req.Header.Set("If-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Equal(t, `"4"`, rr.Header().Get("ETag"))
+		assert.Contains(t, rr.Body.String(), "Renamed")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("If-Match on a missing task", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		req := newAuthedRequest(t, "PATCH", "/tasks/"+taskID.String()+"/complete", nil, userID)
+		req.Header.Set("If-Match", `"3"`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("required If-Match", func(t *testing.T) {
+		RequireIfMatch = true
+		t.Cleanup(func() { RequireIfMatch = false })
+		mock := setupMockDB(t)
+
+		body, _ := json.Marshal(MoveTaskRequest{})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String()+"/project", body, userID))
+
+		assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
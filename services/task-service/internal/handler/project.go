@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+type CreateProjectRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+	Color       *string `json:"color"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Color       *string `json:"color,omitempty"`
+	Archived    *bool   `json:"archived,omitempty"`
+}
+
+// MoveTaskRequest moves a task into ProjectID, or out of any project when it is null
+type MoveTaskRequest struct {
+	ProjectID *uuid.UUID `json:"project_id"`
+}
+
+// writeProjectError maps repository errors to HTTP responses
+func writeProjectError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrProjectNotFound):
+		http.Error(w, "Project not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrProjectArchived):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, database.ErrSubtaskProject):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func ListProjects(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Archived projects are hidden unless asked for
+	includeArchived := false
+	if raw := r.URL.Query().Get("include_archived"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			http.Error(w, "include_archived must be true or false", http.StatusBadRequest)
+			return
+		}
+		includeArchived = v
+	}
+
+	projects, err := database.GetProjectsByUserID(userID, includeArchived)
+	if err != nil {
+		http.Error(w, "Failed to fetch projects", http.StatusInternalServerError)
+		return
+	}
+
+	if projects == nil {
+		projects = []model.Project{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(projects)
+}
+
+func CreateProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	project := model.Project{
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Color:       model.DefaultProjectColor,
+	}
+	if req.Color != nil {
+		project.Color = *req.Color
+	}
+
+	if err := project.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateProject(&project); err != nil {
+		writeProjectError(w, err, "Failed to create project")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(project)
+}
+
+func GetProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	project, err := database.GetProject(userID, projectID)
+	if err != nil {
+		writeProjectError(w, err, "Failed to fetch project")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(project)
+}
+
+func UpdateProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetProject(userID, projectID)
+	if err != nil {
+		writeProjectError(w, err, "Failed to fetch project")
+		return
+	}
+
+	// Validate the project as it will look after the update
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		current.Name = strings.TrimSpace(*req.Name)
+		updates["name"] = current.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Color != nil {
+		current.Color = *req.Color
+		updates["color"] = current.Color
+	}
+	if req.Archived != nil {
+		updates["archived"] = *req.Archived
+	}
+
+	if err := current.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project := current
+	if len(updates) > 0 {
+		project, err = database.UpdateProject(userID, projectID, updates)
+		if err != nil {
+			writeProjectError(w, err, "Failed to update project")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(project)
+}
+
+func DeleteProject(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteProject(userID, projectID); err != nil {
+		writeProjectError(w, err, "Failed to delete project")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListProjectTasks is GET /tasks scoped to a single project. It accepts the
+// same filter, sort and pagination parameters.
+func ListProjectTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	filter, err := parseTaskFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The project must belong to the caller; archived projects stay readable
+	if _, err := database.GetProject(userID, projectID); err != nil {
+		writeProjectError(w, err, "Failed to fetch project")
+		return
+	}
+
+	filter.ProjectID = &projectID
+	filter.NoProject = false
+
+	writeTaskPage(w, userID, filter)
+}
+
+func MoveTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	var req MoveTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	ifVersion, _, ok := taskPrecondition(w, r, userID, taskID)
+	if !ok {
+		return
+	}
+
+	task, err := database.MoveTask(userID, taskID, ifVersion, req.ProjectID)
+	if err != nil {
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeTaskConflict(w, userID, taskID)
+			return
+		}
+		writeProjectError(w, err, "Failed to move task")
+		return
+	}
+
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", resp.ETag)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
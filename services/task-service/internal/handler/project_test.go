@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+var projectColumns = []string{"id", "user_id", "name", "description", "color", "archived", "created_at", "updated_at"}
+
+func TestCreateProject(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("successful creation with default color", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/projects", []byte(`{"name":" Acme "}`), userID))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var project model.Project
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &project))
+		assert.Equal(t, "Acme", project.Name)
+		assert.Equal(t, model.DefaultProjectColor, project.Color)
+		assert.Equal(t, userID, project.UserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing name", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/projects", []byte(`{"name":""}`), uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "name field is required")
+	})
+}
+
+func TestListProjects(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("invalid include_archived", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/projects?include_archived=maybe", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("empty list encodes as array", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/projects", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `[]`, rr.Body.String())
+	})
+}
+
+func TestListProjectTasks(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+
+	t.Run("scopes the listing to the project", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(projectID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", true, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND status IN \(\$2\) AND project_id = \$3`).
+			WithArgs(userID, "todo", projectID, database.DefaultTaskPageSize+1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/projects/"+projectID.String()+"/tasks?status=todo", nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("project owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows(projectColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/projects/"+projectID.String()+"/tasks", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Project not found")
+	})
+}
+
+func TestMoveTask(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+
+	t.Run("into an archived project", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", true, now, now))
+
+		body := []byte(`{"project_id":"` + projectID.String() + `"}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String()+"/project", body, userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String()+"/project", []byte(`{"project_id":"nope"}`), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
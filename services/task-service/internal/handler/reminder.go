@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+type CreateReminderRequest struct {
+	// OffsetMinutes is how long before the due date to remind; 0 is at the
+	// due time
+	OffsetMinutes int `json:"offset_minutes"`
+}
+
+// writeReminderError maps repository errors to HTTP responses
+func writeReminderError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrReminderNotFound):
+		http.Error(w, "Reminder not found", http.StatusNotFound)
+	case errors.Is(err, database.ErrReminderExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func ListReminders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	reminders, err := database.GetReminders(userID, taskID)
+	if err != nil {
+		writeReminderError(w, err, "Failed to fetch reminders")
+		return
+	}
+
+	if reminders == nil {
+		reminders = []model.Reminder{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reminders)
+}
+
+func CreateReminder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	var req CreateReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	reminder := model.Reminder{
+		TaskID:        taskID,
+		OffsetMinutes: req.OffsetMinutes,
+	}
+
+	if err := reminder.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateReminder(userID, &reminder); err != nil {
+		writeReminderError(w, err, "Failed to create reminder")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reminder)
+}
+
+func DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	reminderID, ok := parseURLUUID(w, r, "reminderID", "reminder")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteReminder(userID, taskID, reminderID); err != nil {
+		writeReminderError(w, err, "Failed to delete reminder")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
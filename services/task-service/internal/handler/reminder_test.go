@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+func TestCreateReminder(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	t.Run("creates a reminder", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, due, nil, now, now))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."reminders"`).
+			WithArgs(taskID, 1440).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."reminders"`).
+			WithArgs(taskID, userID, 1440, nil, nil, 0, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/reminders", []byte(`{"offset_minutes":1440}`), userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var reminder model.Reminder
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &reminder))
+		require.NotNil(t, reminder.RemindAt)
+		assert.True(t, due.Add(-24*time.Hour).Equal(*reminder.RemindAt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate offset", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, due, nil, now, now))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"."reminders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/reminders", []byte(`{"offset_minutes":0}`), userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("negative offset", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/reminders", []byte(`{"offset_minutes":-10}`), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "offset_minutes cannot be negative")
+	})
+
+	t.Run("task owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/reminders", []byte(`{"offset_minutes":0}`), uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Task not found")
+	})
+}
+
+func TestListReminders(t *testing.T) {
+	router := setupTestRouter()
+	mock := setupMockDB(t)
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+		WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."reminders" WHERE task_id = \$1 ORDER BY offset_minutes DESC`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "user_id", "offset_minutes", "created_at"}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/"+taskID.String()+"/reminders", nil, userID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteReminder(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	t.Run("unknown reminder", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM "tasks"."reminders"`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/"+taskID.String()+"/reminders/"+uuid.New().String(), nil, userID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Reminder not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid reminder ID", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/"+taskID.String()+"/reminders/nope", nil, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Invalid reminder ID")
+	})
+}
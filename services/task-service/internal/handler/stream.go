@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/stream"
+)
+
+// StreamHeartbeatInterval is how often an idle stream sends a comment, so
+// proxies keep the connection open and clients notice when it drops
+var StreamHeartbeatInterval = 15 * time.Second
+
+// streamCatchUpPageSize is how many missed events are read at a time when a
+// stream resumes
+const streamCatchUpPageSize = 500
+
+// TaskStreamEvent is the data of a task stream event. Task is the task as it
+// is now, as GET /tasks/{id} returns it; it is left out of deletions and of
+// tasks that no longer exist.
+type TaskStreamEvent struct {
+	ID     int64            `json:"id"`
+	TaskID uuid.UUID        `json:"task_id"`
+	Action string           `json:"action"`
+	Task   *GetTaskResponse `json:"task,omitempty"`
+}
+
+// StreamTasks streams changes to the user's tasks as Server-Sent Events
+// named created, updated or deleted. A client that reconnects with
+// Last-Event-ID, or the last_event_id query parameter, first receives the
+// changes it missed. A ready event marks the point where the stream is
+// caught up and its ID is where to resume from.
+func StreamTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	lastID, resuming, err := lastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before catching up, so nothing committed in between is lost
+	sub := stream.Events.Subscribe(userID)
+	defer sub.Close()
+
+	var missed []stream.Event
+	if resuming {
+		for {
+			page, err := database.GetTaskStreamEvents(userID, lastID, streamCatchUpPageSize)
+			if err != nil {
+				http.Error(w, "Failed to fetch task events", http.StatusInternalServerError)
+				return
+			}
+			missed = append(missed, page...)
+			if len(page) < streamCatchUpPageSize {
+				break
+			}
+			lastID = page[len(page)-1].ID
+		}
+	} else if lastID, err = database.LatestTaskStreamEventID(userID); err != nil {
+		http.Error(w, "Failed to fetch task events", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Stop nginx-style proxies from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	for _, event := range missed {
+		if err := writeTaskStreamEvent(w, userID, event); err != nil {
+			return
+		}
+		lastID = max(lastID, event.ID)
+	}
+	fmt.Fprintf(w, "id: %d\nevent: ready\ndata: {}\n\n", lastID)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped; the client reconnects and catches up
+				return
+			}
+			// Already sent while catching up. Events are numbered in commit
+			// order, so nothing below lastID can still be on its way.
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeTaskStreamEvent(w, userID, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// lastEventID returns the ID a stream resumes from, if the client sent one
+func lastEventID(r *http.Request) (int64, bool, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.New("Last-Event-ID must be a non-negative integer")
+	}
+	return id, true, nil
+}
+
+func writeTaskStreamEvent(w io.Writer, userID uuid.UUID, event stream.Event) error {
+	data := TaskStreamEvent{ID: event.ID, TaskID: event.TaskID, Action: event.Action}
+
+	if event.Type() != stream.TypeDeleted {
+		task, err := streamedTask(userID, event.TaskID)
+		if err != nil {
+			log.Printf("Failed to load task %s for stream event %d: %v", event.TaskID, event.ID, err)
+		}
+		data.Task = task
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type(), payload)
+	return err
+}
+
+// streamedTask loads a task for a stream event, or nil if it no longer exists
+func streamedTask(userID, taskID uuid.UUID) (*GetTaskResponse, error) {
+	task, err := database.GetTask(userID, taskID)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
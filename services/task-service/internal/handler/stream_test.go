@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/stream"
+)
+
+// sseEvent is one parsed Server-Sent Events block; comments are collected
+// under ":"
+type sseEvent map[string]string
+
+func readSSEEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	event := sseEvent{}
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return event
+		}
+		if strings.HasPrefix(line, ":") {
+			event[":"] = strings.TrimSpace(line[1:])
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		event[field] = value
+	}
+}
+
+// openTaskStream starts a stream request against server; the stream ends
+// when the test does
+func openTaskStream(t *testing.T, server *httptest.Server, target string, header http.Header) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+target, nil)
+	require.NoError(t, err)
+	req.Header = header
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+func TestStreamTasks(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("resumes from Last-Event-ID, then streams live changes", func(t *testing.T) {
+		mock := setupMockDB(t)
+		server := httptest.NewServer(router)
+		t.Cleanup(server.Close)
+
+		userID := uuid.New()
+		deletedID, createdID := uuid.New(), uuid.New()
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT max\(seq\) AS id, user_id, task_id, action FROM tasks.task_events`).
+			WithArgs(userID, int64(10), streamCatchUpPageSize).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "task_id", "action"}).
+				AddRow(11, userID, deletedID, "deleted"))
+
+		resp, events := openTaskStream(t, server, "/tasks/stream", http.Header{
+			"Authorization": {bearerToken(t, userID)},
+			"Last-Event-Id": {"10"},
+		})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, sseEvent{"retry": "3000"}, readSSEEvent(t, events))
+
+		missed := readSSEEvent(t, events)
+		assert.Equal(t, "11", missed["id"])
+		assert.Equal(t, "deleted", missed["event"])
+		assert.JSONEq(t, `{"id":11,"task_id":"`+deletedID.String()+`","action":"deleted"}`, missed["data"])
+
+		assert.Equal(t, sseEvent{"id": "11", "event": "ready", "data": "{}"}, readSSEEvent(t, events))
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\)`).
+			WithArgs(createdID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+				createdID, userID, "Live task", nil, "todo", nil, nil, nil, now, now,
+			))
+		expectTaskEnrichment(mock, nil)
+
+		// Events the stream has already sent are skipped
+		stream.Events.Publish(stream.Event{ID: 11, UserID: userID, TaskID: deletedID, Action: "deleted"})
+		stream.Events.Publish(stream.Event{ID: 12, UserID: userID, TaskID: createdID, Action: "created"})
+
+		live := readSSEEvent(t, events)
+		assert.Equal(t, "12", live["id"])
+		assert.Equal(t, "created", live["event"])
+
+		var data TaskStreamEvent
+		require.NoError(t, json.Unmarshal([]byte(live["data"]), &data))
+		assert.Equal(t, createdID, data.TaskID)
+		require.NotNil(t, data.Task)
+		assert.Equal(t, "Live task", data.Task.Title)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("new streams start at the latest change and send heartbeats", func(t *testing.T) {
+		mock := setupMockDB(t)
+		server := httptest.NewServer(router)
+		t.Cleanup(server.Close)
+
+		defer func(interval time.Duration) { StreamHeartbeatInterval = interval }(StreamHeartbeatInterval)
+		StreamHeartbeatInterval = 10 * time.Millisecond
+
+		userID := uuid.New()
+		mock.ExpectQuery(`SELECT COALESCE\(max\(seq\), 0\) FROM tasks.task_events WHERE user_id = \$1`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(5))
+
+		// EventSource cannot set headers, so the token goes in the query
+		token := strings.TrimPrefix(bearerToken(t, userID), "Bearer ")
+		resp, events := openTaskStream(t, server, "/tasks/stream?jwt="+url.QueryEscape(token), http.Header{})
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		readSSEEvent(t, events)
+		assert.Equal(t, sseEvent{"id": "5", "event": "ready", "data": "{}"}, readSSEEvent(t, events))
+		assert.Equal(t, sseEvent{":": "heartbeat"}, readSSEEvent(t, events))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		req := newAuthedRequest(t, "GET", "/tasks/stream", nil, uuid.New())
+		req.Header.Set("Last-Event-ID", "yesterday")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Last-Event-ID must be a non-negative integer")
+	})
+
+	t.Run("query tokens are only accepted by the stream route", func(t *testing.T) {
+		token := strings.TrimPrefix(bearerToken(t, uuid.New()), "Bearer ")
+
+		// Asking for an event stream does not make other routes take one
+		req := httptest.NewRequest("GET", "/tasks?jwt="+url.QueryEscape(token), nil)
+		req.Header.Set("Accept", "text/event-stream")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
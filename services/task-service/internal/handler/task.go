@@ -2,9 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,15 +16,19 @@ import (
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/utils"
 )
 
-// TODO: Add authorization for task endpoints based on UserID so each user has their own tasks
-
 type CreateTaskRequest struct {
+	ProjectID   *uuid.UUID `json:"project_id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
 	Title       string     `json:"title"`
 	Description *string    `json:"description"`
 	Status      string     `json:"status"`
 	Priority    *string    `json:"priority"`
 	DueDate     *time.Time `json:"due_date"`
 	CompletedAt *time.Time `json:"completed_at"`
+	// Recurrence is an RRULE such as "FREQ=WEEKLY;BYDAY=MO", evaluated in
+	// RecurrenceTimezone (an IANA name, UTC if omitted)
+	Recurrence         *string `json:"recurrence"`
+	RecurrenceTimezone *string `json:"recurrence_timezone"`
 }
 
 type UpdateTaskRequest struct {
@@ -33,26 +38,126 @@ type UpdateTaskRequest struct {
 	Priority    *string    `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// An empty Recurrence stops the task from recurring
+	Recurrence         *string `json:"recurrence,omitempty"`
+	RecurrenceTimezone *string `json:"recurrence_timezone,omitempty"`
+}
+
+// CompleteTaskResponse is the completed task plus, for recurring tasks, the
+// occurrence created to follow it
+type CompleteTaskResponse struct {
+	model.Task
+	NextOccurrence *model.Task `json:"next_occurrence,omitempty"`
 }
 
 type GetTaskResponse struct {
-	ID          uuid.UUID  `json:"id"`
-	UserID      uuid.UUID  `json:"user_id"`
-	Title       string     `json:"title"`
-	Description *string    `json:"description,omitempty"`
-	Status      string     `json:"status"`
-	Priority    *string    `json:"priority,omitempty"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID           uuid.UUID           `json:"id"`
+	UserID       uuid.UUID           `json:"user_id"`
+	ProjectID    *uuid.UUID          `json:"project_id,omitempty"`
+	ParentID     *uuid.UUID          `json:"parent_id,omitempty"`
+	Title        string              `json:"title"`
+	Description  *string             `json:"description,omitempty"`
+	Status       string              `json:"status"`
+	Priority     *string             `json:"priority,omitempty"`
+	DueDate      *time.Time          `json:"due_date,omitempty"`
+	CompletedAt  *time.Time          `json:"completed_at,omitempty"`
+	Recurrence   *string             `json:"recurrence,omitempty"`
+	RecurrenceTZ *string             `json:"recurrence_timezone,omitempty"`
+	Occurrence   int                 `json:"occurrence,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Labels       []model.Label       `json:"labels"`
+	Progress     *model.TaskProgress `json:"progress,omitempty"`
+	CommentCount int                 `json:"comment_count"`
+	DeletedAt    *time.Time          `json:"deleted_at,omitempty"`
+	Version      int                 `json:"version"`
+	Rank         string              `json:"rank"`
+	ETag         string              `json:"etag"`
 }
 
-func CreateTask(w http.ResponseWriter, r *http.Request) {
-	// Get userID from JWT token
-	userID, err := utils.GetUserIDFromToken(r, os.Getenv("JWT_SECRET"))
+// enrichTaskResponses loads the related data shown alongside each task
+// (labels, checklist/subtask progress, comment count) with one query per kind
+func enrichTaskResponses(userID uuid.UUID, resps []*GetTaskResponse) error {
+	if len(resps) == 0 {
+		return nil
+	}
+
+	if err := attachTaskLabels(userID, resps); err != nil {
+		return err
+	}
+
+	taskIDs := make([]uuid.UUID, len(resps))
+	for i, resp := range resps {
+		taskIDs[i] = resp.ID
+	}
+
+	progress, err := database.GetTaskProgress(taskIDs)
+	if err != nil {
+		return err
+	}
+	counts, err := database.GetCommentCounts(taskIDs)
 	if err != nil {
-		http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	for _, resp := range resps {
+		if p, ok := progress[resp.ID]; ok {
+			resp.Progress = &p
+		}
+		resp.CommentCount = counts[resp.ID]
+	}
+
+	return nil
+}
+
+// authenticatedUserID returns the caller's user ID set by utils.AuthMiddleware,
+// writing a 401 response if it is missing
+func authenticatedUserID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, ok := utils.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized: missing user identity", http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func newGetTaskResponse(task model.Task) GetTaskResponse {
+	return GetTaskResponse{
+		ID:           task.ID,
+		UserID:       task.UserID,
+		ProjectID:    task.ProjectID,
+		ParentID:     task.ParentID,
+		Title:        task.Title,
+		Description:  task.Description,
+		Status:       task.Status,
+		Priority:     task.Priority,
+		DueDate:      task.DueDate,
+		CompletedAt:  task.CompletedAt,
+		Recurrence:   task.Recurrence,
+		RecurrenceTZ: task.RecurrenceTimezone,
+		Occurrence:   task.Occurrence,
+		CreatedAt:    task.CreatedAt,
+		UpdatedAt:    task.UpdatedAt,
+		Labels:       []model.Label{},
+		DeletedAt:    deletedAt(task),
+		Version:      task.Version,
+		Rank:         task.Rank,
+		ETag:         taskETag(task.Version),
+	}
+}
+
+// deletedAt is when task was moved to the trash, or nil if it is live
+func deletedAt(task model.Task) *time.Time {
+	if !task.DeletedAt.Valid {
+		return nil
+	}
+	return &task.DeletedAt.Time
+}
+
+func CreateTask(w http.ResponseWriter, r *http.Request) {
+	// Get authenticated user ID from context
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -64,9 +169,47 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Subtasks must hang off one of the caller's own top-level tasks and
+	// always live in the same project as their parent
+	projectID := req.ProjectID
+	if req.ParentID != nil {
+		parent, err := database.ValidateParent(userID, *req.ParentID)
+		if err != nil {
+			if errors.Is(err, database.ErrParentNotFound) || errors.Is(err, database.ErrSubtaskDepth) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Failed to validate parent task", http.StatusInternalServerError)
+			return
+		}
+		if projectID != nil && (parent.ProjectID == nil || *projectID != *parent.ProjectID) {
+			http.Error(w, database.ErrSubtaskProject.Error(), http.StatusBadRequest)
+			return
+		}
+		projectID = parent.ProjectID
+	} else if projectID != nil {
+		if err := database.ValidateProject(userID, *projectID); err != nil {
+			writeProjectError(w, err, "Failed to validate project")
+			return
+		}
+	}
+
+	// Statuses come from the project's workflow; new tasks start in its first not-started status
+	wf, err := database.GetWorkflow(userID, projectID)
+	if err != nil {
+		writeProjectError(w, err, "Failed to load workflow")
+		return
+	}
+	if req.Status == "" {
+		initial, _ := wf.FirstInCategory(model.StatusCategoryNotStarted)
+		req.Status = initial.Key
+	}
+
 	// Create Task object
 	task := model.Task{
 		UserID:      userID,
+		ProjectID:   projectID,
+		ParentID:    req.ParentID,
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      req.Status,
@@ -74,9 +217,15 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		DueDate:     req.DueDate,
 		CompletedAt: req.CompletedAt,
 	}
+	if req.Recurrence != nil && *req.Recurrence != "" {
+		task.Recurrence = req.Recurrence
+		task.RecurrenceTimezone = req.RecurrenceTimezone
+		task.RecurrenceStart = req.DueDate
+		task.Occurrence = 1
+	}
 
 	// Validate task input
-	if err := task.Validate(); err != nil {
+	if err := task.ValidateInWorkflow(*wf); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -100,16 +249,28 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 
 func ListTasks(w http.ResponseWriter, r *http.Request) {
 	// Get authenticated user ID from context
-	userID := r.Context().Value(utils.UserIDKey).(uuid.UUID)
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
 
-	// Fetch Tasks for THIS USER from DB
-	tasks, err := database.GetTasksByUserID(userID)
+	// Parse filters, sorting and pagination from the query string
+	filter, err := parseTaskFilter(r.URL.Query())
 	if err != nil {
-		if err.Error() == "no tasks found for user" {
-			// Return empty array for no tasks (not an error)
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode([]GetTaskResponse{})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeTaskPage(w, userID, filter)
+}
+
+// writeTaskPage fetches one page of the caller's tasks matching filter and writes it as a ListTasksResponse
+func writeTaskPage(w http.ResponseWriter, userID uuid.UUID, filter database.TaskFilter) {
+	// Fetch one page of Tasks for THIS USER from DB
+	tasks, nextCursor, err := database.ListTasks(userID, filter)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 		http.Error(w, "Failed to fetch tasks", http.StatusInternalServerError)
@@ -117,20 +278,17 @@ func ListTasks(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create response
-	resp := make([]GetTaskResponse, len(tasks))
+	resp := ListTasksResponse{
+		Data:       make([]GetTaskResponse, len(tasks)),
+		NextCursor: nextCursor,
+		Limit:      filter.Limit,
+	}
 	for i, task := range tasks {
-		resp[i] = GetTaskResponse{
-			ID:          task.ID,
-			UserID:      task.UserID,
-			Title:       task.Title,
-			Description: task.Description,
-			Status:      task.Status,
-			Priority:    task.Priority,
-			DueDate:     task.DueDate,
-			CompletedAt: task.CompletedAt,
-			CreatedAt:   task.CreatedAt,
-			UpdatedAt:   task.UpdatedAt,
-		}
+		resp.Data[i] = newGetTaskResponse(task)
+	}
+	if err := enrichTaskResponses(userID, taskResponsePointers(resp.Data)); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
 	}
 
 	// Return Task data
@@ -140,6 +298,11 @@ func ListTasks(w http.ResponseWriter, r *http.Request) {
 }
 
 func GetTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
 	// Get task ID from URL path
 	taskIDStr := chi.URLParam(r, "taskID")
 	taskID, err := uuid.Parse(taskIDStr)
@@ -149,10 +312,10 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch Task from DB
-	task, err := database.GetTask(taskID)
+	task, err := database.GetTask(userID, taskID)
 	if err != nil {
-		if err.Error() == "task not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
 			return
 		}
 		http.Error(w, "Failed to fetch task", http.StatusInternalServerError)
@@ -160,26 +323,25 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create response
-	resp := GetTaskResponse{
-		ID:          task.ID, // Don't forget this!
-		UserID:      task.UserID,
-		Title:       task.Title,
-		Description: task.Description,
-		Status:      task.Status,
-		Priority:    task.Priority,
-		DueDate:     task.DueDate,
-		CompletedAt: task.CompletedAt,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
 	}
 
 	// Return Task data
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", resp.ETag)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(resp)
 }
 
 func UpdateTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
 	var req UpdateTaskRequest
 
 	// Decode Request
@@ -196,6 +358,74 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ifVersion, current, ok := taskPrecondition(w, r, userID, taskID)
+	if !ok {
+		return
+	}
+
+	updates, err := taskUpdates(req,
+		func() (*model.Task, error) {
+			if current != nil {
+				return current, nil
+			}
+			return database.GetTask(userID, taskID)
+		},
+		database.PrepareStatusChange)
+	if err != nil {
+		writeTaskUpdateError(w, err)
+		return
+	}
+
+	// Update in database
+	task, err := database.UpdateTask(userID, taskID, ifVersion, updates)
+	if err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeTaskConflict(w, userID, taskID)
+			return
+		}
+		http.Error(w, "Failed to update task", http.StatusInternalServerError)
+		return
+	}
+
+	// Return updated task
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(task)
+}
+
+// taskUpdateError is a task update that cannot be made, with the status and
+// message it is answered with
+type taskUpdateError struct {
+	status  int
+	message string
+}
+
+func (e *taskUpdateError) Error() string {
+	return e.message
+}
+
+func writeTaskUpdateError(w http.ResponseWriter, err error) {
+	var updateErr *taskUpdateError
+	if errors.As(err, &updateErr) {
+		http.Error(w, updateErr.message, updateErr.status)
+		return
+	}
+	http.Error(w, "Failed to update task", http.StatusInternalServerError)
+}
+
+// taskUpdates builds the column updates for req. Status, recurrence and due
+// date changes depend on the task's current state, which is then loaded with
+// load and checked against its workflow with prepareStatus.
+func taskUpdates(
+	req UpdateTaskRequest,
+	load func() (*model.Task, error),
+	prepareStatus func(*model.Task, string, map[string]interface{}) error,
+) (map[string]interface{}, error) {
 	// Build updates map with only provided fields
 	updates := map[string]interface{}{
 		"updated_at": time.Now(),
@@ -207,9 +437,6 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
-	if req.Status != nil {
-		updates["status"] = *req.Status
-	}
 	if req.Priority != nil {
 		updates["priority"] = *req.Priority
 	}
@@ -220,24 +447,99 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		updates["completed_at"] = *req.CompletedAt
 	}
 
-	// Update in database
-	task, err := database.UpdateTask(taskID, updates)
-	if err != nil {
-		if err.Error() == "failed to update task: task not found" || strings.Contains(err.Error(), "task not found") {
-			http.Error(w, "Task not found", http.StatusNotFound)
-			return
+	// Status and recurrence changes depend on the task's current state
+	var current *model.Task
+	if req.Status != nil || req.Recurrence != nil || req.DueDate != nil {
+		var err error
+		current, err = load()
+		if err != nil {
+			if errors.Is(err, database.ErrTaskNotFound) {
+				return nil, &taskUpdateError{http.StatusNotFound, "Task not found"}
+			}
+			return nil, &taskUpdateError{http.StatusInternalServerError, "Failed to fetch task"}
 		}
-		http.Error(w, "Failed to update task", http.StatusInternalServerError)
-		return
 	}
 
-	// Return updated task
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(task)
+	// Status changes follow the task's workflow and move completed_at with them
+	if req.Status != nil {
+		if err := prepareStatus(current, *req.Status, updates); err != nil {
+			switch {
+			case errors.Is(err, database.ErrInvalidStatus):
+				return nil, &taskUpdateError{http.StatusBadRequest, err.Error()}
+			case errors.Is(err, database.ErrTransitionNotAllowed):
+				return nil, &taskUpdateError{http.StatusConflict, err.Error()}
+			default:
+				return nil, &taskUpdateError{http.StatusInternalServerError, "Failed to load workflow"}
+			}
+		}
+	}
+
+	if err := prepareRecurrenceChange(current, req, updates); err != nil {
+		return nil, &taskUpdateError{http.StatusBadRequest, err.Error()}
+	}
+
+	return updates, nil
+}
+
+// prepareRecurrenceChange validates the recurrence fields of req and adds
+// them to updates. current is nil unless req changes the rule or due date.
+// Setting a rule, or moving the due date of a recurring task, restarts its
+// schedule from the new due date; the occurrence count carries on.
+func prepareRecurrenceChange(current *model.Task, req UpdateTaskRequest, updates map[string]interface{}) error {
+	if req.RecurrenceTimezone != nil {
+		if _, err := model.LoadRecurrenceLocation(req.RecurrenceTimezone); err != nil {
+			return err
+		}
+		if *req.RecurrenceTimezone == "" {
+			updates["recurrence_tz"] = nil
+		} else {
+			updates["recurrence_tz"] = *req.RecurrenceTimezone
+		}
+	}
+
+	if current == nil || (req.Recurrence == nil && req.DueDate == nil) {
+		return nil
+	}
+
+	recurrence := current.Recurrence
+	if req.Recurrence != nil {
+		if *req.Recurrence == "" {
+			updates["recurrence"] = nil
+			updates["recurrence_start"] = nil
+			updates["occurrence"] = 0
+			return nil
+		}
+		if _, err := model.ParseRecurrenceRule(*req.Recurrence); err != nil {
+			return err
+		}
+		updates["recurrence"] = *req.Recurrence
+		if current.Occurrence == 0 {
+			updates["occurrence"] = 1
+		}
+		recurrence = req.Recurrence
+	}
+	if recurrence == nil {
+		return nil
+	}
+
+	dueDate := current.DueDate
+	if req.DueDate != nil {
+		dueDate = req.DueDate
+	}
+	if dueDate == nil {
+		return errors.New("recurring tasks require a due date")
+	}
+	updates["recurrence_start"] = *dueDate
+
+	return nil
 }
 
 func DeleteTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
 	// Get task ID from URL
 	taskIDStr := chi.URLParam(r, "taskID")
 	taskID, err := uuid.Parse(taskIDStr)
@@ -246,13 +548,22 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete from database
-	err = database.DeleteTask(taskID)
+	ifVersion, _, ok := taskPrecondition(w, r, userID, taskID)
+	if !ok {
+		return
+	}
+
+	// Move to the trash; attachments are kept until the task is purged
+	err = database.DeleteTask(userID, taskID, ifVersion)
 	if err != nil {
-		if strings.Contains(err.Error(), "task not found") {
+		if errors.Is(err, database.ErrTaskNotFound) {
 			http.Error(w, "Task not found", http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeTaskConflict(w, userID, taskID)
+			return
+		}
 		http.Error(w, "Failed to delete task", http.StatusInternalServerError)
 		return
 	}
@@ -262,6 +573,11 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func CompleteTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
 	// Get task ID from URL
 	taskIDStr := chi.URLParam(r, "taskID")
 	taskID, err := uuid.Parse(taskIDStr)
@@ -270,19 +586,163 @@ func CompleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Open subtasks block completion unless the caller asks to cascade
+	policy, err := parseSubtaskPolicy(r.URL.Query().Get("subtasks"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ifVersion, _, ok := taskPrecondition(w, r, userID, taskID)
+	if !ok {
+		return
+	}
+
 	// Mark task as completed
-	task, err := database.CompleteTask(taskID)
+	task, next, err := database.CompleteTask(userID, taskID, ifVersion, policy)
 	if err != nil {
-		if strings.Contains(err.Error(), "task not found") {
+		if errors.Is(err, database.ErrTaskNotFound) {
 			http.Error(w, "Task not found", http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, database.ErrVersionConflict) {
+			writeTaskConflict(w, userID, taskID)
+			return
+		}
+		if errors.Is(err, database.ErrOpenSubtasks) {
+			http.Error(w, "Task has open subtasks; complete them first or retry with ?subtasks=cascade", http.StatusConflict)
+			return
+		}
 		http.Error(w, "Failed to complete task", http.StatusInternalServerError)
 		return
 	}
 
-	// Return completed task
+	// Return completed task, and the next occurrence if it recurs
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", taskETag(task.Version))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(CompleteTaskResponse{Task: *task, NextOccurrence: next})
+}
+
+// parseSubtaskPolicy reads how a completion treats open subtasks
+func parseSubtaskPolicy(raw string) (database.SubtaskPolicy, error) {
+	switch raw {
+	case "", "refuse":
+		return database.SubtaskPolicyRefuse, nil
+	case "cascade":
+		return database.SubtaskPolicyCascade, nil
+	default:
+		return 0, errors.New("subtasks must be refuse or cascade")
+	}
+}
+
+func SearchTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "Search query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	// Search accepts the same filters as ListTasks; results are always ordered by relevance
+	filter, err := parseTaskFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	offset := 0
+	if raw := query.Get("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			http.Error(w, "offset must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := database.SearchTasks(userID, q, filter, offset)
+	if err != nil {
+		if errors.Is(err, database.ErrEmptySearchQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to search tasks", http.StatusInternalServerError)
+		return
+	}
+
+	resp := SearchTasksResponse{
+		Data:   make([]SearchTaskResult, len(results)),
+		Query:  q,
+		Limit:  filter.Limit,
+		Offset: offset,
+	}
+	for i, result := range results {
+		resp.Data[i] = SearchTaskResult{
+			GetTaskResponse: newGetTaskResponse(result.Task),
+			SearchRank:      result.SearchRank,
+			Highlights: SearchTaskHighlights{
+				Title:       result.TitleHighlight,
+				Description: result.DescriptionHighlight,
+			},
+		}
+	}
+	searchResponses := make([]*GetTaskResponse, len(resp.Data))
+	for i := range resp.Data {
+		searchResponses[i] = &resp.Data[i].GetTaskResponse
+	}
+	if err := enrichTaskResponses(userID, searchResponses); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func ListSubtasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	// The parent must belong to the caller
+	if _, err := database.GetTask(userID, taskID); err != nil {
+		if errors.Is(err, database.ErrTaskNotFound) {
+			http.Error(w, "Task not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch task", http.StatusInternalServerError)
+		return
+	}
+
+	subtasks, err := database.GetSubtasks(userID, taskID)
+	if err != nil {
+		http.Error(w, "Failed to fetch subtasks", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]GetTaskResponse, len(subtasks))
+	for i, task := range subtasks {
+		resp[i] = newGetTaskResponse(task)
+	}
+	if err := enrichTaskResponses(userID, taskResponsePointers(resp)); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
 }
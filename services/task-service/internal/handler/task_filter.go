@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+type ListTasksResponse struct {
+	Data       []GetTaskResponse `json:"data"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	Limit      int               `json:"limit"`
+}
+
+// splitQueryList accepts both repeated (?status=a&status=b) and comma-separated (?status=a,b) values
+func splitQueryList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// parseQueryTime accepts RFC 3339 timestamps or plain YYYY-MM-DD dates
+func parseQueryTime(query url.Values, key string) (*time.Time, error) {
+	raw := query.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s must be an RFC 3339 timestamp or YYYY-MM-DD date", key)
+}
+
+// parseTaskFilter builds a database.TaskFilter from the ListTasks query string
+func parseTaskFilter(query url.Values) (database.TaskFilter, error) {
+	var filter database.TaskFilter
+	var err error
+
+	filter.Statuses = splitQueryList(query["status"])
+	for _, s := range filter.Statuses {
+		// Statuses are defined per project, so only their shape can be checked here
+		if !model.IsValidStatusKey(s) {
+			return filter, fmt.Errorf("invalid status: %s", s)
+		}
+	}
+
+	filter.Priorities = splitQueryList(query["priority"])
+	for _, p := range filter.Priorities {
+		if !model.IsValidPriority(p) {
+			return filter, fmt.Errorf("priority must be one of: %s", strings.Join(model.ValidPriorities, ", "))
+		}
+	}
+
+	timeParams := []struct {
+		key  string
+		dest **time.Time
+	}{
+		{"due_after", &filter.DueAfter},
+		{"due_before", &filter.DueBefore},
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	}
+	for _, p := range timeParams {
+		if *p.dest, err = parseQueryTime(query, p.key); err != nil {
+			return filter, err
+		}
+	}
+
+	if raw := query.Get("completed"); raw != "" {
+		completed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("completed must be true or false")
+		}
+		filter.Completed = &completed
+	}
+
+	for _, raw := range splitQueryList(query["labels"]) {
+		labelID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("labels must be a comma-separated list of label IDs")
+		}
+		filter.LabelIDs = append(filter.LabelIDs, labelID)
+	}
+
+	switch strings.ToLower(query.Get("label_match")) {
+	case "", "any":
+		filter.LabelMatchAll = false
+	case "all":
+		filter.LabelMatchAll = true
+	default:
+		return filter, fmt.Errorf("label_match must be any or all")
+	}
+
+	switch raw := query.Get("parent_id"); raw {
+	case "":
+	case "none":
+		filter.TopLevelOnly = true
+	default:
+		parentID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("parent_id must be a task ID or none")
+		}
+		filter.ParentID = &parentID
+	}
+
+	switch raw := query.Get("project_id"); raw {
+	case "":
+	case "none":
+		filter.NoProject = true
+	default:
+		projectID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, fmt.Errorf("project_id must be a project ID or none")
+		}
+		filter.ProjectID = &projectID
+	}
+
+	filter.SortBy = query.Get("sort")
+	if filter.SortBy == "" {
+		filter.SortBy = database.DefaultTaskSort
+	}
+	if !database.IsValidTaskSortField(filter.SortBy) {
+		return filter, fmt.Errorf("invalid sort field: %s", filter.SortBy)
+	}
+
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+		filter.SortDesc = false
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return filter, fmt.Errorf("order must be asc or desc")
+	}
+
+	filter.Limit = database.DefaultTaskPageSize
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("limit must be a positive integer")
+		}
+		filter.Limit = min(limit, database.MaxTaskPageSize)
+	}
+
+	filter.Cursor = query.Get("cursor")
+
+	return filter, nil
+}
+
+type SearchTaskHighlights struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+}
+
+type SearchTaskResult struct {
+	GetTaskResponse
+	SearchRank float64              `json:"search_rank"`
+	Highlights SearchTaskHighlights `json:"highlights"`
+}
+
+type SearchTasksResponse struct {
+	Data   []SearchTaskResult `json:"data"`
+	Query  string             `json:"query"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
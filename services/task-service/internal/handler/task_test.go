@@ -2,54 +2,209 @@ package handler
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
 	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
-// Mock database functions for testing
-var (
-	mockCreateTask   func(task model.Task) error
-	mockGetTask      func(taskID uuid.UUID) (*model.Task, error)
-	mockUpdateTask   func(taskID uuid.UUID, updates map[string]interface{}) (*model.Task, error)
-	mockDeleteTask   func(taskID uuid.UUID) error
-	mockCompleteTask func(taskID uuid.UUID) (*model.Task, error)
-)
+// testSigningKey stands in for the auth-service's current signing key
+var testSigningKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+
+const testKeyID = "test-key"
+
+var testKeys = utils.StaticKeys{testKeyID: testSigningKey.Public()}
+
+var taskColumns = []string{
+	"id", "user_id", "title", "description", "status", "priority",
+	"due_date", "completed_at", "created_at", "updated_at",
+}
+
+var labelColumns = []string{"id", "user_id", "name", "color", "created_at", "updated_at", "task_id"}
+
+var progressColumns = []string{"task_id", "checklist_done", "checklist_total", "subtasks_done", "subtasks_total"}
+
+// expectTaskEnrichment expects the follow-up queries that load labels, progress and comment counts for returned tasks
+func expectTaskEnrichment(mock sqlmock.Sqlmock, labels *sqlmock.Rows) {
+	if labels == nil {
+		labels = sqlmock.NewRows(labelColumns)
+	}
+	mock.ExpectQuery(`SELECT labels\.\*, task_labels\.task_id FROM "tasks"."labels" JOIN tasks.task_labels`).
+		WillReturnRows(labels)
+	mock.ExpectQuery(`SELECT t\.id AS task_id,.* FROM tasks\.tasks AS t`).
+		WillReturnRows(sqlmock.NewRows(progressColumns))
+	mock.ExpectQuery(`SELECT task_id, COUNT\(\*\) AS count FROM "tasks"."comments"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "count"}))
+}
 
 func setupTestRouter() *chi.Mux {
 	r := chi.NewRouter()
-	r.Post("/tasks", CreateTask)
-	r.Get("/tasks/{taskID}", GetTask)
-	r.Put("/tasks/{taskID}", UpdateTask)
-	r.Delete("/tasks/{taskID}", DeleteTask)
-	r.Patch("/tasks/{taskID}/complete", CompleteTask)
+	r.With(utils.EventStreamAuthMiddleware(testKeys)).Get("/tasks/stream", StreamTasks)
+	r.Route("/tasks", func(r chi.Router) {
+		r.Use(utils.AuthMiddleware(testKeys))
+
+		r.Get("/", ListTasks)
+		r.Post("/", CreateTask)
+		r.Post("/bulk", BulkTasks)
+		r.Get("/export", ExportTasks)
+		r.Post("/import", ImportTasks)
+		r.Get("/search", SearchTasks)
+		r.Get("/trash", ListTrash)
+		r.Delete("/trash", EmptyTrash)
+		r.Delete("/trash/{taskID}", PurgeTask)
+		r.Get("/{taskID}", GetTask)
+		r.Put("/{taskID}", UpdateTask)
+		r.Delete("/{taskID}", DeleteTask)
+		r.Patch("/{taskID}/complete", CompleteTask)
+		r.Post("/{taskID}/restore", RestoreTask)
+		r.Post("/{taskID}/move", RepositionTask)
+		r.Put("/{taskID}/labels/{labelID}", AttachTaskLabel)
+		r.Delete("/{taskID}/labels/{labelID}", DetachTaskLabel)
+		r.Put("/{taskID}/project", MoveTask)
+		r.Get("/{taskID}/subtasks", ListSubtasks)
+		r.Get("/{taskID}/history", ListTaskHistory)
+		r.Get("/{taskID}/comments", ListComments)
+		r.Post("/{taskID}/comments", CreateComment)
+		r.Put("/{taskID}/comments/{commentID}", UpdateComment)
+		r.Delete("/{taskID}/comments/{commentID}", DeleteComment)
+		r.Get("/{taskID}/attachments", ListAttachments)
+		r.Post("/{taskID}/attachments", UploadAttachment)
+		r.Get("/{taskID}/attachments/{attachmentID}", DownloadAttachment)
+		r.Delete("/{taskID}/attachments/{attachmentID}", DeleteAttachment)
+		r.Get("/{taskID}/reminders", ListReminders)
+		r.Post("/{taskID}/reminders", CreateReminder)
+		r.Delete("/{taskID}/reminders/{reminderID}", DeleteReminder)
+		r.Get("/{taskID}/checklist", ListChecklistItems)
+		r.Post("/{taskID}/checklist", CreateChecklistItem)
+		r.Put("/{taskID}/checklist/{itemID}", UpdateChecklistItem)
+		r.Delete("/{taskID}/checklist/{itemID}", DeleteChecklistItem)
+	})
+	r.Route("/labels", func(r chi.Router) {
+		r.Use(utils.AuthMiddleware(testKeys))
+
+		r.Get("/", ListLabels)
+		r.Post("/", CreateLabel)
+		r.Get("/{labelID}", GetLabel)
+		r.Put("/{labelID}", UpdateLabel)
+		r.Delete("/{labelID}", DeleteLabel)
+	})
+	r.Route("/projects", func(r chi.Router) {
+		r.Use(utils.AuthMiddleware(testKeys))
+
+		r.Get("/", ListProjects)
+		r.Post("/", CreateProject)
+		r.Get("/{projectID}", GetProject)
+		r.Put("/{projectID}", UpdateProject)
+		r.Delete("/{projectID}", DeleteProject)
+		r.Get("/{projectID}/tasks", ListProjectTasks)
+		r.Get("/{projectID}/workflow", GetProjectWorkflow)
+		r.Put("/{projectID}/workflow", ReplaceProjectWorkflow)
+	})
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(utils.AuthMiddleware(testKeys))
+
+		r.Get("/", ListWebhooks)
+		r.Post("/", CreateWebhook)
+		r.Get("/{webhookID}", GetWebhook)
+		r.Put("/{webhookID}", UpdateWebhook)
+		r.Delete("/{webhookID}", DeleteWebhook)
+		r.Get("/{webhookID}/deliveries", ListWebhookDeliveries)
+		r.Post("/{webhookID}/test", SendTestWebhook)
+	})
+	r.Route("/calendar", func(r chi.Router) {
+		r.Use(utils.AuthMiddleware(testKeys))
+
+		r.Get("/feed", GetCalendarFeed)
+		r.Post("/feed", CreateCalendarFeed)
+		r.Delete("/feed", DeleteCalendarFeed)
+	})
+	r.Get("/feeds/{token}.ics", ServeCalendarFeed)
+
 	return r
 }
 
+func setupMockDB(t *testing.T) sqlmock.Sqlmock {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create mock: %v", err)
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: sqlDB,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open gorm connection: %v", err)
+	}
+
+	database.DB = gormDB
+	return mock
+}
+
+// bearerToken signs an access token for userID the same way the auth-service does
+func bearerToken(t *testing.T, userID uuid.UUID) string {
+	claims := &utils.Claims{
+		UserID: userID.String(),
+		Email:  "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	unsigned.Header["kid"] = testKeyID
+	token, err := unsigned.SignedString(testSigningKey)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func newAuthedRequest(t *testing.T, method, target string, body []byte, userID uuid.UUID) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearerToken(t, userID))
+	return req
+}
+
 func TestCreateTask(t *testing.T) {
 	router := setupTestRouter()
 
 	t.Run("successful task creation", func(t *testing.T) {
+		mock := setupMockDB(t)
 		userID := uuid.New()
 		reqBody := CreateTaskRequest{
 			Title:  "Test Task",
 			Status: "todo",
 		}
 
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-User-Id", userID.String())
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(sqlmock.AnyArg(), userID, "created", nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), time.Now()))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
 
+		body, _ := json.Marshal(reqBody)
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks", body, userID))
 
 		assert.Equal(t, http.StatusCreated, rr.Code)
 
@@ -59,9 +214,30 @@ func TestCreateTask(t *testing.T) {
 		assert.Equal(t, "Test Task", response.Title)
 		assert.Equal(t, "todo", response.Status)
 		assert.Equal(t, userID, response.UserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("parent is itself a subtask", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		parentID := uuid.New()
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(parentID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(append(append([]string{}, taskColumns...), "parent_id")).AddRow(
+				parentID, userID, "Subtask", nil, "todo", nil, nil, nil, now, now, uuid.New(),
+			))
+
+		body, _ := json.Marshal(CreateTaskRequest{Title: "Nested", Status: "todo", ParentID: &parentID})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks", body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("missing user ID header", func(t *testing.T) {
+	t.Run("missing authorization header", func(t *testing.T) {
 		reqBody := CreateTaskRequest{
 			Title:  "Test Task",
 			Status: "todo",
@@ -77,88 +253,276 @@ func TestCreateTask(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
-	t.Run("invalid user ID", func(t *testing.T) {
-		reqBody := CreateTaskRequest{
-			Title:  "Test Task",
-			Status: "todo",
-		}
+	t.Run("token signed with wrong secret", func(t *testing.T) {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &utils.Claims{
+			UserID: uuid.New().String(),
+		}).SignedString([]byte("some-other-secret"))
 
-		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-User-Id", "invalid-uuid")
+		req := httptest.NewRequest("POST", "/tasks", bytes.NewBufferString(`{}`))
+		req.Header.Set("Authorization", "Bearer "+token)
 
 		rr := httptest.NewRecorder()
 		router.ServeHTTP(rr, req)
 
-		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		assert.Contains(t, rr.Body.String(), "Invalid User ID")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
 	})
 
 	t.Run("invalid request payload", func(t *testing.T) {
-		userID := uuid.New()
-		req := httptest.NewRequest("POST", "/tasks", bytes.NewBufferString("invalid json"))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-User-Id", userID.String())
-
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks", []byte("invalid json"), uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid request payload")
 	})
 
 	t.Run("validation error - missing title", func(t *testing.T) {
-		userID := uuid.New()
 		reqBody := CreateTaskRequest{
 			Status: "todo",
 		}
 
 		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-User-Id", userID.String())
-
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks", body, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "title field is required")
 	})
 
 	t.Run("validation error - invalid status", func(t *testing.T) {
-		userID := uuid.New()
 		reqBody := CreateTaskRequest{
 			Title:  "Test Task",
 			Status: "invalid-status",
 		}
 
 		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("POST", "/tasks", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("X-User-Id", userID.String())
-
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks", body, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "status must be one of")
 	})
 }
 
+func TestListTasks(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("only returns the caller's tasks", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		taskID := uuid.New()
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1`).
+			WithArgs(userID, database.DefaultTaskPageSize+1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+				taskID, userID, "Mine", nil, "todo", nil, nil, nil, now, now,
+			))
+		expectTaskEnrichment(mock, sqlmock.NewRows(labelColumns).AddRow(
+			uuid.New(), userID, "bug", "#d73a4a", now, now, taskID,
+		))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks", nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response ListTasksResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, userID, response.Data[0].UserID)
+		assert.Len(t, response.Data[0].Labels, 1)
+		assert.Equal(t, "bug", response.Data[0].Labels[0].Name)
+		assert.Empty(t, response.NextCursor)
+		assert.Equal(t, database.DefaultTaskPageSize, response.Limit)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("passes filters and sort to the query", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+
+		mock.ExpectQuery(`WHERE user_id = \$1 AND status IN \(\$2\) AND completed_at IS NOT NULL AND "tasks"."deleted_at" IS NULL ORDER BY COALESCE\(completed_at, '-infinity'::timestamp\) DESC`).
+			WithArgs(userID, "done", 6).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks?status=done&completed=true&sort=completed_at&order=desc&limit=5", nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		setupMockDB(t)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks?cursor=garbage!", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "invalid cursor")
+	})
+}
+
+func TestSearchTasks(t *testing.T) {
+	router := setupTestRouter()
+
+	t.Run("combines search with filters", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+
+		mock.ExpectQuery(`to_tsquery\('english', \$1\) AS query WHERE user_id = \$2 AND tasks.deleted_at IS NULL AND search_vector @@ query AND priority IN \(\$3\)`).
+			WithArgs("deploy:*", userID, "high", 10, 10).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/search?q=deploy&priority=high&limit=10&offset=10", nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response SearchTasksResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "deploy", response.Query)
+		assert.Equal(t, 10, response.Offset)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing query", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/search", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("query without searchable terms", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/search?q=%26%7C", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("invalid offset", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/search?q=x&offset=-1", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestParseTaskFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+		check   func(t *testing.T, f database.TaskFilter)
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.Equal(t, database.DefaultTaskSort, f.SortBy)
+				assert.False(t, f.SortDesc)
+				assert.Equal(t, database.DefaultTaskPageSize, f.Limit)
+			},
+		},
+		{
+			name:  "comma separated and repeated lists",
+			query: "status=todo,done&status=in-review&priority=high",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.Equal(t, []string{"todo", "done", "in-review"}, f.Statuses)
+				assert.Equal(t, []string{"high"}, f.Priorities)
+			},
+		},
+		{
+			name:  "date ranges",
+			query: "due_after=2025-01-01&due_before=2025-02-01T00:00:00Z&updated_after=2025-01-15",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *f.DueAfter)
+				assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), *f.DueBefore)
+				assert.NotNil(t, f.UpdatedAfter)
+				assert.Nil(t, f.CreatedAfter)
+			},
+		},
+		{
+			name:  "label filter",
+			query: "labels=6f1c1f4e-8b4a-4a41-9d0c-1b2c3d4e5f60,0b7a1d2c-3e4f-4a5b-8c6d-7e8f9a0b1c2d&label_match=all",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.Len(t, f.LabelIDs, 2)
+				assert.True(t, f.LabelMatchAll)
+			},
+		},
+		{
+			name:  "tasks outside any project",
+			query: "project_id=none",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.True(t, f.NoProject)
+				assert.Nil(t, f.ProjectID)
+			},
+		},
+		{
+			name:  "limit is capped",
+			query: "limit=100000",
+			check: func(t *testing.T, f database.TaskFilter) {
+				assert.Equal(t, database.MaxTaskPageSize, f.Limit)
+			},
+		},
+		{name: "invalid status", query: "status=In%20Review", wantErr: "invalid status"},
+		{name: "invalid priority", query: "priority=urgent", wantErr: "priority must be one of"},
+		{name: "invalid date", query: "created_before=yesterday", wantErr: "created_before must be"},
+		{name: "invalid completed", query: "completed=maybe", wantErr: "completed must be"},
+		{name: "invalid sort", query: "sort=password", wantErr: "invalid sort field"},
+		{name: "invalid order", query: "order=sideways", wantErr: "order must be"},
+		{name: "invalid limit", query: "limit=0", wantErr: "limit must be"},
+		{name: "invalid label ID", query: "labels=bug", wantErr: "labels must be"},
+		{name: "invalid label match", query: "label_match=some", wantErr: "label_match must be"},
+		{name: "invalid project ID", query: "project_id=acme", wantErr: "project_id must be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			filter, err := parseTaskFilter(query)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, filter)
+		})
+	}
+}
+
 func TestGetTask(t *testing.T) {
 	router := setupTestRouter()
 
 	t.Run("successful task retrieval", func(t *testing.T) {
-		// This test would need a real database or more sophisticated mocking
-		// For now, we'll test the error cases
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		taskID := uuid.New()
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(
+				taskID, userID, "Test Task", nil, "todo", nil, nil, nil, now, now,
+			))
+		expectTaskEnrichment(mock, nil)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/"+taskID.String(), nil, userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response GetTaskResponse
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, taskID, response.ID)
+		assert.NotNil(t, response.Labels)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("invalid task ID", func(t *testing.T) {
-		req := httptest.NewRequest("GET", "/tasks/invalid-uuid", nil)
 		rr := httptest.NewRecorder()
-
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/invalid-uuid", nil, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid task ID")
@@ -174,11 +538,8 @@ func TestUpdateTask(t *testing.T) {
 		}
 
 		body, _ := json.Marshal(reqBody)
-		req := httptest.NewRequest("PUT", "/tasks/invalid-uuid", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
-
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/invalid-uuid", body, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid task ID")
@@ -186,11 +547,8 @@ func TestUpdateTask(t *testing.T) {
 
 	t.Run("invalid request payload", func(t *testing.T) {
 		taskID := uuid.New()
-		req := httptest.NewRequest("PUT", "/tasks/"+taskID.String(), bytes.NewBufferString("invalid json"))
-		req.Header.Set("Content-Type", "application/json")
-
 		rr := httptest.NewRecorder()
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), []byte("invalid json"), uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid request payload")
@@ -201,10 +559,8 @@ func TestDeleteTask(t *testing.T) {
 	router := setupTestRouter()
 
 	t.Run("invalid task ID", func(t *testing.T) {
-		req := httptest.NewRequest("DELETE", "/tasks/invalid-uuid", nil)
 		rr := httptest.NewRecorder()
-
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/invalid-uuid", nil, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid task ID")
@@ -215,21 +571,235 @@ func TestCompleteTask(t *testing.T) {
 	router := setupTestRouter()
 
 	t.Run("invalid task ID", func(t *testing.T) {
-		req := httptest.NewRequest("PATCH", "/tasks/invalid-uuid/complete", nil)
 		rr := httptest.NewRecorder()
-
-		router.ServeHTTP(rr, req)
+		router.ServeHTTP(rr, newAuthedRequest(t, "PATCH", "/tasks/invalid-uuid/complete", nil, uuid.New()))
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 		assert.Contains(t, rr.Body.String(), "Invalid task ID")
 	})
+
+	t.Run("invalid subtask policy", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PATCH", "/tasks/"+uuid.New().String()+"/complete?subtasks=ignore", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("open subtasks", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		taskID := uuid.New()
+		now := time.Now()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2 AND completed_at IS NULL\) AND "tasks"."deleted_at" IS NULL`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(uuid.New(), userID, "Subtask", nil, "todo", nil, nil, nil, now, now))
+		mock.ExpectRollback()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PATCH", "/tasks/"+taskID.String()+"/complete", nil, userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "open subtasks")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("returns the next occurrence of a recurring task", func(t *testing.T) {
+		mock := setupMockDB(t)
+		userID := uuid.New()
+		taskID := uuid.New()
+		nextID := uuid.New()
+		now := time.Now()
+		due := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+		columns := append(append([]string{}, taskColumns...), "recurrence", "occurrence")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Stand-up", nil, "todo", nil, due, nil, now, now, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(user_id = \$1 AND parent_id = \$2`).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectExec(`UPDATE "tasks"."tasks"`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Stand-up", nil, "done", nil, due, now, now, now, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", 1))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectQuery(`INSERT INTO "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(nextID))
+		mock.ExpectExec(`INSERT INTO tasks.task_labels`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO tasks.reminders`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PATCH", "/tasks/"+taskID.String()+"/complete", nil, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp CompleteTaskResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, taskID, resp.ID)
+		assert.Equal(t, "done", resp.Status)
+		require.NotNil(t, resp.NextOccurrence)
+		assert.Equal(t, nextID, resp.NextOccurrence.ID)
+		assert.Equal(t, 2, resp.NextOccurrence.Occurrence)
+		assert.True(t, due.AddDate(0, 0, 1).Equal(*resp.NextOccurrence.DueDate))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateTaskRecurrence(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+
+	t.Run("rejects an invalid rule", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, now, nil, now, now))
+
+		body, _ := json.Marshal(map[string]string{"recurrence": "FREQ=SECONDLY"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "FREQ must be one of")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("requires a due date", func(t *testing.T) {
+		mock := setupMockDB(t)
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+
+		body, _ := json.Marshal(map[string]string{"recurrence": "FREQ=DAILY"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "recurring tasks require a due date")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects an unknown timezone", func(t *testing.T) {
+		setupMockDB(t)
+
+		body, _ := json.Marshal(map[string]string{"recurrence_timezone": "Nowhere/Special"})
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+// TestCrossUserAccess verifies that a task owned by one user is invisible to every
+// other user on every route: the repository is always queried with the caller's ID
+// and a miss is reported as 404, never 403, so task IDs cannot be probed.
+func TestCrossUserAccess(t *testing.T) {
+	router := setupTestRouter()
+	taskID := uuid.New()
+	intruderID := uuid.New()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   []byte
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:   "get",
+			method: "GET",
+			path:   "/tasks/" + taskID.String(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+					WithArgs(taskID, intruderID, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumns))
+			},
+		},
+		{
+			name:   "history",
+			method: "GET",
+			path:   "/tasks/" + taskID.String() + "/history",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+					WithArgs(taskID, intruderID, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumns))
+			},
+		},
+		{
+			name:   "update",
+			method: "PUT",
+			path:   "/tasks/" + taskID.String(),
+			body:   []byte(`{"title":"pwned"}`),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+					WithArgs(taskID, intruderID, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumns))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:   "delete",
+			method: "DELETE",
+			path:   "/tasks/" + taskID.String(),
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+					WithArgs(taskID, intruderID, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumns))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:   "complete",
+			method: "PATCH",
+			path:   "/tasks/" + taskID.String() + "/complete",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+					WithArgs(taskID, intruderID, 1).
+					WillReturnRows(sqlmock.NewRows(taskColumns))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := setupMockDB(t)
+			tt.expect(mock)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, newAuthedRequest(t, tt.method, tt.path, tt.body, intruderID))
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.Contains(t, rr.Body.String(), "Task not found")
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run(tt.name+" without token", func(t *testing.T) {
+			mock := setupMockDB(t)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBuffer(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnauthorized, rr.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
 }
 
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
 }
-
-func timePtr(t time.Time) *time.Time {
-	return &t
-}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/storage"
+)
+
+// writeTrashError maps repository errors to HTTP responses
+func writeTrashError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrTaskNotFound):
+		http.Error(w, "Task not found in trash", http.StatusNotFound)
+	case errors.Is(err, database.ErrParentTrashed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// ListTrash returns the caller's deleted tasks, most recently deleted first
+func ListTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := database.GetTrash(userID)
+	if err != nil {
+		writeTrashError(w, err, "Failed to fetch trash")
+		return
+	}
+
+	resp := make([]GetTaskResponse, len(tasks))
+	for i, task := range tasks {
+		resp[i] = newGetTaskResponse(task)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RestoreTask takes a task, and the subtasks deleted with it, out of the trash
+func RestoreTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	task, err := database.RestoreTask(userID, taskID)
+	if err != nil {
+		writeTrashError(w, err, "Failed to restore task")
+		return
+	}
+
+	resp := newGetTaskResponse(*task)
+	if err := enrichTaskResponses(userID, []*GetTaskResponse{&resp}); err != nil {
+		http.Error(w, "Failed to fetch task details", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PurgeTask permanently deletes a trashed task and its attachments
+func PurgeTask(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	taskID, ok := parseURLUUID(w, r, "taskID", "task")
+	if !ok {
+		return
+	}
+
+	keys, err := database.PurgeTask(userID, taskID)
+	if err != nil {
+		writeTrashError(w, err, "Failed to purge task")
+		return
+	}
+
+	storage.DeleteAll(r.Context(), keys)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EmptyTrash permanently deletes every task in the caller's trash
+func EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := database.EmptyTrash(userID)
+	if err != nil {
+		writeTrashError(w, err, "Failed to empty trash")
+		return
+	}
+
+	storage.DeleteAll(r.Context(), keys)
+
+	w.WriteHeader(http.StatusNoContent)
+}
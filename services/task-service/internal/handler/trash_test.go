@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListTrash(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	now := time.Now()
+	deletedAt := now.Add(-time.Hour).UTC().Truncate(time.Second)
+
+	mock := setupMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND deleted_at IS NOT NULL`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(append(append([]string{}, taskColumns...), "deleted_at")).
+			AddRow(taskID, userID, "Trashed", nil, "todo", nil, nil, nil, now, now, deletedAt))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/tasks/trash", nil, userID))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var tasks []GetTaskResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 1)
+	assert.Equal(t, taskID, tasks[0].ID)
+	require.NotNil(t, tasks[0].DeletedAt)
+	assert.True(t, deletedAt.Equal(*tasks[0].DeletedAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreTask(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	now := time.Now()
+
+	t.Run("parent still in the trash", func(t *testing.T) {
+		mock := setupMockDB(t)
+		taskID := uuid.New()
+		parentID := uuid.New()
+		columns := append(append([]string{}, taskColumns...), "deleted_at")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(append(columns, "parent_id")).AddRow(taskID, userID, "Subtask", nil, "todo", nil, nil, nil, now, now, now, parentID))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(parentID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(parentID, userID, "Parent", nil, "todo", nil, nil, nil, now, now, now))
+		mock.ExpectRollback()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/restore", nil, userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("task not in the trash", func(t *testing.T) {
+		mock := setupMockDB(t)
+		taskID := uuid.New()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE id = \$1 AND \(user_id = \$2 AND deleted_at IS NOT NULL\)`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(taskColumns))
+		mock.ExpectRollback()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/tasks/"+taskID.String()+"/restore", nil, userID))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEmptyTrash(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+
+	mock := setupMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE user_id = \$1 AND deleted_at IS NOT NULL`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(taskColumns))
+	mock.ExpectCommit()
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "DELETE", "/tasks/trash", nil, userID))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
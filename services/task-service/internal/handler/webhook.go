@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/webhook"
+)
+
+type CreateWebhookRequest struct {
+	URL string `json:"url"`
+	// Secret signs deliveries; one is generated if it is left out
+	Secret *string  `json:"secret,omitempty"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url,omitempty"`
+	Secret *string  `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// CreateWebhookResponse is the only response that includes the secret
+type CreateWebhookResponse struct {
+	model.Webhook
+	Secret string `json:"secret"`
+}
+
+// writeWebhookError maps repository errors to HTTP responses
+func writeWebhookError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, database.ErrWebhookNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+	default:
+		http.Error(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+func ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhooks, err := database.GetWebhooks(userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch webhooks", http.StatusInternalServerError)
+		return
+	}
+
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	hook := model.Webhook{
+		UserID: userID,
+		URL:    strings.TrimSpace(req.URL),
+		Secret: webhook.NewSecret(),
+		Events: req.Events,
+		Active: true,
+	}
+	if req.Secret != nil {
+		hook.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if err := hook.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.CreateWebhook(&hook); err != nil {
+		writeWebhookError(w, err, "Failed to create webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateWebhookResponse{Webhook: hook, Secret: hook.Secret})
+}
+
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := parseURLUUID(w, r, "webhookID", "webhook")
+	if !ok {
+		return
+	}
+
+	hook, err := database.GetWebhook(userID, webhookID)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to fetch webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hook)
+}
+
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := parseURLUUID(w, r, "webhookID", "webhook")
+	if !ok {
+		return
+	}
+
+	var req UpdateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	current, err := database.GetWebhook(userID, webhookID)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to fetch webhook")
+		return
+	}
+
+	// Validate the webhook as it will look after the update
+	updates := map[string]interface{}{}
+	if req.URL != nil {
+		current.URL = strings.TrimSpace(*req.URL)
+		updates["url"] = current.URL
+	}
+	if req.Secret != nil {
+		current.Secret = *req.Secret
+		updates["secret"] = current.Secret
+	}
+	if req.Events != nil {
+		current.Events = req.Events
+		updates["events"] = current.Events
+	}
+	if req.Active != nil {
+		current.Active = *req.Active
+		updates["active"] = current.Active
+	}
+
+	if err := current.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook := current
+	if len(updates) > 0 {
+		hook, err = database.UpdateWebhook(userID, webhookID, updates)
+		if err != nil {
+			writeWebhookError(w, err, "Failed to update webhook")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hook)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := parseURLUUID(w, r, "webhookID", "webhook")
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteWebhook(userID, webhookID); err != nil {
+		writeWebhookError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries returns a webhook's most recent deliveries, newest
+// first, as its delivery log
+func ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := parseURLUUID(w, r, "webhookID", "webhook")
+	if !ok {
+		return
+	}
+
+	limit := database.DefaultWebhookDeliveryPageSize
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, database.MaxWebhookDeliveryPageSize)
+	}
+
+	deliveries, err := database.GetWebhookDeliveries(userID, webhookID, limit)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []model.WebhookDelivery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// SendTestWebhook queues a webhook.test event for the webhook. The event is
+// sent by the dispatcher like any other and shows up in the delivery log.
+func SendTestWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	webhookID, ok := parseURLUUID(w, r, "webhookID", "webhook")
+	if !ok {
+		return
+	}
+
+	delivery, err := database.QueueTestEvent(userID, webhookID)
+	if err != nil {
+		writeWebhookError(w, err, "Failed to queue test event")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
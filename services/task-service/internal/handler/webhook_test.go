@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var webhookColumns = []string{"id", "user_id", "url", "secret", "events", "active", "created_at", "updated_at"}
+
+// expectNoWebhooks expects the webhook lookup that follows recording task
+// events, for a user without webhooks
+func expectNoWebhooks(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks" WHERE user_id IN \(.+\) AND active`).
+		WillReturnRows(sqlmock.NewRows(webhookColumns))
+}
+
+func TestCreateWebhook(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+
+	t.Run("generates a secret and shows it once", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."webhooks"`).
+			WithArgs(userID, "https://example.com/hook", sqlmock.AnyArg(), "task.created,task.completed", true).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(uuid.New(), time.Now(), time.Now()))
+		mock.ExpectCommit()
+
+		body := []byte(`{"url":" https://example.com/hook ","events":["task.created","task.completed"]}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/webhooks", body, userID))
+
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "https://example.com/hook", resp["url"])
+		assert.Regexp(t, `^whsec_[0-9a-f]{48}$`, resp["secret"])
+		assert.Equal(t, []interface{}{"task.created", "task.completed"}, resp["events"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid webhooks", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+			want string
+		}{
+			{"missing url", `{"events":["task.created"]}`, "url field is required"},
+			{"not http", `{"url":"ftp://example.com","events":["task.created"]}`, "url must be an absolute http or https URL"},
+			{"no events", `{"url":"https://example.com/hook","events":[]}`, "events must list at least one event type"},
+			{"unknown event", `{"url":"https://example.com/hook","events":["task.exploded"]}`, `unknown event type "task.exploded"`},
+			{"short secret", `{"url":"https://example.com/hook","events":["task.created"],"secret":"hunter2"}`, "secret must be at least 16 characters"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/webhooks", []byte(tt.body), userID))
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				assert.Contains(t, rr.Body.String(), tt.want)
+			})
+		}
+	})
+}
+
+func TestGetWebhookHidesSecret(t *testing.T) {
+	router := setupTestRouter()
+	mock := setupMockDB(t)
+	userID := uuid.New()
+	webhookID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks" WHERE id = \$1 AND user_id = \$2`).
+		WithArgs(webhookID, userID, 1).
+		WillReturnRows(sqlmock.NewRows(webhookColumns).
+			AddRow(webhookID, userID, "https://example.com/hook", "whsec_topsecretvalue", "task.created", true, now, now))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/webhooks/"+webhookID.String(), nil, userID))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "whsec_topsecretvalue")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	webhookID := uuid.New()
+	now := time.Now()
+
+	t.Run("returns the delivery log", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).
+				AddRow(webhookID, userID, "https://example.com/hook", "0123456789abcdef", "task.created", true, now, now))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhook_deliveries" WHERE webhook_id = \$1 ORDER BY created_at DESC, id LIMIT \$2`).
+			WithArgs(webhookID, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_type", "payload", "status", "attempts", "response_status"}).
+				AddRow(uuid.New(), webhookID, "task.created", `{"type":"task.created"}`, "succeeded", 1, 204))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/webhooks/"+webhookID.String()+"/deliveries?limit=10", nil, userID))
+
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var deliveries []map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &deliveries))
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, map[string]interface{}{"type": "task.created"}, deliveries[0]["payload"])
+		assert.Equal(t, float64(204), deliveries[0]["response_status"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "GET", "/webhooks/"+webhookID.String()+"/deliveries?limit=0", nil, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestSendTestWebhook(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	webhookID := uuid.New()
+
+	t.Run("queues a test event", func(t *testing.T) {
+		mock := setupMockDB(t)
+		now := time.Now()
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns).
+				AddRow(webhookID, userID, "https://example.com/hook", "0123456789abcdef", "task.created", true, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "tasks"."webhook_deliveries"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at", "updated_at"}).AddRow(uuid.New(), now, now, now))
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/webhooks/"+webhookID.String()+"/test", nil, userID))
+
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"event_type":"webhook.test"`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("webhook owned by another user", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."webhooks"`).
+			WillReturnRows(sqlmock.NewRows(webhookColumns))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "POST", "/webhooks/"+webhookID.String()+"/test", nil, uuid.New()))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "Webhook not found")
+	})
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// ReplaceWorkflowRequest is the complete new workflow of a project. Remap
+// names, for every status being dropped that tasks still use, the status to move them to.
+type ReplaceWorkflowRequest struct {
+	Statuses    []model.WorkflowStatus     `json:"statuses"`
+	Transitions []model.WorkflowTransition `json:"transitions"`
+	Remap       map[string]string          `json:"remap"`
+}
+
+func GetProjectWorkflow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	wf, err := database.GetWorkflow(userID, &projectID)
+	if err != nil {
+		writeProjectError(w, err, "Failed to fetch workflow")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(wf)
+}
+
+func ReplaceProjectWorkflow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authenticatedUserID(w, r)
+	if !ok {
+		return
+	}
+
+	projectID, ok := parseURLUUID(w, r, "projectID", "project")
+	if !ok {
+		return
+	}
+
+	var req ReplaceWorkflowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	wf := model.Workflow{Statuses: req.Statuses, Transitions: req.Transitions}
+	for i := range wf.Statuses {
+		wf.Statuses[i].Name = strings.TrimSpace(wf.Statuses[i].Name)
+	}
+	if wf.Transitions == nil {
+		wf.Transitions = []model.WorkflowTransition{}
+	}
+
+	if err := wf.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := database.ReplaceWorkflow(userID, projectID, wf, req.Remap)
+	if err != nil {
+		if errors.Is(err, database.ErrStatusInUse) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeProjectError(w, err, "Failed to save workflow")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(saved)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var workflowStatusColumns = []string{"id", "project_id", "key", "name", "category", "position"}
+
+func TestReplaceProjectWorkflow(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	projectID := uuid.New()
+	path := "/projects/" + projectID.String() + "/workflow"
+	now := time.Now()
+
+	t.Run("workflow without a done status", func(t *testing.T) {
+		body := []byte(`{"statuses":[{"key":"open","name":"Open","category":"not-started"}]}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", path, body, userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "workflow must have a done status")
+	})
+
+	t.Run("dropping a status still in use", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."projects"`).
+			WillReturnRows(sqlmock.NewRows(projectColumns).AddRow(projectID, userID, "Acme", nil, "#3b82f6", false, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT DISTINCT "status" FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("in-progress"))
+		mock.ExpectRollback()
+
+		body := []byte(`{"statuses":[
+			{"key":"open","name":"Open","category":"not-started"},
+			{"key":"closed","name":"Closed","category":"done"}
+		]}`)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", path, body, userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "in-progress")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUpdateTaskStatus(t *testing.T) {
+	router := setupTestRouter()
+	userID := uuid.New()
+	taskID := uuid.New()
+	projectID := uuid.New()
+	now := time.Now()
+	columns := append(append([]string{}, taskColumns...), "project_id")
+
+	t.Run("transition not allowed by the project workflow", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks" WHERE \(id = \$1 AND user_id = \$2\) AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(taskID, userID, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(taskID, userID, "Task", nil, "backlog", nil, nil, nil, now, now, projectID))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_statuses"`).
+			WillReturnRows(sqlmock.NewRows(workflowStatusColumns).
+				AddRow(uuid.New(), projectID, "backlog", "Backlog", "not-started", 0).
+				AddRow(uuid.New(), projectID, "in-review", "In Review", "active", 1).
+				AddRow(uuid.New(), projectID, "shipped", "Shipped", "done", 2))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."workflow_transitions"`).
+			WillReturnRows(sqlmock.NewRows([]string{"project_id", "from_status", "to_status"}).
+				AddRow(projectID, "backlog", "in-review").
+				AddRow(projectID, "in-review", "shipped"))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), []byte(`{"status":"shipped"}`), userID))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "backlog -> shipped")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unknown status for a task outside any project", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "todo", nil, nil, nil, now, now))
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), []byte(`{"status":"shipped"}`), userID))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "status must be one of: todo, in-progress, done")
+	})
+
+	t.Run("moving to done sets completed_at", func(t *testing.T) {
+		mock := setupMockDB(t)
+
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "in-progress", nil, nil, nil, now, now))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "in-progress", nil, nil, nil, now, now))
+		mock.ExpectExec(`UPDATE "tasks"."tasks" SET "completed_at"=\$1,"status"=\$2,"updated_at"=\$3`).
+			WithArgs(sqlmock.AnyArg(), "done", sqlmock.AnyArg(), taskID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`SELECT \* FROM "tasks"."tasks"`).
+			WillReturnRows(sqlmock.NewRows(taskColumns).AddRow(taskID, userID, "Task", nil, "done", nil, nil, now, now, now))
+		mock.ExpectQuery(`INSERT INTO "tasks"."task_events"`).
+			WithArgs(
+				taskID, userID, "updated", "status", "in-progress", "done",
+				taskID, userID, "updated", "completed_at", nil, sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New(), now).AddRow(uuid.New(), now))
+		expectNoWebhooks(mock)
+		mock.ExpectCommit()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, newAuthedRequest(t, "PUT", "/tasks/"+taskID.String(), []byte(`{"status":"done"}`), userID))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,99 @@
+// Package ical writes RFC 5545 iCalendar documents.
+//
+// A document is a sequence of content lines, NAME:value, nested between
+// BEGIN and END lines for each component:
+//
+//	cw := ical.NewWriter(w)
+//	cw.Begin("VCALENDAR")
+//	cw.Raw("VERSION", "2.0")
+//	cw.Text("SUMMARY", "Ship it, today; really")
+//	cw.End("VCALENDAR")
+//	err := cw.Flush()
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest a content line may be before it is folded
+const maxLineOctets = 75
+
+// dateTimeFormat is a DATE-TIME in UTC
+const dateTimeFormat = "20060102T150405Z"
+
+// Writer writes content lines, ending them with CRLF and folding long ones.
+// Write errors are kept until Flush.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Begin opens a component such as VCALENDAR or VTODO
+func (cw *Writer) Begin(component string) {
+	cw.line("BEGIN:" + component)
+}
+
+// End closes a component opened with Begin
+func (cw *Writer) End(component string) {
+	cw.line("END:" + component)
+}
+
+// Raw writes a property whose value is already valid iCalendar, such as a
+// number or an enumerated value
+func (cw *Writer) Raw(name, value string) {
+	cw.line(name + ":" + value)
+}
+
+// Text writes a TEXT property, escaping value
+func (cw *Writer) Text(name, value string) {
+	cw.line(name + ":" + EscapeText(value))
+}
+
+// Time writes a DATE-TIME property in UTC
+func (cw *Writer) Time(name string, t time.Time) {
+	cw.line(name + ":" + t.UTC().Format(dateTimeFormat))
+}
+
+// Flush writes any buffered lines and returns the first write error
+func (cw *Writer) Flush() error {
+	return cw.w.Flush()
+}
+
+// line writes a content line, folding it after every 75 octets without
+// splitting a UTF-8 sequence. Continuation lines start with a space.
+func (cw *Writer) line(s string) {
+	width := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		if width+size > maxLineOctets {
+			cw.w.WriteString("\r\n ")
+			width = 1
+		}
+		cw.w.WriteString(s[:size])
+		width += size
+		s = s[size:]
+	}
+	cw.w.WriteString("\r\n")
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// EscapeText escapes a TEXT value: backslashes, semicolons and commas are
+// escaped and line breaks become \n
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
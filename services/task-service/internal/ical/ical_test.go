@@ -0,0 +1,67 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a, b; c", `a\, b\; c`},
+		{`C:\tmp`, `C:\\tmp`},
+		{"one\ntwo\r\nthree", `one\ntwo\nthree`},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeText(tt.in); got != tt.want {
+			t.Errorf("EscapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var sb strings.Builder
+	cw := NewWriter(&sb)
+	cw.Begin("VCALENDAR")
+	cw.Raw("VERSION", "2.0")
+	cw.Text("SUMMARY", "Call Bob, then lunch")
+	cw.Time("DUE", time.Date(2026, 5, 1, 11, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+	cw.End("VCALENDAR")
+	require.NoError(t, cw.Flush())
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"SUMMARY:Call Bob\\, then lunch\r\n"+
+		"DUE:20260501T090000Z\r\n"+
+		"END:VCALENDAR\r\n", sb.String())
+}
+
+func TestWriterFoldsLongLines(t *testing.T) {
+	var sb strings.Builder
+	cw := NewWriter(&sb)
+	summary := strings.Repeat("é", 100)
+	cw.Text("SUMMARY", summary)
+	require.NoError(t, cw.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n")
+	require.Greater(t, len(lines), 1)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), 75, "line %d is too long", i)
+		assert.True(t, utf8.ValidString(line), "line %d splits a character", i)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "), "continuation line %d must start with a space", i)
+		}
+	}
+
+	unfolded := strings.ReplaceAll(sb.String(), "\r\n ", "")
+	assert.Equal(t, "SUMMARY:"+summary+"\r\n", unfolded)
+}
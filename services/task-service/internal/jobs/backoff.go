@@ -0,0 +1,13 @@
+package jobs
+
+import "time"
+
+// retryBackoff is how long to wait after the given number of failed
+// attempts: base after the first, doubling each time up to ceiling
+func retryBackoff(attempts int, base, ceiling time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < ceiling; i++ {
+		backoff *= 2
+	}
+	return min(backoff, ceiling)
+}
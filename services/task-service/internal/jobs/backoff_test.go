@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{7, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, retryBackoff(tt.attempts, time.Minute, time.Hour), "attempts=%d", tt.attempts)
+	}
+}
@@ -0,0 +1,62 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+)
+
+// DefaultMaxRankLength is how long a task's rank may grow, through repeated
+// moves to the same spot, before its board column is respread
+const DefaultMaxRankLength = 16
+
+// RankRebalancer keeps board ranks short by respreading every column that
+// holds a rank longer than MaxLength. Respreading keeps each column's order,
+// so several instances may run it.
+type RankRebalancer struct {
+	MaxLength int
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Run rebalances once immediately and then every Interval until ctx is done
+func (b RankRebalancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+
+	for {
+		if columns, err := b.RebalanceOnce(ctx); err != nil {
+			log.Printf("Rank rebalance failed: %v", err)
+		} else if columns > 0 {
+			log.Printf("Rebalanced the ranks of %d board columns", columns)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RebalanceOnce respreads, in batches, every column with a rank longer than
+// MaxLength. It returns the number of columns respread.
+func (b RankRebalancer) RebalanceOnce(ctx context.Context) (int, error) {
+	total := 0
+
+	for ctx.Err() == nil {
+		count, err := database.RebalanceTaskRanks(b.MaxLength, b.BatchSize)
+		total += count
+		if err != nil {
+			return total, err
+		}
+
+		if count < b.BatchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/notify"
+)
+
+// ReminderScheduler sends due-date reminders through Notifier. Every replica
+// may run one: reminders are leased to Owner while they are being sent, so
+// each fires once.
+type ReminderScheduler struct {
+	Notifier notify.Notifier
+	// Owner identifies this replica in reminder leases and must be unique
+	Owner    string
+	Interval time.Duration
+	// Lease is how long a claimed reminder is reserved for this replica; one
+	// that is not marked sent or failed in time is picked up again
+	Lease time.Duration
+	// StaleAfter skips reminders for tasks that were due longer ago than
+	// this, so a scheduler that was down does not send a flood of old ones
+	StaleAfter  time.Duration
+	BatchSize   int
+	MaxAttempts int
+}
+
+// Run dispatches once immediately and then every Interval until ctx is done
+func (s ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := s.DispatchOnce(ctx, time.Now()); err != nil {
+			log.Printf("Reminder dispatch failed: %v", err)
+		} else if sent > 0 {
+			log.Printf("Sent %d task reminders", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims and sends, in batches, every reminder due at now. It
+// returns the number of reminders sent.
+func (s ReminderScheduler) DispatchOnce(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+
+	for ctx.Err() == nil {
+		due, err := database.ClaimDueReminders(s.Owner, now, s.Lease, s.StaleAfter, s.BatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, reminder := range due {
+			if s.send(ctx, reminder, now) {
+				sent++
+			}
+		}
+
+		if len(due) < s.BatchSize {
+			break
+		}
+	}
+
+	return sent, nil
+}
+
+// send delivers one claimed reminder and records the outcome
+func (s ReminderScheduler) send(ctx context.Context, reminder database.DueReminder, now time.Time) bool {
+	sendCtx, cancel := context.WithTimeout(ctx, s.Lease)
+	defer cancel()
+
+	if err := s.Notifier.Send(sendCtx, reminderMessage(reminder, now)); err != nil {
+		var retryAt *time.Time
+		if reminder.Attempts < s.MaxAttempts {
+			at := now.Add(retryBackoff(reminder.Attempts, time.Minute, time.Hour))
+			retryAt = &at
+		} else {
+			log.Printf("Giving up on reminder %s after %d attempts: %v", reminder.ID, reminder.Attempts, err)
+		}
+
+		if err := database.MarkReminderFailed(reminder.ID, s.Owner, reminder.DueDate, err, retryAt); err != nil {
+			log.Printf("Failed to record reminder %s failure: %v", reminder.ID, err)
+		}
+		return false
+	}
+
+	if err := database.MarkReminderSent(reminder.ID, s.Owner, reminder.DueDate, now); err != nil {
+		// The lease runs out and the reminder may be sent again
+		log.Printf("Failed to record reminder %s as sent: %v", reminder.ID, err)
+	}
+	return true
+}
+
+func reminderMessage(reminder database.DueReminder, now time.Time) notify.Message {
+	when := "now"
+	if left := reminder.DueDate.Sub(now).Round(time.Minute); left > 0 {
+		when = "in " + humanizeDuration(left)
+	}
+
+	return notify.Message{
+		To:      reminder.Email,
+		Subject: fmt.Sprintf("Reminder: %q is due %s", reminder.TaskTitle, when),
+		Body: fmt.Sprintf("Your task %q is due %s (%s).\n",
+			reminder.TaskTitle, when, reminder.DueDate.UTC().Format("Mon, 02 Jan 2006 15:04 MST")),
+	}
+}
+
+// humanizeDuration renders d in its largest whole unit, e.g. "2 days"
+func humanizeDuration(d time.Duration) string {
+	unit := func(n int, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+
+	switch {
+	case d >= 24*time.Hour:
+		return unit(int(d/(24*time.Hour)), "day")
+	case d >= time.Hour:
+		return unit(int(d/time.Hour), "hour")
+	default:
+		return unit(int(d/time.Minute), "minute")
+	}
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/notify"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var claimedColumns = []string{"id", "task_id", "user_id", "email", "offset_minutes", "attempts", "task_title", "due_date"}
+
+func setupMockDB(t *testing.T) sqlmock.Sqlmock {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+
+	database.DB = gormDB
+	return mock
+}
+
+// fakeNotifier records messages and fails for addresses in fail
+type fakeNotifier struct {
+	sent []notify.Message
+	fail map[string]bool
+}
+
+func (n *fakeNotifier) Send(_ context.Context, msg notify.Message) error {
+	if n.fail[msg.To] {
+		return errors.New("mailbox unavailable")
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestReminderSchedulerDispatchOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	due := now.Add(2 * time.Hour)
+	scheduler := ReminderScheduler{
+		Owner:       "replica-1",
+		Lease:       5 * time.Minute,
+		StaleAfter:  24 * time.Hour,
+		BatchSize:   10,
+		MaxAttempts: 3,
+	}
+
+	t.Run("sends and records due reminders", func(t *testing.T) {
+		mock := setupMockDB(t)
+		notifier := &fakeNotifier{}
+		scheduler.Notifier = notifier
+		reminderID := uuid.New()
+
+		mock.ExpectQuery(`UPDATE tasks.reminders AS r`).
+			WillReturnRows(sqlmock.NewRows(claimedColumns).
+				AddRow(reminderID, uuid.New(), uuid.New(), "user@example.com", 120, 1, "Ship it", due))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."reminders" SET .*"sent_at"=\$5,"sent_for"=\$6 WHERE id = \$7 AND lease_owner = \$8`).
+			WithArgs(0, nil, nil, nil, now, due, reminderID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		sent, err := scheduler.DispatchOnce(context.Background(), now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, sent)
+		require.Len(t, notifier.sent, 1)
+		assert.Equal(t, "user@example.com", notifier.sent[0].To)
+		assert.Equal(t, `Reminder: "Ship it" is due in 2 hours`, notifier.sent[0].Subject)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed deliveries are retried with backoff, then given up", func(t *testing.T) {
+		mock := setupMockDB(t)
+		scheduler.Notifier = &fakeNotifier{fail: map[string]bool{"user@example.com": true}}
+		retrying, exhausted := uuid.New(), uuid.New()
+
+		mock.ExpectQuery(`UPDATE tasks.reminders AS r`).
+			WillReturnRows(sqlmock.NewRows(claimedColumns).
+				AddRow(retrying, uuid.New(), uuid.New(), "user@example.com", 0, 2, "Ship it", due).
+				AddRow(exhausted, uuid.New(), uuid.New(), "user@example.com", 0, 3, "Ship it", due))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."reminders" SET "last_error"=\$1,"lease_owner"=\$2,"lease_until"=\$3 WHERE`).
+			WithArgs("mailbox unavailable", nil, now.Add(2*time.Minute), retrying, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."reminders" SET "attempts"=\$1,"last_error"=\$2,"lease_owner"=\$3,"lease_until"=\$4,"sent_for"=\$5 WHERE`).
+			WithArgs(0, "mailbox unavailable", nil, nil, due, exhausted, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		sent, err := scheduler.DispatchOnce(context.Background(), now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, sent)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
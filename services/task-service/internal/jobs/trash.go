@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/storage"
+)
+
+// DefaultTrashRetention is how long deleted tasks stay in the trash unless
+// TRASH_RETENTION overrides it
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashPurger permanently deletes tasks that have been in the trash longer
+// than Retention. Purging is idempotent, so several instances may run it.
+type TrashPurger struct {
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Run purges once immediately and then every Interval until ctx is done
+func (p TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := p.PurgeOnce(ctx, time.Now()); err != nil {
+			log.Printf("Trash purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired tasks from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce deletes, in batches, every task trashed more than Retention
+// before now, along with its attachment blobs. It returns the number of
+// trashed tasks purged.
+func (p TrashPurger) PurgeOnce(ctx context.Context, now time.Time) (int, error) {
+	cutoff := now.Add(-p.Retention)
+	total := 0
+
+	for ctx.Err() == nil {
+		keys, count, err := database.PurgeExpiredTrash(cutoff, p.BatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		storage.DeleteAll(ctx, keys)
+		total += count
+
+		if count < p.BatchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
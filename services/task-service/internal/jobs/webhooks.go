@@ -0,0 +1,105 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/database"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/webhook"
+)
+
+// WebhookDispatcher sends queued webhook deliveries. Every replica may run
+// one: deliveries are leased to Owner while they are being sent. A receiver
+// may still see a delivery twice if a replica dies after sending it, so
+// receivers should deduplicate on the X-Webhook-ID header.
+type WebhookDispatcher struct {
+	Sender *webhook.Sender
+	// Owner identifies this replica in delivery leases and must be unique
+	Owner    string
+	Interval time.Duration
+	// Lease must be longer than the sender's timeout
+	Lease       time.Duration
+	BatchSize   int
+	MaxAttempts int
+}
+
+// Run dispatches once immediately and then every Interval until ctx is done
+func (d WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchOnce(ctx, time.Now()); err != nil {
+			log.Printf("Webhook dispatch failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce claims and sends, in batches, every delivery due at now. It
+// returns the number of deliveries the receivers accepted.
+func (d WebhookDispatcher) DispatchOnce(ctx context.Context, now time.Time) (int, error) {
+	delivered := 0
+
+	for ctx.Err() == nil {
+		due, err := database.ClaimWebhookDeliveries(d.Owner, now, d.Lease, d.BatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		for _, delivery := range due {
+			if d.send(ctx, delivery, now) {
+				delivered++
+			}
+		}
+
+		if len(due) < d.BatchSize {
+			break
+		}
+	}
+
+	return delivered, nil
+}
+
+// send makes one attempt at a claimed delivery and records the outcome
+func (d WebhookDispatcher) send(ctx context.Context, delivery database.DueWebhookDelivery, now time.Time) bool {
+	status, err := d.Sender.Send(ctx, webhook.Request{
+		URL:        delivery.URL,
+		Secret:     delivery.Secret,
+		DeliveryID: delivery.ID.String(),
+		EventType:  delivery.EventType,
+		Payload:    delivery.Payload,
+	})
+
+	if err != nil {
+		var responseStatus *int
+		if status != 0 {
+			responseStatus = &status
+		}
+
+		var retryAt *time.Time
+		if delivery.Attempts < d.MaxAttempts {
+			at := now.Add(retryBackoff(delivery.Attempts, time.Minute, 6*time.Hour))
+			retryAt = &at
+		} else {
+			log.Printf("Giving up on webhook delivery %s after %d attempts: %v", delivery.ID, delivery.Attempts, err)
+		}
+
+		if err := database.MarkWebhookFailed(delivery.ID, d.Owner, responseStatus, err, retryAt); err != nil {
+			log.Printf("Failed to record webhook delivery %s failure: %v", delivery.ID, err)
+		}
+		return false
+	}
+
+	if err := database.MarkWebhookDelivered(delivery.ID, d.Owner, status, now); err != nil {
+		// The lease runs out and the delivery may be sent again
+		log.Printf("Failed to record webhook delivery %s as delivered: %v", delivery.ID, err)
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/webhook"
+)
+
+var claimedDeliveryColumns = []string{"id", "webhook_id", "event_type", "payload", "attempts", "url", "secret"}
+
+func TestWebhookDispatcherDispatchOnce(t *testing.T) {
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	dispatcher := WebhookDispatcher{
+		Sender:      webhook.NewSender(time.Second, true),
+		Owner:       "replica-1",
+		Lease:       time.Minute,
+		BatchSize:   10,
+		MaxAttempts: 3,
+	}
+	payload := `{"type":"task.created"}`
+
+	t.Run("sends signed deliveries and records them", func(t *testing.T) {
+		mock := setupMockDB(t)
+		deliveryID := uuid.New()
+
+		var verified bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+			verified = webhook.Verify("0123456789abcdef", timestamp, body, r.Header.Get(webhook.HeaderSignature)) &&
+				r.Header.Get(webhook.HeaderID) == deliveryID.String()
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		mock.ExpectQuery(`UPDATE tasks.webhook_deliveries AS d`).
+			WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+				AddRow(deliveryID, uuid.New(), "task.created", payload, 1, receiver.URL, "0123456789abcdef"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."webhook_deliveries" SET "delivered_at"=\$1,"last_error"=\$2,"lease_owner"=\$3,"response_status"=\$4,"status"=\$5,"updated_at"=\$6 WHERE id = \$7 AND lease_owner = \$8`).
+			WithArgs(now, nil, nil, 200, "succeeded", sqlmock.AnyArg(), deliveryID, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		delivered, err := dispatcher.DispatchOnce(context.Background(), now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.True(t, verified)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed deliveries are retried with backoff, then given up", func(t *testing.T) {
+		mock := setupMockDB(t)
+		retrying, exhausted := uuid.New(), uuid.New()
+		status := http.StatusInternalServerError
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		defer receiver.Close()
+
+		mock.ExpectQuery(`UPDATE tasks.webhook_deliveries AS d`).
+			WillReturnRows(sqlmock.NewRows(claimedDeliveryColumns).
+				AddRow(retrying, uuid.New(), "task.created", payload, 2, receiver.URL, "0123456789abcdef").
+				AddRow(exhausted, uuid.New(), "task.created", payload, 3, receiver.URL, "0123456789abcdef"))
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."webhook_deliveries" SET "last_error"=\$1,"lease_owner"=\$2,"next_attempt_at"=\$3,"response_status"=\$4`).
+			WithArgs("receiver responded 500 Internal Server Error", nil, now.Add(2*time.Minute), &status, sqlmock.AnyArg(), retrying, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "tasks"."webhook_deliveries" SET "last_error"=\$1,"lease_owner"=\$2,"response_status"=\$3,"status"=\$4`).
+			WithArgs("receiver responded 500 Internal Server Error", nil, &status, "failed", sqlmock.AnyArg(), exhausted, "replica-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		delivered, err := dispatcher.DispatchOnce(context.Background(), now)
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultMaxAttachmentSize is the upload limit used unless ATTACHMENT_MAX_BYTES
+// overrides it
+const DefaultMaxAttachmentSize int64 = 25 << 20
+
+// MaxAttachmentSize is the largest file, in bytes, that can be attached to a task
+var MaxAttachmentSize = DefaultMaxAttachmentSize
+
+// AllowedAttachmentTypes lists the media types that may be uploaded. Anything
+// that browsers would render as active content (HTML, SVG, scripts) is
+// deliberately missing.
+var AllowedAttachmentTypes = []string{
+	"application/json",
+	"application/msword",
+	"application/pdf",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/zip",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+	"text/csv",
+	"text/markdown",
+	"text/plain",
+}
+
+// Attachment is a file uploaded to a task. The contents live in the blob store
+// under StorageKey; the row only holds metadata.
+type Attachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID      uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Filename    string    `gorm:"type:varchar(255);not null" json:"filename"`
+	ContentType string    `gorm:"type:varchar(100);not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	StorageKey  string    `gorm:"type:varchar(255);not null;unique" json:"-"`
+	CreatedAt   time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (Attachment) TableName() string {
+	return "tasks.attachments"
+}
+
+// IsAllowedAttachmentType reports whether files of contentType may be uploaded
+func IsAllowedAttachmentType(contentType string) bool {
+	for _, allowed := range AllowedAttachmentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Attachment) Validate() error {
+	if strings.TrimSpace(a.Filename) == "" {
+		return errors.New("filename is required")
+	}
+
+	if len(a.Filename) > 255 {
+		return errors.New("filename must be 255 characters or less")
+	}
+
+	if strings.ContainsAny(a.Filename, "/\\\x00") {
+		return errors.New("filename must not contain path separators")
+	}
+
+	if !IsAllowedAttachmentType(a.ContentType) {
+		return fmt.Errorf("file type %s is not allowed", a.ContentType)
+	}
+
+	if a.Size <= 0 {
+		return errors.New("file must not be empty")
+	}
+
+	if a.Size > MaxAttachmentSize {
+		return fmt.Errorf("file must be %d bytes or less", MaxAttachmentSize)
+	}
+
+	return nil
+}
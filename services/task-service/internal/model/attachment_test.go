@@ -0,0 +1,55 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentValidate(t *testing.T) {
+	valid := Attachment{Filename: "spec.pdf", ContentType: "application/pdf", Size: 1024}
+
+	tests := []struct {
+		name   string
+		modify func(*Attachment)
+		errMsg string
+	}{
+		{name: "valid attachment", modify: func(a *Attachment) {}},
+		{name: "blank filename", modify: func(a *Attachment) { a.Filename = " " }, errMsg: "filename is required"},
+		{
+			name:   "filename too long",
+			modify: func(a *Attachment) { a.Filename = strings.Repeat("a", 256) },
+			errMsg: "filename must be 255 characters or less",
+		},
+		{
+			name:   "filename with path",
+			modify: func(a *Attachment) { a.Filename = "../spec.pdf" },
+			errMsg: "filename must not contain path separators",
+		},
+		{
+			name:   "disallowed type",
+			modify: func(a *Attachment) { a.ContentType = "text/html" },
+			errMsg: "file type text/html is not allowed",
+		},
+		{name: "empty file", modify: func(a *Attachment) { a.Size = 0 }, errMsg: "file must not be empty"},
+		{
+			name:   "file too large",
+			modify: func(a *Attachment) { a.Size = MaxAttachmentSize + 1 },
+			errMsg: "file must be 26214400 bytes or less",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachment := valid
+			tt.modify(&attachment)
+
+			err := attachment.Validate()
+			if (err != nil) != (tt.errMsg != "") {
+				t.Fatalf("Attachment.Validate() error = %v, want %q", err, tt.errMsg)
+			}
+			if err != nil && err.Error() != tt.errMsg {
+				t.Errorf("Attachment.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
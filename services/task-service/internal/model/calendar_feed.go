@@ -0,0 +1,42 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CalendarFeedTokenPrefix starts every calendar feed token, so a leaked one is
+// easy to recognise
+const CalendarFeedTokenPrefix = "cal_"
+
+// CalendarFeed is a user's iCalendar feed of tasks with due dates. Calendar
+// apps fetch it with a secret token in the URL instead of a JWT; only a hash
+// of the token is stored, and the token is shown once, when it is created.
+type CalendarFeed struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	TokenHash      string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	LastAccessedAt *time.Time `gorm:"type:timestamp" json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (CalendarFeed) TableName() string {
+	return "tasks.calendar_feeds"
+}
+
+// NewCalendarFeedToken generates a random feed token
+func NewCalendarFeedToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return CalendarFeedTokenPrefix + hex.EncodeToString(b)
+}
+
+// HashCalendarFeedToken is the TokenHash stored for token
+func HashCalendarFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
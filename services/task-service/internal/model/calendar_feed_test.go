@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCalendarFeedToken(t *testing.T) {
+	token := NewCalendarFeedToken()
+
+	if !strings.HasPrefix(token, CalendarFeedTokenPrefix) {
+		t.Errorf("NewCalendarFeedToken() = %q, want prefix %q", token, CalendarFeedTokenPrefix)
+	}
+	if len(token) != len(CalendarFeedTokenPrefix)+64 {
+		t.Errorf("NewCalendarFeedToken() has length %d, want %d", len(token), len(CalendarFeedTokenPrefix)+64)
+	}
+	if other := NewCalendarFeedToken(); other == token {
+		t.Errorf("NewCalendarFeedToken() returned %q twice", token)
+	}
+}
+
+func TestHashCalendarFeedToken(t *testing.T) {
+	hash := HashCalendarFeedToken("cal_example")
+
+	if len(hash) != 64 {
+		t.Errorf("HashCalendarFeedToken() has length %d, want 64", len(hash))
+	}
+	if hash != HashCalendarFeedToken("cal_example") {
+		t.Error("HashCalendarFeedToken() is not deterministic")
+	}
+	if hash == HashCalendarFeedToken("cal_other") {
+		t.Error("HashCalendarFeedToken() gave two tokens the same hash")
+	}
+}
+
+func TestCalendarFeedTableName(t *testing.T) {
+	if got := (CalendarFeed{}).TableName(); got != "tasks.calendar_feeds" {
+		t.Errorf("CalendarFeed.TableName() = %v, want tasks.calendar_feeds", got)
+	}
+}
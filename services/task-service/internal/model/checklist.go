@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChecklistItem struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID    uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	Text      string    `gorm:"type:varchar(500);not null" json:"text"`
+	Done      bool      `gorm:"not null;default:false" json:"done"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (ChecklistItem) TableName() string {
+	return "tasks.checklist_items"
+}
+
+func (c ChecklistItem) Validate() error {
+	if strings.TrimSpace(c.Text) == "" {
+		return errors.New("text field is required")
+	}
+
+	if len(c.Text) > 500 {
+		return errors.New("text field length must be 500 characters or less")
+	}
+
+	if c.Position < 0 {
+		return errors.New("position cannot be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestChecklistItemValidate(t *testing.T) {
+	taskID := uuid.New()
+
+	tests := []struct {
+		name    string
+		item    ChecklistItem
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid item",
+			item:    ChecklistItem{TaskID: taskID, Text: "Write tests"},
+			wantErr: false,
+		},
+		{
+			name:    "missing text",
+			item:    ChecklistItem{TaskID: taskID, Text: " "},
+			wantErr: true,
+			errMsg:  "text field is required",
+		},
+		{
+			name:    "text too long",
+			item:    ChecklistItem{TaskID: taskID, Text: strings.Repeat("x", 501)},
+			wantErr: true,
+			errMsg:  "text field length must be 500 characters or less",
+		},
+		{
+			name:    "negative position",
+			item:    ChecklistItem{TaskID: taskID, Text: "Write tests", Position: -1},
+			wantErr: true,
+			errMsg:  "position cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.item.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ChecklistItem.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("ChecklistItem.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestChecklistItemTableName(t *testing.T) {
+	if got := (ChecklistItem{}).TableName(); got != "tasks.checklist_items" {
+		t.Errorf("ChecklistItem.TableName() = %v, want tasks.checklist_items", got)
+	}
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const MaxCommentLength = 10000
+
+// Comment is a Markdown note on a task. Deleted comments are soft-deleted and
+// excluded from queries by GORM.
+type Comment struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	Body      string         `gorm:"type:text;not null" json:"body"`
+	EditedAt  *time.Time     `gorm:"type:timestamp" json:"edited_at,omitempty"`
+	CreatedAt time.Time      `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"type:timestamp;index" json:"-"`
+}
+
+func (Comment) TableName() string {
+	return "tasks.comments"
+}
+
+func (c Comment) Validate() error {
+	if strings.TrimSpace(c.Body) == "" {
+		return errors.New("body field is required")
+	}
+
+	if len(c.Body) > MaxCommentLength {
+		return errors.New("body field length must be 10000 characters or less")
+	}
+
+	return nil
+}
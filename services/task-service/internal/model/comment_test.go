@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommentValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment Comment
+		errMsg  string
+	}{
+		{name: "valid markdown", comment: Comment{Body: "**Blocked** on review, see [PR](https://example.com)"}},
+		{name: "blank body", comment: Comment{Body: " \n\t"}, errMsg: "body field is required"},
+		{
+			name:    "body too long",
+			comment: Comment{Body: strings.Repeat("a", MaxCommentLength+1)},
+			errMsg:  "body field length must be 10000 characters or less",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.comment.Validate()
+			if (err != nil) != (tt.errMsg != "") {
+				t.Fatalf("Comment.Validate() error = %v, want %q", err, tt.errMsg)
+			}
+			if err != nil && err.Error() != tt.errMsg {
+				t.Errorf("Comment.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
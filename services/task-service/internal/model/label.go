@@ -0,0 +1,54 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+const DefaultLabelColor = "#6b7280"
+
+type Label struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
+	Color     string    `gorm:"type:varchar(7);not null;default:'#6b7280'" json:"color"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Label) TableName() string {
+	return "tasks.labels"
+}
+
+// TaskLabel is a row of the many-to-many join between tasks and labels
+type TaskLabel struct {
+	TaskID    uuid.UUID `gorm:"type:uuid;primary_key" json:"task_id"`
+	LabelID   uuid.UUID `gorm:"type:uuid;primary_key" json:"label_id"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (TaskLabel) TableName() string {
+	return "tasks.task_labels"
+}
+
+func (l Label) Validate() error {
+	if strings.TrimSpace(l.Name) == "" {
+		return errors.New("name field is required")
+	}
+
+	if len(l.Name) > 50 {
+		return errors.New("name field length must be 50 characters or less")
+	}
+
+	if !hexColorPattern.MatchString(l.Color) {
+		return errors.New("color must be a hex color like #1a2b3c")
+	}
+
+	return nil
+}
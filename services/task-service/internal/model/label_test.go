@@ -0,0 +1,76 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestLabelValidate(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		label   Label
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid label",
+			label:   Label{UserID: userID, Name: "bug", Color: "#d73a4a"},
+			wantErr: false,
+		},
+		{
+			name:    "valid uppercase color",
+			label:   Label{UserID: userID, Name: "frontend", Color: "#A2EEEF"},
+			wantErr: false,
+		},
+		{
+			name:    "missing name",
+			label:   Label{UserID: userID, Name: "  ", Color: "#d73a4a"},
+			wantErr: true,
+			errMsg:  "name field is required",
+		},
+		{
+			name:    "name too long",
+			label:   Label{UserID: userID, Name: strings.Repeat("a", 51), Color: "#d73a4a"},
+			wantErr: true,
+			errMsg:  "name field length must be 50 characters or less",
+		},
+		{
+			name:    "color without hash",
+			label:   Label{UserID: userID, Name: "bug", Color: "d73a4a"},
+			wantErr: true,
+			errMsg:  "color must be a hex color like #1a2b3c",
+		},
+		{
+			name:    "named color",
+			label:   Label{UserID: userID, Name: "bug", Color: "red"},
+			wantErr: true,
+			errMsg:  "color must be a hex color like #1a2b3c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.label.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Label.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("Label.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestLabelTableNames(t *testing.T) {
+	if got := (Label{}).TableName(); got != "tasks.labels" {
+		t.Errorf("Label.TableName() = %v, want tasks.labels", got)
+	}
+	if got := (TaskLabel{}).TableName(); got != "tasks.task_labels" {
+		t.Errorf("TaskLabel.TableName() = %v, want tasks.task_labels", got)
+	}
+}
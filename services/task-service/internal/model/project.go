@@ -0,0 +1,42 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const DefaultProjectColor = "#3b82f6"
+
+type Project struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string    `gorm:"type:varchar(100);not null" json:"name"`
+	Description *string   `gorm:"type:text" json:"description,omitempty"`
+	Color       string    `gorm:"type:varchar(7);not null;default:'#3b82f6'" json:"color"`
+	Archived    bool      `gorm:"not null;default:false" json:"archived"`
+	CreatedAt   time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Project) TableName() string {
+	return "tasks.projects"
+}
+
+func (p Project) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name field is required")
+	}
+
+	if len(p.Name) > 100 {
+		return errors.New("name field length must be 100 characters or less")
+	}
+
+	if !hexColorPattern.MatchString(p.Color) {
+		return errors.New("color must be a hex color like #1a2b3c")
+	}
+
+	return nil
+}
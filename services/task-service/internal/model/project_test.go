@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestProjectValidate(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name    string
+		project Project
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid project",
+			project: Project{UserID: userID, Name: "Acme redesign", Color: DefaultProjectColor},
+			wantErr: false,
+		},
+		{
+			name:    "missing name",
+			project: Project{UserID: userID, Name: " ", Color: DefaultProjectColor},
+			wantErr: true,
+			errMsg:  "name field is required",
+		},
+		{
+			name:    "name too long",
+			project: Project{UserID: userID, Name: strings.Repeat("a", 101), Color: DefaultProjectColor},
+			wantErr: true,
+			errMsg:  "name field length must be 100 characters or less",
+		},
+		{
+			name:    "invalid color",
+			project: Project{UserID: userID, Name: "Acme", Color: "blue"},
+			wantErr: true,
+			errMsg:  "color must be a hex color like #1a2b3c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.project.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Project.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("Project.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
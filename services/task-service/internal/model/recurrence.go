@@ -0,0 +1,394 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies supported in the FREQ part of a rule
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+)
+
+// maxRecurrenceSteps bounds the search for the next occurrence so rules that
+// can never match again (such as BYMONTHDAY=30 every 12 months from February)
+// end the series instead of looping forever
+const maxRecurrenceSteps = 5000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// RecurrenceDay is one BYDAY entry. Ordinal picks the nth such weekday of the
+// month (negative counts from the end); zero means every such weekday.
+type RecurrenceDay struct {
+	Weekday time.Weekday
+	Ordinal int
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE that tasks support:
+// FREQ (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, COUNT or UNTIL, BYDAY,
+// BYMONTHDAY and WKST. The series starts at the due date of its first task,
+// which also supplies the time of day of every occurrence.
+type RecurrenceRule struct {
+	Frequency  string
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+	WeekStart  time.Weekday
+
+	// untilIsDate is set when UNTIL was a plain date, which includes the
+	// whole of that day in the rule's timezone
+	untilIsDate bool
+}
+
+// ParseRecurrenceRule parses an RRULE value such as
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10". A leading "RRULE:" is ignored.
+func ParseRecurrenceRule(s string) (*RecurrenceRule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("recurrence rule is empty")
+	}
+
+	rule := RecurrenceRule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("malformed recurrence rule part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("recurrence rule repeats %s", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch value {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				rule.Frequency = value
+			default:
+				err = fmt.Errorf("FREQ must be one of %s, %s, %s, %s", FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(name, value)
+		case "COUNT":
+			rule.Count, err = parsePositive(name, value)
+		case "UNTIL":
+			err = rule.parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "WKST":
+			day, known := weekdayCodes[value]
+			if !known {
+				err = fmt.Errorf("WKST must be a weekday code such as MO")
+			}
+			rule.WeekStart = day
+		default:
+			err = fmt.Errorf("recurrence rule part %s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func parsePositive(name, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+func (r *RecurrenceRule) parseUntil(value string) error {
+	if t, err := time.Parse("20060102", value); err == nil {
+		r.Until = &t
+		r.untilIsDate = true
+		return nil
+	}
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		r.Until = &t
+		return nil
+	}
+	return errors.New("UNTIL must be a date (YYYYMMDD) or a UTC date-time (YYYYMMDDTHHMMSSZ)")
+}
+
+func parseByDay(value string) ([]RecurrenceDay, error) {
+	var days []RecurrenceDay
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+		weekday, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+
+		day := RecurrenceDay{Weekday: weekday}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			ordinal, err := strconv.Atoi(prefix)
+			if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+				return nil, fmt.Errorf("invalid BYDAY value %q", item)
+			}
+			day.Ordinal = ordinal
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(item)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("invalid BYMONTHDAY value %q", item)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func (r RecurrenceRule) validate() error {
+	if r.Frequency == "" {
+		return errors.New("recurrence rule requires FREQ")
+	}
+
+	if r.Count > 0 && r.Until != nil {
+		return errors.New("recurrence rule cannot have both COUNT and UNTIL")
+	}
+
+	if len(r.ByMonthDay) > 0 && r.Frequency != FrequencyMonthly {
+		return errors.New("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+
+	if len(r.ByDay) > 0 && r.Frequency == FrequencyYearly {
+		return errors.New("BYDAY is not supported with FREQ=YEARLY")
+	}
+
+	for _, day := range r.ByDay {
+		if day.Ordinal != 0 && r.Frequency != FrequencyMonthly {
+			return errors.New("numbered BYDAY values such as 1MO are only supported with FREQ=MONTHLY")
+		}
+	}
+
+	return nil
+}
+
+// Next returns the occurrence that follows prev, the due date of occurrence
+// number n (counting from 1) of a series that began at start. Every
+// occurrence falls at start's wall-clock time in loc, so a 09:00 task stays at
+// 09:00 across DST changes (see wallClock for days where that time is skipped
+// or repeated). ok is false once the series has ended.
+func (r RecurrenceRule) Next(start, prev time.Time, n int, loc *time.Location) (next time.Time, ok bool) {
+	if r.Count > 0 && n >= r.Count {
+		return time.Time{}, false
+	}
+
+	start = start.In(loc)
+	date := civilDate(prev.In(loc))
+
+	var found bool
+	switch r.Frequency {
+	case FrequencyDaily:
+		date, found = r.nextDaily(date)
+	case FrequencyWeekly:
+		date, found = r.nextWeekly(date)
+	case FrequencyMonthly:
+		date, found = r.nextMonthly(date)
+	case FrequencyYearly:
+		date, found = r.nextYearly(date)
+	}
+	if !found {
+		return time.Time{}, false
+	}
+
+	next = wallClock(date, start, loc)
+
+	if r.Until != nil {
+		if r.untilIsDate {
+			if civilDate(next).After(*r.Until) {
+				return time.Time{}, false
+			}
+		} else if next.After(*r.Until) {
+			return time.Time{}, false
+		}
+	}
+
+	return next, true
+}
+
+// wallClock is clock's time of day on date in loc. As RFC 5545 specifies, a
+// time skipped by a DST change is read with the UTC offset from before the
+// change, landing after the gap (02:30 becomes 03:30), and a time that occurs
+// twice is the first of the two.
+func wallClock(date, clock time.Time, loc *time.Location) time.Time {
+	t := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), loc)
+	if t.Hour() == clock.Hour() && t.Minute() == clock.Minute() {
+		return t
+	}
+
+	_, offset := t.Add(-12 * time.Hour).Zone()
+	utc := time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), clock.Nanosecond(), time.UTC)
+	return utc.Add(-time.Duration(offset) * time.Second).In(loc)
+}
+
+// civilDate is t's calendar date as midnight UTC, so date arithmetic is not
+// disturbed by DST
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (r RecurrenceRule) matchesWeekday(date time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(r.ByDay, func(day RecurrenceDay) bool { return day.Weekday == date.Weekday() })
+}
+
+func (r RecurrenceRule) nextDaily(date time.Time) (time.Time, bool) {
+	for i := 0; i < maxRecurrenceSteps; i++ {
+		date = date.AddDate(0, 0, r.Interval)
+		if r.matchesWeekday(date) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextWeekly looks for a later matching day in date's week, then moves on
+// Interval weeks at a time. Weeks begin on WeekStart.
+func (r RecurrenceRule) nextWeekly(date time.Time) (time.Time, bool) {
+	if len(r.ByDay) == 0 {
+		return date.AddDate(0, 0, 7*r.Interval), true
+	}
+
+	weekStart := date.AddDate(0, 0, -((int(date.Weekday()) - int(r.WeekStart) + 7) % 7))
+	for day := date.AddDate(0, 0, 1); day.Before(weekStart.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+		if r.matchesWeekday(day) {
+			return day, true
+		}
+	}
+
+	weekStart = weekStart.AddDate(0, 0, 7*r.Interval)
+	for day := weekStart; ; day = day.AddDate(0, 0, 1) {
+		if r.matchesWeekday(day) {
+			return day, true
+		}
+	}
+}
+
+// nextMonthly looks for a later matching day in date's month, then moves on
+// Interval months at a time. Months without a matching day, such as February
+// for BYMONTHDAY=30, are skipped.
+func (r RecurrenceRule) nextMonthly(date time.Time) (time.Time, bool) {
+	for _, day := range r.monthDays(date.Year(), date.Month(), date.Day()) {
+		if day > date.Day() {
+			return time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, time.UTC), true
+		}
+	}
+
+	year, month := date.Year(), date.Month()
+	for i := 0; i < maxRecurrenceSteps; i++ {
+		first := time.Date(year, month+time.Month(r.Interval), 1, 0, 0, 0, 0, time.UTC)
+		year, month = first.Year(), first.Month()
+		if days := r.monthDays(year, month, date.Day()); len(days) > 0 {
+			return time.Date(year, month, days[0], 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// monthDays lists the days of the month that match the rule, in order.
+// Without BYDAY or BYMONTHDAY the series repeats on startDay.
+func (r RecurrenceRule) monthDays(year int, month time.Month, startDay int) []int {
+	length := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []int
+	for day := 1; day <= length; day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		if r.matchesMonthDay(date, length, startDay) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func (r RecurrenceRule) matchesMonthDay(date time.Time, length, startDay int) bool {
+	day := date.Day()
+
+	if len(r.ByMonthDay) > 0 {
+		matched := slices.ContainsFunc(r.ByMonthDay, func(want int) bool {
+			return want == day || want == day-length-1
+		})
+		if !matched {
+			return false
+		}
+		return r.matchesWeekday(date)
+	}
+
+	if len(r.ByDay) > 0 {
+		return slices.ContainsFunc(r.ByDay, func(want RecurrenceDay) bool {
+			if want.Weekday != date.Weekday() {
+				return false
+			}
+			switch {
+			case want.Ordinal > 0:
+				return (day-1)/7+1 == want.Ordinal
+			case want.Ordinal < 0:
+				return (length-day)/7+1 == -want.Ordinal
+			default:
+				return true
+			}
+		})
+	}
+
+	return day == startDay
+}
+
+// nextYearly repeats on date's month and day; February 29 only recurs in
+// leap years
+func (r RecurrenceRule) nextYearly(date time.Time) (time.Time, bool) {
+	for year := date.Year() + r.Interval; year <= date.Year()+maxRecurrenceSteps; year += r.Interval {
+		next := time.Date(year, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		if next.Day() == date.Day() {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// LoadRecurrenceLocation resolves a task's recurrence timezone. An empty name
+// means UTC.
+func LoadRecurrenceLocation(name *string) (*time.Location, error) {
+	if name == nil || *name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(*name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown recurrence timezone %q", *name)
+	}
+	return loc, nil
+}
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		errMsg string
+	}{
+		{name: "daily", rule: "FREQ=DAILY"},
+		{name: "with RRULE prefix", rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{name: "lowercase", rule: "freq=monthly;bymonthday=1,-1"},
+		{name: "numbered weekday", rule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=12"},
+		{name: "until date", rule: "FREQ=DAILY;UNTIL=20261231"},
+		{name: "until date-time", rule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20261231T235959Z;WKST=SU"},
+		{name: "empty", rule: "", errMsg: "recurrence rule is empty"},
+		{name: "missing FREQ", rule: "INTERVAL=2", errMsg: "recurrence rule requires FREQ"},
+		{name: "unknown FREQ", rule: "FREQ=HOURLY", errMsg: "FREQ must be one of DAILY, WEEKLY, MONTHLY, YEARLY"},
+		{name: "malformed part", rule: "FREQ=DAILY;INTERVAL", errMsg: `malformed recurrence rule part "INTERVAL"`},
+		{name: "repeated part", rule: "FREQ=DAILY;FREQ=WEEKLY", errMsg: "recurrence rule repeats FREQ"},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0", errMsg: "INTERVAL must be a positive integer"},
+		{name: "count and until", rule: "FREQ=DAILY;COUNT=3;UNTIL=20261231", errMsg: "recurrence rule cannot have both COUNT and UNTIL"},
+		{name: "bad until", rule: "FREQ=DAILY;UNTIL=2026-12-31", errMsg: "UNTIL must be a date (YYYYMMDD) or a UTC date-time (YYYYMMDDTHHMMSSZ)"},
+		{name: "bad weekday", rule: "FREQ=WEEKLY;BYDAY=XX", errMsg: `invalid BYDAY value "XX"`},
+		{name: "bad ordinal", rule: "FREQ=MONTHLY;BYDAY=6MO", errMsg: `invalid BYDAY value "6MO"`},
+		{name: "numbered weekday outside monthly", rule: "FREQ=WEEKLY;BYDAY=1MO", errMsg: "numbered BYDAY values such as 1MO are only supported with FREQ=MONTHLY"},
+		{name: "bad month day", rule: "FREQ=MONTHLY;BYMONTHDAY=32", errMsg: `invalid BYMONTHDAY value "32"`},
+		{name: "month day outside monthly", rule: "FREQ=WEEKLY;BYMONTHDAY=1", errMsg: "BYMONTHDAY is only supported with FREQ=MONTHLY"},
+		{name: "unsupported part", rule: "FREQ=DAILY;BYHOUR=9", errMsg: "recurrence rule part BYHOUR is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecurrenceRule(tt.rule)
+			if (err != nil) != (tt.errMsg != "") {
+				t.Fatalf("ParseRecurrenceRule(%q) error = %v, want %q", tt.rule, err, tt.errMsg)
+			}
+			if err != nil && err.Error() != tt.errMsg {
+				t.Errorf("ParseRecurrenceRule(%q) error message = %v, want %v", tt.rule, err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	const layout = "2006-01-02 15:04 MST"
+
+	tests := []struct {
+		name     string
+		rule     string
+		timezone string
+		start    string
+		// want lists the occurrences after start, in layout and the rule's
+		// timezone; "end" means the series has ended
+		want []string
+	}{
+		{
+			name:     "daily across spring forward keeps the time of day",
+			rule:     "FREQ=DAILY",
+			timezone: "America/New_York",
+			start:    "2026-03-07 09:00 EST",
+			want:     []string{"2026-03-08 09:00 EDT", "2026-03-09 09:00 EDT"},
+		},
+		{
+			name:     "daily in the skipped hour shifts that day only",
+			rule:     "FREQ=DAILY",
+			timezone: "America/New_York",
+			start:    "2026-03-07 02:30 EST",
+			want:     []string{"2026-03-08 03:30 EDT", "2026-03-09 02:30 EDT"},
+		},
+		{
+			name:     "daily in the repeated hour",
+			rule:     "FREQ=DAILY",
+			timezone: "America/New_York",
+			start:    "2026-10-31 01:30 EDT",
+			want:     []string{"2026-11-01 01:30 EDT", "2026-11-02 01:30 EST"},
+		},
+		{
+			name:     "weekly on several days across the European change",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,TH",
+			timezone: "Europe/Berlin",
+			start:    "2026-03-26 18:00 CET",
+			want:     []string{"2026-03-30 18:00 CEST", "2026-04-02 18:00 CEST", "2026-04-06 18:00 CEST"},
+		},
+		{
+			name:     "monthly onto the day the southern hemisphere falls back",
+			rule:     "FREQ=MONTHLY",
+			timezone: "Australia/Sydney",
+			start:    "2026-03-05 07:00 AEDT",
+			want:     []string{"2026-04-05 07:00 AEST", "2026-05-05 07:00 AEST"},
+		},
+		{
+			name:     "weekly without BYDAY repeats the start weekday",
+			rule:     "FREQ=WEEKLY",
+			timezone: "UTC",
+			start:    "2026-01-07 12:00 UTC",
+			want:     []string{"2026-01-14 12:00 UTC", "2026-01-21 12:00 UTC"},
+		},
+		{
+			name:     "every other week",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,FR",
+			timezone: "UTC",
+			start:    "2026-01-06 08:00 UTC",
+			want:     []string{"2026-01-09 08:00 UTC", "2026-01-20 08:00 UTC", "2026-01-23 08:00 UTC"},
+		},
+		{
+			name:     "every other week starting on Sunday",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU,MO;WKST=SU",
+			timezone: "UTC",
+			start:    "2026-01-04 08:00 UTC",
+			want:     []string{"2026-01-05 08:00 UTC", "2026-01-18 08:00 UTC"},
+		},
+		{
+			name:     "weekdays only",
+			rule:     "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR",
+			timezone: "UTC",
+			start:    "2026-01-09 08:00 UTC",
+			want:     []string{"2026-01-12 08:00 UTC", "2026-01-13 08:00 UTC"},
+		},
+		{
+			name:     "monthly on the 31st skips shorter months",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=31",
+			timezone: "UTC",
+			start:    "2026-01-31 10:00 UTC",
+			want:     []string{"2026-03-31 10:00 UTC", "2026-05-31 10:00 UTC"},
+		},
+		{
+			name:     "monthly on the first and last day",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=1,-1",
+			timezone: "UTC",
+			start:    "2026-01-31 10:00 UTC",
+			want:     []string{"2026-02-01 10:00 UTC", "2026-02-28 10:00 UTC", "2026-03-01 10:00 UTC"},
+		},
+		{
+			name:     "monthly on the last Friday",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			timezone: "UTC",
+			start:    "2026-01-30 16:00 UTC",
+			want:     []string{"2026-02-27 16:00 UTC", "2026-03-27 16:00 UTC"},
+		},
+		{
+			name:     "quarterly on the second Tuesday",
+			rule:     "FREQ=MONTHLY;INTERVAL=3;BYDAY=2TU",
+			timezone: "UTC",
+			start:    "2026-01-13 16:00 UTC",
+			want:     []string{"2026-04-14 16:00 UTC", "2026-07-14 16:00 UTC"},
+		},
+		{
+			name:     "yearly on February 29",
+			rule:     "FREQ=YEARLY",
+			timezone: "UTC",
+			start:    "2024-02-29 09:00 UTC",
+			want:     []string{"2028-02-29 09:00 UTC"},
+		},
+		{
+			name:     "count",
+			rule:     "FREQ=DAILY;COUNT=3",
+			timezone: "UTC",
+			start:    "2026-01-01 09:00 UTC",
+			want:     []string{"2026-01-02 09:00 UTC", "2026-01-03 09:00 UTC", "end"},
+		},
+		{
+			name:     "until date includes that whole day",
+			rule:     "FREQ=DAILY;UNTIL=20260110",
+			timezone: "America/New_York",
+			start:    "2026-01-08 21:00 EST",
+			want:     []string{"2026-01-09 21:00 EST", "2026-01-10 21:00 EST", "end"},
+		},
+		{
+			name:     "until date-time is an instant",
+			rule:     "FREQ=DAILY;UNTIL=20260109T100000Z",
+			timezone: "America/New_York",
+			start:    "2026-01-08 09:00 EST",
+			want:     []string{"end"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrenceRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrenceRule(%q) error = %v", tt.rule, err)
+			}
+			loc, err := time.LoadLocation(tt.timezone)
+			if err != nil {
+				t.Fatalf("LoadLocation(%q) error = %v", tt.timezone, err)
+			}
+			start, err := time.ParseInLocation(layout, tt.start, loc)
+			if err != nil {
+				t.Fatalf("ParseInLocation(%q) error = %v", tt.start, err)
+			}
+
+			prev := start
+			for n, want := range tt.want {
+				next, ok := rule.Next(start, prev, n+1, loc)
+				got := "end"
+				if ok {
+					got = next.In(loc).Format(layout)
+				}
+				if got != want {
+					t.Fatalf("occurrence %d = %s, want %s", n+2, got, want)
+				}
+				prev = next
+			}
+		})
+	}
+}
+
+func TestTaskNextDueDate(t *testing.T) {
+	rule := "FREQ=WEEKLY"
+	tz := "Europe/Berlin"
+	berlin, _ := time.LoadLocation(tz)
+	start := time.Date(2026, 3, 2, 2, 30, 0, 0, berlin)
+	// The week of the change put this occurrence at 03:30; the series goes
+	// back to the start's 02:30 afterwards
+	due := time.Date(2026, 3, 29, 3, 30, 0, 0, berlin)
+
+	task := Task{Recurrence: &rule, RecurrenceTimezone: &tz, RecurrenceStart: &start, DueDate: &due, Occurrence: 5}
+	next, ok, err := task.NextDueDate()
+	if err != nil || !ok {
+		t.Fatalf("NextDueDate() = %v, %v, %v", next, ok, err)
+	}
+	if want := time.Date(2026, 4, 5, 2, 30, 0, 0, berlin); !next.Equal(want) {
+		t.Errorf("NextDueDate() = %v, want %v", next, want)
+	}
+
+	oneOff := Task{DueDate: &due}
+	if _, ok, _ := oneOff.NextDueDate(); ok {
+		t.Error("NextDueDate() of a one-off task should not return a date")
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxReminderOffset is the earliest a reminder can fire before its task is due
+const MaxReminderOffset = 365 * 24 * time.Hour
+
+// Reminder notifies a task's owner OffsetMinutes before the task is due; 0
+// means at the due time. It fires once per due date: moving the due date
+// re-arms it. Lease and retry bookkeeping is internal to the scheduler.
+type Reminder struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	OffsetMinutes int        `gorm:"not null" json:"offset_minutes"`
+	SentFor       *time.Time `gorm:"type:timestamp" json:"-"`
+	SentAt        *time.Time `gorm:"type:timestamp" json:"sent_at,omitempty"`
+	Attempts      int        `gorm:"not null;default:0" json:"-"`
+	LastError     *string    `gorm:"type:text" json:"last_error,omitempty"`
+	LeaseOwner    *string    `gorm:"type:varchar(100)" json:"-"`
+	LeaseUntil    *time.Time `gorm:"type:timestamp" json:"-"`
+	CreatedAt     time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	// RemindAt is when the reminder fires for the task's current due date
+	RemindAt *time.Time `gorm:"-" json:"remind_at,omitempty"`
+}
+
+func (Reminder) TableName() string {
+	return "tasks.reminders"
+}
+
+func (r Reminder) Validate() error {
+	if r.OffsetMinutes < 0 {
+		return errors.New("offset_minutes cannot be negative")
+	}
+
+	if time.Duration(r.OffsetMinutes)*time.Minute > MaxReminderOffset {
+		return errors.New("offset_minutes must be at most one year")
+	}
+
+	return nil
+}
+
+// At is when r fires for a task due at dueDate
+func (r Reminder) At(dueDate time.Time) time.Time {
+	return dueDate.Add(-time.Duration(r.OffsetMinutes) * time.Minute)
+}
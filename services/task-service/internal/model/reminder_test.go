@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestReminderValidate(t *testing.T) {
+	taskID := uuid.New()
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+		wantErr  bool
+		errMsg   string
+	}{
+		{
+			name:     "at the due time",
+			reminder: Reminder{TaskID: taskID},
+			wantErr:  false,
+		},
+		{
+			name:     "a year before",
+			reminder: Reminder{TaskID: taskID, OffsetMinutes: 365 * 24 * 60},
+			wantErr:  false,
+		},
+		{
+			name:     "negative offset",
+			reminder: Reminder{TaskID: taskID, OffsetMinutes: -5},
+			wantErr:  true,
+			errMsg:   "offset_minutes cannot be negative",
+		},
+		{
+			name:     "more than a year before",
+			reminder: Reminder{TaskID: taskID, OffsetMinutes: 365*24*60 + 1},
+			wantErr:  true,
+			errMsg:   "offset_minutes must be at most one year",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.reminder.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Reminder.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && err.Error() != tt.errMsg {
+				t.Errorf("Reminder.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestReminderAt(t *testing.T) {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	reminder := Reminder{OffsetMinutes: 24 * 60}
+
+	if got, want := reminder.At(due), time.Date(2026, 4, 30, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Reminder.At() = %v, want %v", got, want)
+	}
+}
+
+func TestReminderTableName(t *testing.T) {
+	if got := (Reminder{}).TableName(); got != "tasks.reminders" {
+		t.Errorf("Reminder.TableName() = %v, want tasks.reminders", got)
+	}
+}
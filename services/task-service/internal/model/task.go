@@ -2,33 +2,91 @@ package model
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type Task struct {
 	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProjectID   *uuid.UUID `gorm:"type:uuid;index" json:"project_id,omitempty"`
+	ParentID    *uuid.UUID `gorm:"type:uuid;index" json:"parent_id,omitempty"`
 	Title       string     `gorm:"type:varchar(255);not null" json:"title"`
 	Description *string    `gorm:"type:text" json:"description,omitempty"`
 	Status      string     `gorm:"type:varchar(50);not null;default:'todo'" json:"status"`
 	Priority    *string    `gorm:"type:varchar(50);default:'medium'" json:"priority,omitempty"`
 	DueDate     *time.Time `gorm:"type:timestamp" json:"due_date,omitempty"`
 	CompletedAt *time.Time `gorm:"type:timestamp" json:"completed_at,omitempty"`
-	CreatedAt   time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
-	UpdatedAt   time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// Recurrence is an RRULE (see RecurrenceRule) evaluated in
+	// RecurrenceTimezone from RecurrenceStart. Completing a recurring task
+	// creates the next occurrence; Occurrence numbers them from 1 and is 0 for
+	// one-off tasks.
+	Recurrence         *string    `gorm:"type:varchar(500)" json:"recurrence,omitempty"`
+	RecurrenceTimezone *string    `gorm:"column:recurrence_tz;type:varchar(64)" json:"recurrence_timezone,omitempty"`
+	RecurrenceStart    *time.Time `gorm:"type:timestamp" json:"recurrence_start,omitempty"`
+	Occurrence         int        `gorm:"not null" json:"occurrence,omitempty"`
+	// Version is bumped by the database on every update; it is the task's
+	// ETag and guards writes made with If-Match
+	Version int `gorm:"not null;default:1" json:"version"`
+	// Rank orders the task within its status column on a board (see package
+	// rank). The database ranks new tasks last in their column.
+	Rank      string    `gorm:"type:text;default:null" json:"rank"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	// DeletedAt is set while the task is in the trash. GORM excludes trashed
+	// tasks from queries unless they are made Unscoped.
+	DeletedAt gorm.DeletedAt `gorm:"type:timestamp;index" json:"-"`
+}
+
+// ValidPriorities lists the accepted priorities in rank order. Statuses are
+// defined per project, see Workflow.
+var ValidPriorities = []string{"low", "medium", "high"}
+
+// IsValidStatusKey reports whether status is shaped like a workflow status key.
+// Whether it exists depends on the task's workflow.
+func IsValidStatusKey(status string) bool {
+	return len(status) <= 50 && statusKeyPattern.MatchString(status)
+}
+
+func IsValidPriority(priority string) bool {
+	return slices.Contains(ValidPriorities, priority)
 }
 
 func (Task) TableName() string {
 	return "tasks.tasks"
 }
 
+// TaskProgress summarises how much of a task's checklist and subtasks are done
+type TaskProgress struct {
+	TaskID         uuid.UUID `gorm:"column:task_id" json:"-"`
+	ChecklistDone  int       `gorm:"column:checklist_done" json:"checklist_done"`
+	ChecklistTotal int       `gorm:"column:checklist_total" json:"checklist_total"`
+	SubtasksDone   int       `gorm:"column:subtasks_done" json:"subtasks_done"`
+	SubtasksTotal  int       `gorm:"column:subtasks_total" json:"subtasks_total"`
+	Done           int       `gorm:"-" json:"done"`
+	Total          int       `gorm:"-" json:"total"`
+}
+
+// Validate checks the task against the default workflow. Tasks in a project
+// with its own workflow are checked with ValidateInWorkflow.
 func (t Task) Validate() error {
+	return t.ValidateInWorkflow(DefaultWorkflow())
+}
+
+func (t Task) ValidateInWorkflow(wf Workflow) error {
 	if t.Title == "" {
 		return errors.New("title field is required")
 	}
 
+	if t.ParentID != nil && *t.ParentID == t.ID {
+		return errors.New("task cannot be its own parent")
+	}
+
 	if len(t.Title) > 255 {
 		return errors.New("title field length must be 255 characters or less")
 	}
@@ -41,12 +99,12 @@ func (t Task) Validate() error {
 		return errors.New("status field length must be 50 characters or less")
 	}
 
-	if t.Priority != nil && (*t.Priority != "low" && *t.Priority != "medium" && *t.Priority != "high") {
-		*t.Priority = "medium"
+	if err := wf.ValidateStatus(t.Status); err != nil {
+		return err
 	}
 
-	if t.Status != "todo" && t.Status != "in-progress" && t.Status != "done" {
-		t.Status = "todo"
+	if t.Priority != nil && !IsValidPriority(*t.Priority) {
+		return fmt.Errorf("priority must be one of: %s", strings.Join(ValidPriorities, ", "))
 	}
 
 	if t.DueDate != nil {
@@ -55,9 +113,47 @@ func (t Task) Validate() error {
 		}
 	}
 
-	if (t.Status == "done" && t.CompletedAt == nil) || (t.Status != "done" && t.CompletedAt != nil) {
-		return errors.New("status must be done and completedAt must be set")
+	if wf.IsDone(t.Status) != (t.CompletedAt != nil) {
+		return errors.New("completedAt must be set exactly when the status is a done status")
+	}
+
+	if t.Recurrence != nil {
+		if _, err := ParseRecurrenceRule(*t.Recurrence); err != nil {
+			return err
+		}
+		if t.DueDate == nil {
+			return errors.New("recurring tasks require a due date")
+		}
+	}
+
+	if _, err := LoadRecurrenceLocation(t.RecurrenceTimezone); err != nil {
+		return err
 	}
 
 	return nil
 }
+
+// NextDueDate is the due date of the occurrence after t. ok is false if t
+// does not recur or its series has ended.
+func (t Task) NextDueDate() (next time.Time, ok bool, err error) {
+	if t.Recurrence == nil || t.DueDate == nil {
+		return time.Time{}, false, nil
+	}
+
+	rule, err := ParseRecurrenceRule(*t.Recurrence)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	loc, err := LoadRecurrenceLocation(t.RecurrenceTimezone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	start := t.DueDate
+	if t.RecurrenceStart != nil {
+		start = t.RecurrenceStart
+	}
+
+	next, ok = rule.Next(*start, *t.DueDate, max(t.Occurrence, 1), loc)
+	return next, ok, nil
+}
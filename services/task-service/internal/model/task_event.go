@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Task event actions
+const (
+	TaskEventCreated   = "created"
+	TaskEventUpdated   = "updated"
+	TaskEventCompleted = "completed"
+	TaskEventDeleted   = "deleted"
+	TaskEventRestored  = "restored"
+	TaskEventPurged    = "purged"
+)
+
+// TaskEvent is one entry in a task's history. Updates and completions record
+// one event per changed field with its old and new value; creations,
+// deletions, restores and purges record a single event without a field.
+type TaskEvent struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TaskID    uuid.UUID `gorm:"type:uuid;not null;index" json:"task_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Action    string    `gorm:"type:varchar(20);not null" json:"action"`
+	Field     *string   `gorm:"type:varchar(50)" json:"field,omitempty"`
+	OldValue  *string   `gorm:"type:text" json:"old_value"`
+	NewValue  *string   `gorm:"type:text" json:"new_value"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (TaskEvent) TableName() string {
+	return "tasks.task_events"
+}
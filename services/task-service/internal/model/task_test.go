@@ -18,6 +18,10 @@ func TestTaskValidate(t *testing.T) {
 	futureDate := time.Now().Add(24 * time.Hour)
 	pastDate := time.Now().Add(-24 * time.Hour)
 	now := time.Now()
+	weekly := "FREQ=WEEKLY;BYDAY=MO"
+	hourly := "FREQ=HOURLY"
+	berlin := "Europe/Berlin"
+	unknownZone := "Mars/Olympus_Mons"
 
 	tests := []struct {
 		name    string
@@ -76,6 +80,18 @@ func TestTaskValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "title field length must be 255 characters or less",
 		},
+		{
+			name: "task is its own parent",
+			task: Task{
+				ID:       userID,
+				ParentID: &userID,
+				UserID:   userID,
+				Title:    validTitle,
+				Status:   validStatus,
+			},
+			wantErr: true,
+			errMsg:  "task cannot be its own parent",
+		},
 		{
 			name: "missing status",
 			task: Task{
@@ -157,7 +173,7 @@ func TestTaskValidate(t *testing.T) {
 				CompletedAt: nil,
 			},
 			wantErr: true,
-			errMsg:  "status must be done and completedAt must be set",
+			errMsg:  "completedAt must be set exactly when the status is a done status",
 		},
 		{
 			name: "non-completed task with completed_at",
@@ -168,7 +184,55 @@ func TestTaskValidate(t *testing.T) {
 				CompletedAt: &now,
 			},
 			wantErr: true,
-			errMsg:  "status must be done and completedAt must be set",
+			errMsg:  "completedAt must be set exactly when the status is a done status",
+		},
+		{
+			name: "valid recurring task",
+			task: Task{
+				UserID:             userID,
+				Title:              validTitle,
+				Status:             validStatus,
+				DueDate:            &futureDate,
+				Recurrence:         &weekly,
+				RecurrenceTimezone: &berlin,
+			},
+			wantErr: false,
+		},
+		{
+			name: "recurring task without due date",
+			task: Task{
+				UserID:     userID,
+				Title:      validTitle,
+				Status:     validStatus,
+				Recurrence: &weekly,
+			},
+			wantErr: true,
+			errMsg:  "recurring tasks require a due date",
+		},
+		{
+			name: "invalid recurrence rule",
+			task: Task{
+				UserID:     userID,
+				Title:      validTitle,
+				Status:     validStatus,
+				DueDate:    &futureDate,
+				Recurrence: &hourly,
+			},
+			wantErr: true,
+			errMsg:  "FREQ must be one of DAILY, WEEKLY, MONTHLY, YEARLY",
+		},
+		{
+			name: "unknown recurrence timezone",
+			task: Task{
+				UserID:             userID,
+				Title:              validTitle,
+				Status:             validStatus,
+				DueDate:            &futureDate,
+				Recurrence:         &weekly,
+				RecurrenceTimezone: &unknownZone,
+			},
+			wantErr: true,
+			errMsg:  `unknown recurrence timezone "Mars/Olympus_Mons"`,
 		},
 	}
 
@@ -0,0 +1,231 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook event types. Task events are named after the history action that
+// produced them; webhook.test is only sent by the "send test event" endpoint.
+const (
+	WebhookEventTaskCreated   = "task.created"
+	WebhookEventTaskUpdated   = "task.updated"
+	WebhookEventTaskCompleted = "task.completed"
+	WebhookEventTaskDeleted   = "task.deleted"
+	WebhookEventTaskRestored  = "task.restored"
+	WebhookEventTaskPurged    = "task.purged"
+	WebhookEventTest          = "webhook.test"
+)
+
+// WebhookEventTypes are the event types a webhook can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventTaskCreated,
+	WebhookEventTaskUpdated,
+	WebhookEventTaskCompleted,
+	WebhookEventTaskDeleted,
+	WebhookEventTaskRestored,
+	WebhookEventTaskPurged,
+}
+
+// WebhookEventType is the webhook event type for a task history action
+func WebhookEventType(action string) string {
+	return "task." + action
+}
+
+// MinWebhookSecretLength keeps user-chosen signing secrets from being guessable
+const MinWebhookSecretLength = 16
+
+// WebhookEvents is a set of subscribed event types, stored comma-separated
+type WebhookEvents []string
+
+func (e WebhookEvents) Value() (driver.Value, error) {
+	return strings.Join(e, ","), nil
+}
+
+func (e *WebhookEvents) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	case nil:
+		*e = nil
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEvents", src)
+	}
+
+	if s == "" {
+		*e = WebhookEvents{}
+		return nil
+	}
+	*e = strings.Split(s, ",")
+	return nil
+}
+
+// Has reports whether eventType is one of e. Test events go to every webhook.
+func (e WebhookEvents) Has(eventType string) bool {
+	return eventType == WebhookEventTest || slices.Contains(e, eventType)
+}
+
+// Webhook is an endpoint a user registered to receive task events. Payloads
+// are signed with Secret, which is only shown when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	URL       string        `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string        `gorm:"type:varchar(255);not null" json:"-"`
+	Events    WebhookEvents `gorm:"type:varchar(500);not null" json:"events"`
+	Active    bool          `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time     `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time     `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "tasks.webhooks"
+}
+
+func (h Webhook) Validate() error {
+	if h.URL == "" {
+		return errors.New("url field is required")
+	}
+
+	if len(h.URL) > 2048 {
+		return errors.New("url field length must be 2048 characters or less")
+	}
+
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	if u.User != nil {
+		return errors.New("url cannot contain credentials")
+	}
+
+	if len(h.Secret) < MinWebhookSecretLength {
+		return fmt.Errorf("secret must be at least %d characters", MinWebhookSecretLength)
+	}
+
+	if len(h.Secret) > 255 {
+		return errors.New("secret must be 255 characters or less")
+	}
+
+	if len(h.Events) == 0 {
+		return errors.New("events must list at least one event type")
+	}
+
+	seen := map[string]bool{}
+	for _, event := range h.Events {
+		if !slices.Contains(WebhookEventTypes, event) {
+			return fmt.Errorf("unknown event type %q: must be one of %s", event, strings.Join(WebhookEventTypes, ", "))
+		}
+		if seen[event] {
+			return fmt.Errorf("event type %q is listed twice", event)
+		}
+		seen[event] = true
+	}
+
+	return nil
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event queued for one webhook, together with the
+// outcome of its latest attempt. Pending deliveries are attempted from
+// NextAttemptAt; a dispatcher holding the delivery pushes NextAttemptAt out
+// by its lease so other replicas leave it alone.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	WebhookID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"webhook_id"`
+	EventID        uuid.UUID  `gorm:"type:uuid;not null" json:"event_id"`
+	EventType      string     `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        RawJSON    `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus *int       `gorm:"type:integer" json:"response_status,omitempty"`
+	LastError      *string    `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"next_attempt_at"`
+	LeaseOwner     *string    `gorm:"type:varchar(100)" json:"-"`
+	DeliveredAt    *time.Time `gorm:"type:timestamp" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "tasks.webhook_deliveries"
+}
+
+// RawJSON is a JSON document kept in a jsonb column and emitted as is
+type RawJSON []byte
+
+func (j RawJSON) Value() (driver.Value, error) {
+	if j == nil {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *RawJSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		*j = RawJSON(v)
+	case []byte:
+		*j = append(RawJSON(nil), v...)
+	case nil:
+		*j = nil
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", src)
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+func (j *RawJSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+// WebhookEvent is the JSON body POSTed to webhooks
+type WebhookEvent struct {
+	ID        uuid.UUID        `json:"id"`
+	Type      string           `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      WebhookEventData `json:"data"`
+}
+
+// WebhookEventData describes the task an event is about. Task is the task as
+// it was right after the change, and is omitted once the task is purged;
+// Changes lists the changed fields of task.updated and task.completed events.
+type WebhookEventData struct {
+	TaskID  *uuid.UUID    `json:"task_id,omitempty"`
+	Task    *Task         `json:"task,omitempty"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	Message string        `json:"message,omitempty"`
+}
+
+// FieldChange is one field's old and new value
+type FieldChange struct {
+	Field    string  `json:"field"`
+	OldValue *string `json:"old_value"`
+	NewValue *string `json:"new_value"`
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status categories give custom statuses a meaning the service can act on:
+// tasks in a done status carry a completed_at, all others do not.
+const (
+	StatusCategoryNotStarted = "not-started"
+	StatusCategoryActive     = "active"
+	StatusCategoryDone       = "done"
+)
+
+var StatusCategories = []string{StatusCategoryNotStarted, StatusCategoryActive, StatusCategoryDone}
+
+// statusKeyPattern matches the keys stored in tasks.status, e.g. "in-review"
+var statusKeyPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// WorkflowStatus is one column of a project's board
+type WorkflowStatus struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"-"`
+	ProjectID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Key       string    `gorm:"type:varchar(50);not null" json:"key"`
+	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
+	Category  string    `gorm:"type:varchar(20);not null" json:"category"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"-"`
+	UpdatedAt time.Time `gorm:"type:timestamp;not null;default:CURRENT_TIMESTAMP" json:"-"`
+}
+
+func (WorkflowStatus) TableName() string {
+	return "tasks.workflow_statuses"
+}
+
+// WorkflowTransition allows moving a task from one status to another
+type WorkflowTransition struct {
+	ProjectID uuid.UUID `gorm:"type:uuid;primary_key" json:"-"`
+	From      string    `gorm:"column:from_status;type:varchar(50);primary_key" json:"from"`
+	To        string    `gorm:"column:to_status;type:varchar(50);primary_key" json:"to"`
+}
+
+func (WorkflowTransition) TableName() string {
+	return "tasks.workflow_transitions"
+}
+
+// Workflow is the ordered list of statuses a task may take and the moves
+// allowed between them. A workflow without transitions allows every move.
+type Workflow struct {
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// DefaultWorkflow is used for tasks outside a project and for projects that
+// have not defined their own statuses
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []WorkflowStatus{
+			{Key: "todo", Name: "To Do", Category: StatusCategoryNotStarted, Position: 0},
+			{Key: "in-progress", Name: "In Progress", Category: StatusCategoryActive, Position: 1},
+			{Key: "done", Name: "Done", Category: StatusCategoryDone, Position: 2},
+		},
+		Transitions: []WorkflowTransition{},
+	}
+}
+
+// Keys returns the status keys in board order
+func (wf Workflow) Keys() []string {
+	keys := make([]string, len(wf.Statuses))
+	for i, s := range wf.Statuses {
+		keys[i] = s.Key
+	}
+	return keys
+}
+
+func (wf Workflow) Status(key string) (WorkflowStatus, bool) {
+	for _, s := range wf.Statuses {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// FirstInCategory returns the first status of category in board order
+func (wf Workflow) FirstInCategory(category string) (WorkflowStatus, bool) {
+	for _, s := range wf.Statuses {
+		if s.Category == category {
+			return s, true
+		}
+	}
+	return WorkflowStatus{}, false
+}
+
+// IsDone reports whether key is a status in the done category
+func (wf Workflow) IsDone(key string) bool {
+	s, ok := wf.Status(key)
+	return ok && s.Category == StatusCategoryDone
+}
+
+// CanTransition reports whether a task may move from one status to another.
+// Staying in the same status is always allowed.
+func (wf Workflow) CanTransition(from, to string) bool {
+	if from == to || len(wf.Transitions) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(wf.Transitions, func(t WorkflowTransition) bool {
+		return t.From == from && t.To == to
+	})
+}
+
+// ValidateStatus checks that key is one of the workflow's statuses
+func (wf Workflow) ValidateStatus(key string) error {
+	if _, ok := wf.Status(key); !ok {
+		return fmt.Errorf("status must be one of: %s", strings.Join(wf.Keys(), ", "))
+	}
+	return nil
+}
+
+func (wf Workflow) Validate() error {
+	if len(wf.Statuses) == 0 {
+		return errors.New("workflow must have at least one status")
+	}
+
+	seen := make(map[string]bool, len(wf.Statuses))
+	for _, s := range wf.Statuses {
+		if !statusKeyPattern.MatchString(s.Key) || len(s.Key) > 50 {
+			return fmt.Errorf("status key %q must be lowercase letters, digits and dashes, 50 characters or less", s.Key)
+		}
+		if seen[s.Key] {
+			return fmt.Errorf("duplicate status key %q", s.Key)
+		}
+		seen[s.Key] = true
+
+		if strings.TrimSpace(s.Name) == "" || len(s.Name) > 50 {
+			return fmt.Errorf("status %q must have a name of 50 characters or less", s.Key)
+		}
+		if !slices.Contains(StatusCategories, s.Category) {
+			return fmt.Errorf("status %q category must be one of: %s", s.Key, strings.Join(StatusCategories, ", "))
+		}
+	}
+
+	// New tasks start in the first not-started status and CompleteTask moves
+	// tasks to the first done status, so both must exist
+	if _, ok := wf.FirstInCategory(StatusCategoryNotStarted); !ok {
+		return errors.New("workflow must have a not-started status")
+	}
+	if _, ok := wf.FirstInCategory(StatusCategoryDone); !ok {
+		return errors.New("workflow must have a done status")
+	}
+
+	for _, t := range wf.Transitions {
+		if !seen[t.From] || !seen[t.To] {
+			return fmt.Errorf("transition %s -> %s refers to an unknown status", t.From, t.To)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("transition %s -> %s must change status", t.From, t.To)
+		}
+	}
+
+	return nil
+}
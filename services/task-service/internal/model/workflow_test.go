@@ -0,0 +1,128 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	valid := func() Workflow {
+		return Workflow{
+			Statuses: []WorkflowStatus{
+				{Key: "backlog", Name: "Backlog", Category: StatusCategoryNotStarted},
+				{Key: "in-review", Name: "In Review", Category: StatusCategoryActive},
+				{Key: "shipped", Name: "Shipped", Category: StatusCategoryDone},
+			},
+			Transitions: []WorkflowTransition{{From: "backlog", To: "in-review"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(wf *Workflow)
+		errMsg string
+	}{
+		{name: "valid workflow", mutate: func(wf *Workflow) {}},
+		{name: "default workflow", mutate: func(wf *Workflow) { *wf = DefaultWorkflow() }},
+		{
+			name:   "no statuses",
+			mutate: func(wf *Workflow) { wf.Statuses = nil },
+			errMsg: "workflow must have at least one status",
+		},
+		{
+			name:   "malformed key",
+			mutate: func(wf *Workflow) { wf.Statuses[0].Key = "In Review" },
+			errMsg: `status key "In Review" must be lowercase letters, digits and dashes, 50 characters or less`,
+		},
+		{
+			name:   "duplicate key",
+			mutate: func(wf *Workflow) { wf.Statuses[1].Key = "backlog" },
+			errMsg: `duplicate status key "backlog"`,
+		},
+		{
+			name:   "unknown category",
+			mutate: func(wf *Workflow) { wf.Statuses[1].Category = "blocked" },
+			errMsg: `status "in-review" category must be one of: not-started, active, done`,
+		},
+		{
+			name:   "no done status",
+			mutate: func(wf *Workflow) { wf.Statuses[2].Category = StatusCategoryActive },
+			errMsg: "workflow must have a done status",
+		},
+		{
+			name:   "no not-started status",
+			mutate: func(wf *Workflow) { wf.Statuses[0].Category = StatusCategoryActive },
+			errMsg: "workflow must have a not-started status",
+		},
+		{
+			name:   "transition to unknown status",
+			mutate: func(wf *Workflow) { wf.Transitions[0].To = "done" },
+			errMsg: "transition backlog -> done refers to an unknown status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := valid()
+			tt.mutate(&wf)
+			err := wf.Validate()
+			if (err != nil) != (tt.errMsg != "") {
+				t.Fatalf("Workflow.Validate() error = %v, want %q", err, tt.errMsg)
+			}
+			if err != nil && err.Error() != tt.errMsg {
+				t.Errorf("Workflow.Validate() error message = %v, want %v", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestWorkflowCanTransition(t *testing.T) {
+	wf := Workflow{
+		Statuses: DefaultWorkflow().Statuses,
+		Transitions: []WorkflowTransition{
+			{From: "todo", To: "in-progress"},
+			{From: "in-progress", To: "done"},
+		},
+	}
+
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"todo", "in-progress", true},
+		{"in-progress", "done", true},
+		{"todo", "done", false},
+		{"done", "todo", false},
+		{"todo", "todo", true},
+	}
+
+	for _, tt := range tests {
+		if got := wf.CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	if !DefaultWorkflow().CanTransition("done", "todo") {
+		t.Error("a workflow without transitions should allow every move")
+	}
+}
+
+func TestTaskValidateInWorkflow(t *testing.T) {
+	wf := Workflow{Statuses: []WorkflowStatus{
+		{Key: "backlog", Name: "Backlog", Category: StatusCategoryNotStarted},
+		{Key: "shipped", Name: "Shipped", Category: StatusCategoryDone},
+	}}
+
+	if err := (Task{Title: "Task", Status: "backlog"}).ValidateInWorkflow(wf); err != nil {
+		t.Errorf("unexpected error for custom status: %v", err)
+	}
+
+	err := (Task{Title: "Task", Status: "todo"}).ValidateInWorkflow(wf)
+	if err == nil || err.Error() != "status must be one of: backlog, shipped" {
+		t.Errorf("ValidateInWorkflow() error = %v, want unknown status error", err)
+	}
+
+	err = (Task{Title: "Task", Status: "shipped"}).ValidateInWorkflow(wf)
+	if err == nil || err.Error() != "completedAt must be set exactly when the status is a done status" {
+		t.Errorf("ValidateInWorkflow() error = %v, want completedAt error", err)
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a plain-text notification for one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv builds the Notifier selected by NOTIFIER: "log" (the default)
+// writes messages to the service log, "smtp" sends email through the server
+// configured with the SMTP_* variables.
+func FromEnv() (Notifier, error) {
+	switch backend := os.Getenv("NOTIFIER"); backend {
+	case "", "log":
+		log.Println("Reminders are written to the log; set NOTIFIER=smtp to email them")
+		return LogNotifier{}, nil
+	case "smtp":
+		port := 587
+		if raw := os.Getenv("SMTP_PORT"); raw != "" {
+			p, err := strconv.Atoi(raw)
+			if err != nil || p <= 0 {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", raw)
+			}
+			port = p
+		}
+
+		notifier, err := NewSMTPNotifier(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Sending reminders through SMTP server %s:%d", os.Getenv("SMTP_HOST"), port)
+		return notifier, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q: must be log or smtp", backend)
+	}
+}
+
+// LogNotifier writes messages to the service log instead of delivering them.
+// It is meant for development.
+type LogNotifier struct{}
+
+func (LogNotifier) Send(_ context.Context, msg Message) error {
+	log.Printf("Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/williamschweitzer/task-management-app/services/shared/smtpmail"
+)
+
+// SMTPConfig describes the mail server reminders are submitted to
+type SMTPConfig = smtpmail.Config
+
+// SMTPNotifier emails messages through an SMTP submission server
+type SMTPNotifier struct {
+	sender *smtpmail.Sender
+}
+
+func NewSMTPNotifier(cfg SMTPConfig) (*SMTPNotifier, error) {
+	sender, err := smtpmail.NewSender(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPNotifier{sender: sender}, nil
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	return n.sender.Send(ctx, smtpmail.Message(msg))
+}
@@ -0,0 +1,158 @@
+// Package rank generates lexicographic ranks for manually ordered lists.
+//
+// A rank is a base-36 fraction between 0 and 1 written without its "0."
+// prefix, using the digits 0-9 and a-z, so byte-wise string comparison
+// orders ranks numerically. Ranks never end in 0, which guarantees there is
+// always another rank between any two, and so placing an item between two
+// others only ever rewrites the item itself:
+//
+//	r, err := rank.Between("a", "b") // "ai"
+//
+// Repeated insertions at the same spot make ranks longer; Spread replaces a
+// whole list's ranks with short, evenly spaced ones.
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// digits are the rank digits in ascending byte order
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// mid is the rank of an item in an otherwise empty list
+const mid = "i"
+
+var (
+	ErrInvalid = errors.New("invalid rank")
+	ErrOrder   = errors.New("lower rank must sort before upper rank")
+)
+
+// Valid reports whether r is a well-formed rank
+func Valid(r string) bool {
+	if r == "" || r[len(r)-1] == digits[0] {
+		return false
+	}
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Between returns a rank that sorts after lower and before upper. An empty
+// lower means the start of the list and an empty upper its end.
+func Between(lower, upper string) (string, error) {
+	if (lower != "" && !Valid(lower)) || (upper != "" && !Valid(upper)) {
+		return "", ErrInvalid
+	}
+
+	switch {
+	case lower == "" && upper == "":
+		return mid, nil
+	case lower == "":
+		return before(upper), nil
+	case upper == "":
+		return after(lower), nil
+	case lower >= upper:
+		return "", ErrOrder
+	default:
+		return midpoint(lower, upper), nil
+	}
+}
+
+// digit is the value of the digit of r at i, treating r as padded with zeros
+func digit(r string, i int) int {
+	if i >= len(r) {
+		return 0
+	}
+	return strings.IndexByte(digits, r[i])
+}
+
+// after returns a rank greater than r, preferring short ones: the next
+// first digit where there is one
+func after(r string) string {
+	if r == "" {
+		return mid
+	}
+	if d := digit(r, 0); d < base-1 {
+		return digits[d+1 : d+2]
+	}
+	return r[:1] + after(r[1:])
+}
+
+// before returns a rank less than r, mirroring after
+func before(r string) string {
+	if r == "" {
+		return mid
+	}
+	if d := digit(r, 0); d > 1 {
+		return digits[d-1 : d]
+	}
+	return digits[:1] + before(r[1:])
+}
+
+// midpoint returns a rank between lower and upper, which must be valid and
+// in order. It keeps their common prefix and halves the gap after it.
+func midpoint(lower, upper string) string {
+	n := 0
+	for digit(lower, n) == digit(upper, n) {
+		n++
+	}
+	if n > 0 {
+		rest := ""
+		if n < len(lower) {
+			rest = lower[n:]
+		}
+		if rest == "" {
+			return upper[:n] + before(upper[n:])
+		}
+		return upper[:n] + midpoint(rest, upper[n:])
+	}
+
+	lo, hi := digit(lower, 0), digit(upper, 0)
+	if hi-lo > 1 {
+		m := (lo + hi + 1) / 2
+		return digits[m : m+1]
+	}
+	// Adjacent first digits: upper's first digit alone fits if upper goes on
+	// past it, otherwise extend lower
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if len(lower) > 1 {
+		rest = lower[1:]
+	}
+	return digits[lo:lo+1] + after(rest)
+}
+
+// Spread returns n evenly spaced ranks in ascending order, as short as n
+// allows
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	width, slots := 1, base
+	for slots <= n {
+		width++
+		slots *= base
+	}
+	step := slots / (n + 1)
+
+	ranks := make([]string, n)
+	buf := make([]byte, width)
+	for i := range ranks {
+		v := (i + 1) * step
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = digits[v%base]
+			v /= base
+		}
+		ranks[i] = strings.TrimRight(string(buf), digits[:1])
+	}
+	return ranks
+}
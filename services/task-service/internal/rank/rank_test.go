@@ -0,0 +1,97 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		lower, upper string
+		want         string
+	}{
+		{"", "", "i"},
+		{"i", "", "j"},
+		{"z", "", "zi"},
+		{"zz", "", "zzi"},
+		{"", "i", "h"},
+		{"", "1", "0i"},
+		{"", "01", "00i"},
+		{"a", "c", "b"},
+		{"a", "b", "ai"},
+		{"a", "a5", "a4"},
+		{"a", "a1", "a0i"},
+		{"a5", "b", "a6"},
+		{"a", "b5", "b"},
+		{"az", "b", "azi"},
+	}
+
+	for _, tt := range tests {
+		got, err := Between(tt.lower, tt.upper)
+		require.NoError(t, err, "%q, %q", tt.lower, tt.upper)
+		assert.Equal(t, tt.want, got, "%q, %q", tt.lower, tt.upper)
+	}
+}
+
+func TestBetweenErrors(t *testing.T) {
+	_, err := Between("b", "a")
+	assert.ErrorIs(t, err, ErrOrder)
+
+	_, err = Between("a", "a")
+	assert.ErrorIs(t, err, ErrOrder)
+
+	_, err = Between("a0", "")
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	_, err = Between("", "A")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+// TestBetweenKeepsOrder inserts at random positions of a list and checks that
+// it stays sorted and every rank stays valid
+func TestBetweenKeepsOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var ranks []string
+
+	for i := 0; i < 2000; i++ {
+		at := rng.Intn(len(ranks) + 1)
+		lower, upper := "", ""
+		if at > 0 {
+			lower = ranks[at-1]
+		}
+		if at < len(ranks) {
+			upper = ranks[at]
+		}
+
+		r, err := Between(lower, upper)
+		require.NoError(t, err)
+		require.True(t, Valid(r), r)
+		require.True(t, lower == "" || lower < r, "%q < %q", lower, r)
+		require.True(t, upper == "" || r < upper, "%q < %q", r, upper)
+
+		ranks = append(ranks[:at], append([]string{r}, ranks[at:]...)...)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	assert.Nil(t, Spread(0))
+	assert.Equal(t, []string{"i"}, Spread(1))
+
+	for _, n := range []int{2, 35, 36, 1000, 50000} {
+		ranks := Spread(n)
+		require.Len(t, ranks, n)
+		assert.True(t, sort.StringsAreSorted(ranks), "n=%d", n)
+		for i, r := range ranks {
+			require.True(t, Valid(r), "n=%d: %q", n, r)
+			if i > 0 {
+				require.NotEqual(t, ranks[i-1], r)
+			}
+		}
+	}
+
+	assert.LessOrEqual(t, len(Spread(1000)[999]), 2)
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	t.Run("round trips a blob", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "tasks/a/b", strings.NewReader("hello"), 5, "text/plain"))
+
+		rc, err := store.Get(ctx, "tasks/a/b")
+		require.NoError(t, err)
+		defer rc.Close()
+
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+	})
+
+	t.Run("short writes are rejected and leave nothing behind", func(t *testing.T) {
+		err := store.Put(ctx, "tasks/a/short", strings.NewReader("hi"), 5, "text/plain")
+		assert.Error(t, err)
+
+		_, err = store.Get(ctx, "tasks/a/short")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("deleted blobs are gone", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "tasks/a/c", strings.NewReader("bye"), 3, "text/plain"))
+		require.NoError(t, store.Delete(ctx, "tasks/a/c"))
+
+		_, err := store.Get(ctx, "tasks/a/c")
+		assert.ErrorIs(t, err, ErrBlobNotFound)
+	})
+
+	t.Run("deleting a missing blob succeeds", func(t *testing.T) {
+		assert.NoError(t, store.Delete(ctx, "tasks/missing"))
+	})
+
+	t.Run("keys cannot escape the root", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "../outside", "tasks/../../outside", `tasks\..\outside`, "tasks//a"} {
+			assert.Error(t, store.Put(ctx, key, strings.NewReader("x"), 1, ""), key)
+			_, err := store.Get(ctx, key)
+			assert.Error(t, err, key)
+		}
+	})
+}
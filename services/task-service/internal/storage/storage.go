@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the contents of uploaded files. Keys are slash-separated
+// relative paths such as "tasks/<task id>/<attachment id>".
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get opens the blob under key; it returns ErrBlobNotFound if there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+var Blobs BlobStore
+
+// Init configures Blobs from the environment. BLOB_STORE selects the backend:
+// "local" (the default) writes under BLOB_LOCAL_DIR, "s3" talks to any
+// S3-compatible service configured with the S3_* variables.
+func Init() error {
+	switch backend := os.Getenv("BLOB_STORE"); backend {
+	case "", "local":
+		dir := os.Getenv("BLOB_LOCAL_DIR")
+		if dir == "" {
+			dir = "data/blobs"
+		}
+
+		store, err := NewLocalStore(dir)
+		if err != nil {
+			return err
+		}
+		Blobs = store
+		log.Printf("Storing attachments on the local filesystem at %s", dir)
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			return err
+		}
+		Blobs = store
+		log.Printf("Storing attachments in S3 bucket %s", os.Getenv("S3_BUCKET"))
+	default:
+		return fmt.Errorf("unknown BLOB_STORE %q: must be local or s3", backend)
+	}
+
+	return nil
+}
+
+// DeleteAll removes the blobs under keys from Blobs. It is used once the rows
+// referencing them are gone, so failures only leave orphaned blobs behind and
+// are logged rather than returned. Deletion is not cut short if ctx is
+// cancelled.
+func DeleteAll(ctx context.Context, keys []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		if err := Blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// TaskBlobKey is the key an attachment's contents are stored under
+func TaskBlobKey(taskID, attachmentID uuid.UUID) string {
+	return "tasks/" + taskID.String() + "/" + attachmentID.String()
+}
+
+// validateKey rejects keys that could escape the store's namespace
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Channel is the PostgreSQL notification channel task events are sent on
+const Channel = "task_events"
+
+// Listener relays task event notifications to Broker over a dedicated
+// PostgreSQL connection, reconnecting whenever it is lost
+type Listener struct {
+	DSN    string
+	Broker *Broker
+}
+
+// Run listens until ctx is done
+func (l Listener) Run(ctx context.Context) {
+	connected, failures := false, 0
+
+	for {
+		err := l.listen(ctx, func() {
+			if connected {
+				l.Broker.Reset()
+			}
+			connected, failures = true, 0
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		wait := min(time.Second<<min(failures-1, 5), 30*time.Second)
+		log.Printf("Task event listener disconnected, reconnecting in %s: %v", wait, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// listen connects, calls ready once it is listening and publishes
+// notifications until the connection fails
+func (l Listener) listen(ctx context.Context, ready func()) error {
+	conn, err := pgx.Connect(ctx, l.DSN)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	ready()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		event, err := ParseEvent(notification.Payload)
+		if err != nil {
+			log.Printf("Ignoring task event notification: %v", err)
+			continue
+		}
+		l.Broker.Publish(event)
+	}
+}
@@ -0,0 +1,148 @@
+// Package stream fans task changes out to the live update streams open on
+// this replica. Changes reach every replica through PostgreSQL: inserting
+// task events NOTIFYs the task_events channel, and each replica's Listener
+// publishes the notifications to its Broker.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/williamschweitzer/task-management-app/services/task-service/internal/model"
+)
+
+// Event is a change to one of a user's tasks. ID is the task event sequence
+// number. A user's changes are numbered in the order they commit, so it
+// doubles as the SSE event ID.
+type Event struct {
+	ID     int64     `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	TaskID uuid.UUID `json:"task_id"`
+	Action string    `json:"action"`
+}
+
+// Stream event types
+const (
+	TypeCreated = "created"
+	TypeUpdated = "updated"
+	TypeDeleted = "deleted"
+)
+
+// Type is how the change looks to a board: restored tasks reappear and
+// purged ones, already gone from the board, are deleted again
+func (e Event) Type() string {
+	switch e.Action {
+	case model.TaskEventCreated, model.TaskEventRestored:
+		return TypeCreated
+	case model.TaskEventDeleted, model.TaskEventPurged:
+		return TypeDeleted
+	default:
+		return TypeUpdated
+	}
+}
+
+// ParseEvent decodes a task_events notification payload
+func ParseEvent(payload string) (Event, error) {
+	var e Event
+	if err := json.Unmarshal([]byte(payload), &e); err != nil {
+		return Event{}, fmt.Errorf("invalid task event notification: %w", err)
+	}
+	if e.ID <= 0 || e.UserID == uuid.Nil || e.TaskID == uuid.Nil {
+		return Event{}, fmt.Errorf("invalid task event notification: %q", payload)
+	}
+	return e, nil
+}
+
+// SubscriptionBuffer is how many events a subscriber may fall behind before
+// it is dropped
+const SubscriptionBuffer = 64
+
+// Events is the broker the task stream endpoint subscribes to
+var Events = NewBroker()
+
+// Broker delivers each published event to the subscriptions of the user it
+// belongs to
+type Broker struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[uuid.UUID]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives a user's events on C. C is closed when the
+// subscription is dropped, after which the subscriber should catch up from
+// the database; see Publish and Reset.
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	userID uuid.UUID
+	broker *Broker
+}
+
+func (b *Broker) Subscribe(userID uuid.UUID) *Subscription {
+	c := make(chan Event, SubscriptionBuffer)
+	sub := &Subscription{C: c, c: c, userID: userID, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[*Subscription]struct{}{}
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// Close unsubscribes; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
+
+// drop removes and closes sub; the caller holds b.mu
+func (b *Broker) drop(sub *Subscription) {
+	subs := b.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(b.subs, sub.userID)
+	}
+	close(sub.c)
+}
+
+// Publish delivers e to its user's subscriptions without blocking. A
+// subscription whose buffer is full is dropped rather than left with a gap.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[e.UserID] {
+		select {
+		case sub.c <- e:
+		default:
+			b.drop(sub)
+		}
+	}
+}
+
+// Reset drops every subscription. The listener calls it after reconnecting,
+// as notifications sent while it was disconnected are lost.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.drop(sub)
+		}
+	}
+}
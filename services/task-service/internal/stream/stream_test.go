@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventType(t *testing.T) {
+	tests := map[string]string{
+		"created":   TypeCreated,
+		"restored":  TypeCreated,
+		"updated":   TypeUpdated,
+		"completed": TypeUpdated,
+		"deleted":   TypeDeleted,
+		"purged":    TypeDeleted,
+	}
+
+	for action, want := range tests {
+		assert.Equal(t, want, Event{Action: action}.Type(), action)
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	userID, taskID := uuid.New(), uuid.New()
+
+	event, err := ParseEvent(`{"id":42,"user_id":"` + userID.String() + `","task_id":"` + taskID.String() + `","action":"completed"}`)
+
+	require.NoError(t, err)
+	assert.Equal(t, Event{ID: 42, UserID: userID, TaskID: taskID, Action: "completed"}, event)
+
+	_, err = ParseEvent(`not json`)
+	assert.Error(t, err)
+	_, err = ParseEvent(`{"id":42,"action":"created"}`)
+	assert.Error(t, err)
+}
+
+func TestBroker(t *testing.T) {
+	alice, bob := uuid.New(), uuid.New()
+
+	t.Run("delivers events to the user's subscriptions only", func(t *testing.T) {
+		broker := NewBroker()
+		tab1, tab2 := broker.Subscribe(alice), broker.Subscribe(alice)
+		other := broker.Subscribe(bob)
+		defer tab1.Close()
+		defer tab2.Close()
+		defer other.Close()
+
+		event := Event{ID: 1, UserID: alice, TaskID: uuid.New(), Action: "created"}
+		broker.Publish(event)
+
+		assert.Equal(t, event, <-tab1.C)
+		assert.Equal(t, event, <-tab2.C)
+		assert.Empty(t, other.C)
+	})
+
+	t.Run("drops subscriptions that fall behind", func(t *testing.T) {
+		broker := NewBroker()
+		slow := broker.Subscribe(alice)
+
+		for i := 0; i <= SubscriptionBuffer; i++ {
+			broker.Publish(Event{ID: int64(i + 1), UserID: alice})
+		}
+
+		received := 0
+		for range slow.C {
+			received++
+		}
+		assert.Equal(t, SubscriptionBuffer, received)
+		slow.Close()
+	})
+
+	t.Run("reset drops every subscription", func(t *testing.T) {
+		broker := NewBroker()
+		a, b := broker.Subscribe(alice), broker.Subscribe(bob)
+
+		broker.Reset()
+
+		_, open := <-a.C
+		assert.False(t, open)
+		_, open = <-b.C
+		assert.False(t, open)
+		assert.Empty(t, broker.subs)
+	})
+
+	t.Run("close is idempotent", func(t *testing.T) {
+		broker := NewBroker()
+		sub := broker.Subscribe(alice)
+
+		sub.Close()
+		sub.Close()
+		broker.Publish(Event{ID: 1, UserID: alice})
+
+		assert.Empty(t, broker.subs)
+	})
+}
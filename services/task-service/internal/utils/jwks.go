@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksMinRefetch is the least time between JWKS fetches, so tokens with made
+// up kids cannot make every request fetch
+const jwksMinRefetch = 30 * time.Second
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySource looks up the public key that verifies tokens signed under kid
+type KeySource interface {
+	PublicKey(kid string) (crypto.PublicKey, error)
+}
+
+// StaticKeys is a fixed KeySource, keyed by kid
+type StaticKeys map[string]crypto.PublicKey
+
+func (k StaticKeys) PublicKey(kid string) (crypto.PublicKey, error) {
+	key, ok := k[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// JWKS is a KeySource that fetches the auth-service's published keys and
+// caches them for a TTL. An unknown kid triggers an early refetch, since the
+// auth-service may have rotated. When a fetch fails the cached keys are kept.
+// Fetches happen outside the lock: lookups of cached keys never wait on one.
+type JWKS struct {
+	url        string
+	ttl        time.Duration
+	minRefetch time.Duration
+	client     *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// fetching is closed when the fetch under way, if any, finishes
+	fetching chan struct{}
+}
+
+// NewJWKS returns a KeySource for the JWKS at url. Keys are fetched on first
+// use.
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	return &JWKS{
+		url:        url,
+		ttl:        ttl,
+		minRefetch: jwksMinRefetch,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (j *JWKS) PublicKey(kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	key, ok := j.keys[kid]
+	stale := time.Since(j.fetchedAt) >= j.ttl
+	switch {
+	case (!ok || stale) && j.fetching == nil && time.Since(j.attemptedAt) >= j.minRefetch:
+		j.attemptedAt = time.Now()
+		done := make(chan struct{})
+		j.fetching = done
+		j.mu.Unlock()
+
+		keys, err := j.fetch()
+
+		j.mu.Lock()
+		if err != nil {
+			log.Printf("Failed to fetch JWKS from %s: %v", j.url, err)
+		} else {
+			j.keys, j.fetchedAt = keys, time.Now()
+		}
+		j.fetching = nil
+		close(done)
+		key, ok = j.keys[kid]
+	case !ok && j.fetching != nil:
+		// The fetch under way may bring the key
+		done := j.fetching
+		j.mu.Unlock()
+		<-done
+		j.mu.Lock()
+		key, ok = j.keys[kid]
+	}
+	j.mu.Unlock()
+
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (j *JWKS) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := parseJWK(k)
+		if err != nil {
+			// Skip keys this service cannot use rather than all of them
+			log.Printf("Ignoring JWKS key %s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func parseJWK(k jwk) (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves the keys in published and counts the fetches
+func jwksServer(t *testing.T, published map[string]any, failing *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	fetches := &atomic.Int32{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if failing != nil && failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		keys := []map[string]string{}
+		for kid, key := range published {
+			switch k := key.(type) {
+			case ed25519.PublicKey:
+				keys = append(keys, map[string]string{
+					"kty": "OKP", "crv": "Ed25519", "kid": kid, "use": "sig", "alg": "EdDSA",
+					"x": base64.RawURLEncoding.EncodeToString(k),
+				})
+			case *rsa.PublicKey:
+				keys = append(keys, map[string]string{
+					"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+					"n": base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+					"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, fetches
+}
+
+func TestJWKS(t *testing.T) {
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	t.Run("fetches once and caches", func(t *testing.T) {
+		srv, fetches := jwksServer(t, map[string]any{"ed": edPublic, "rsa": &rsaKey.PublicKey}, nil)
+		keys := NewJWKS(srv.URL, time.Hour)
+
+		ed, err := keys.PublicKey("ed")
+		require.NoError(t, err)
+		assert.Equal(t, edPublic, ed)
+
+		r, err := keys.PublicKey("rsa")
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(r))
+
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("refetches an unknown kid at most once per interval", func(t *testing.T) {
+		published := map[string]any{"ed": edPublic}
+		srv, fetches := jwksServer(t, published, nil)
+		keys := NewJWKS(srv.URL, time.Hour)
+
+		_, err := keys.PublicKey("ed")
+		require.NoError(t, err)
+
+		_, err = keys.PublicKey("rotated")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		// The auth-service publishes a new key once the interval has passed
+		published["rotated"] = &rsaKey.PublicKey
+		keys.attemptedAt = time.Now().Add(-jwksMinRefetch)
+
+		_, err = keys.PublicKey("rotated")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("keeps stale keys when a fetch fails", func(t *testing.T) {
+		failing := &atomic.Bool{}
+		srv, fetches := jwksServer(t, map[string]any{"ed": edPublic}, failing)
+		keys := NewJWKS(srv.URL, time.Hour)
+
+		_, err := keys.PublicKey("ed")
+		require.NoError(t, err)
+
+		failing.Store(true)
+		keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+		keys.attemptedAt = time.Time{}
+
+		_, err = keys.PublicKey("ed")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+}
+
+func TestJWKSFetchesOutsideTheLock(t *testing.T) {
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() {
+		select {
+		case <-release:
+		default:
+			close(release)
+		}
+	})
+
+	keys := NewJWKS(srv.URL, time.Hour)
+	keys.keys = map[string]crypto.PublicKey{"ed": edPublic}
+	keys.fetchedAt = time.Now().Add(-2 * time.Hour)
+
+	// The first lookup of a stale key refetches, and the auth-service hangs
+	go keys.PublicKey("ed")
+	<-started
+
+	// Meanwhile cached keys are still served
+	looked := make(chan error, 1)
+	go func() {
+		_, err := keys.PublicKey("ed")
+		looked <- err
+	}()
+	select {
+	case err := <-looked:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("lookup of a cached key waited for the fetch")
+	}
+
+	// Unknown kids wait for the fetch under way instead of starting another
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := keys.PublicKey("rotated")
+		unknown <- err
+	}()
+	select {
+	case <-unknown:
+		t.Fatal("lookup of an unknown kid did not wait for the fetch")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	assert.ErrorIs(t, <-unknown, ErrUnknownKey)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := StaticKeys{"ed": edPublic, "rsa": &rsaKey.PublicKey}
+	userID := uuid.New()
+
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, &Claims{
+			UserID: userID.String(),
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	handler := AuthMiddleware(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := GetUserIDFromContext(r.Context())
+		w.Write([]byte(id.String()))
+	}))
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "EdDSA", token: sign(jwt.SigningMethodEdDSA, "ed", edPrivate), status: http.StatusOK},
+		{name: "RS256", token: sign(jwt.SigningMethodRS256, "rsa", rsaKey), status: http.StatusOK},
+		{name: "unknown kid", token: sign(jwt.SigningMethodEdDSA, "other", edPrivate), status: http.StatusUnauthorized},
+		{name: "algorithm does not match the key", token: sign(jwt.SigningMethodRS256, "ed", rsaKey), status: http.StatusUnauthorized},
+		{name: "HMAC with a public key as secret", token: sign(jwt.SigningMethodHS256, "ed", []byte(edPublic)), status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, userID.String(), rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("query token", func(t *testing.T) {
+		token := sign(jwt.SigningMethodEdDSA, "ed", edPrivate)
+		req := httptest.NewRequest("GET", "/?jwt="+token, nil)
+		req.Header.Set("Accept", "text/event-stream")
+
+		// Only the event stream middleware reads it, whatever the Accept header
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+		stream := EventStreamAuthMiddleware(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, _ := GetUserIDFromContext(r.Context())
+			w.Write([]byte(id.String()))
+		}))
+		rr = httptest.NewRecorder()
+		stream.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, userID.String(), rr.Body.String())
+	})
+	t.Run("stripped query token", func(t *testing.T) {
+		token := sign(jwt.SigningMethodEdDSA, "ed", edPrivate)
+		req := httptest.NewRequest("GET", "/?since=5&jwt="+token, nil)
+
+		// Whatever logs after StripQueryToken sees the URL without the token
+		var logged string
+		stream := StripQueryToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logged = r.RequestURI + " " + r.URL.String()
+			EventStreamAuthMiddleware(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, _ := GetUserIDFromContext(r.Context())
+				w.Write([]byte(id.String()))
+			})).ServeHTTP(w, r)
+		}))
+		rr := httptest.NewRecorder()
+		stream.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, userID.String(), rr.Body.String())
+		assert.Equal(t, "/?since=5 /?since=5", logged)
+		assert.Contains(t, req.RequestURI, token, "the caller's request is left alone")
+	})
+}
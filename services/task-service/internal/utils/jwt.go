@@ -2,6 +2,8 @@ package utils
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"fmt"
 	"net/http"
 	"strings"
@@ -18,64 +20,167 @@ type Claims struct {
 
 type contextKey string
 
-const UserIDKey contextKey = "user_id"
+const (
+	UserIDKey contextKey = "user_id"
+	EmailKey  contextKey = "email"
+
+	queryTokenKey contextKey = "query_token"
+)
 
 // GetUserIDFromToken extracts and validates JWT, returns userID
-func GetUserIDFromToken(r *http.Request, jwtSecret string) (uuid.UUID, error) {
-	// Get Authorization header
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		return uuid.Nil, fmt.Errorf("missing authorization header")
-	}
+func GetUserIDFromToken(r *http.Request, keys KeySource) (uuid.UUID, error) {
+	userID, _, err := getIdentityFromToken(r, keys, bearerToken)
+	return userID, err
+}
 
-	// Check Bearer prefix
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return uuid.Nil, fmt.Errorf("invalid authorization header format")
+// getIdentityFromToken extracts the JWT with tokenFrom and validates it,
+// returns the user ID and email it was issued for
+func getIdentityFromToken(r *http.Request, keys KeySource, tokenFrom func(*http.Request) (string, error)) (uuid.UUID, string, error) {
+	tokenString, err := tokenFrom(r)
+	if err != nil {
+		return uuid.Nil, "", err
 	}
 
-	tokenString := parts[1]
-
 	// Parse and validate token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.PublicKey(kid)
+		if err != nil {
+			return nil, err
 		}
-		return []byte(jwtSecret), nil
-	})
+
+		// Verify signing method matches the key
+		switch key.(type) {
+		case ed25519.PublicKey:
+			if token.Method != jwt.SigningMethodEdDSA {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		case *rsa.PublicKey:
+			if token.Method != jwt.SigningMethodRS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported key for kid %s", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
 
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid token: %w", err)
+		return uuid.Nil, "", fmt.Errorf("invalid token: %w", err)
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return uuid.Nil, fmt.Errorf("invalid token claims")
+		return uuid.Nil, "", fmt.Errorf("invalid token claims")
 	}
 
 	// Parse UUID from claims
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID in token")
+		return uuid.Nil, "", fmt.Errorf("invalid user ID in token")
 	}
 
-	return userID, nil
+	return userID, claims.Email, nil
 }
 
-// AuthMiddleware extracts user ID from JWT and adds to context
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// bearerToken returns the token from the Authorization header
+func bearerToken(r *http.Request) (string, error) {
+	// Get Authorization header
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", fmt.Errorf("missing authorization header")
+	}
+
+	// Check Bearer prefix
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", fmt.Errorf("invalid authorization header format")
+	}
+
+	return parts[1], nil
+}
+
+// bearerOrQueryToken returns the token from the Authorization header or,
+// failing that, the jwt query parameter, which Kong's jwt plugin accepts too
+func bearerOrQueryToken(r *http.Request) (string, error) {
+	if r.Header.Get("Authorization") == "" {
+		if token, ok := r.Context().Value(queryTokenKey).(string); ok {
+			return token, nil
+		}
+		if token := r.URL.Query().Get("jwt"); token != "" {
+			return token, nil
+		}
+	}
+	return bearerToken(r)
+}
+
+// StripQueryToken moves the jwt query parameter out of the request URL and
+// into its context, where EventStreamAuthMiddleware still finds it. Install
+// it ahead of the request logger so live tokens never reach the logs.
+func StripQueryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		token := query.Get("jwt")
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		query.Del("jwt")
+		stripped := *r.URL
+		stripped.RawQuery = query.Encode()
+
+		r = r.WithContext(context.WithValue(r.Context(), queryTokenKey, token))
+		r.URL = &stripped
+		r.RequestURI = stripped.RequestURI()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthMiddleware extracts user ID from JWT, verified with the key its kid
+// names in keys, and adds to context
+func AuthMiddleware(keys KeySource) func(http.Handler) http.Handler {
+	return authMiddleware(keys, bearerToken)
+}
+
+// EventStreamAuthMiddleware is AuthMiddleware for Server-Sent Events routes.
+// Browsers' EventSource cannot set headers, so it also accepts the token in
+// the jwt query parameter. Query strings end up in access logs and Referer
+// headers, so use it for nothing else, and strip the token with
+// StripQueryToken before anything logs the request.
+func EventStreamAuthMiddleware(keys KeySource) func(http.Handler) http.Handler {
+	return authMiddleware(keys, bearerOrQueryToken)
+}
+
+func authMiddleware(keys KeySource, tokenFrom func(*http.Request) (string, error)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userID, err := GetUserIDFromToken(r, jwtSecret)
+			userID, email, err := getIdentityFromToken(r, keys, tokenFrom)
 			if err != nil {
 				http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
 				return
 			}
 
-			// Add userID to request context
+			// Add userID and email to request context
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, EmailKey, email)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// GetUserIDFromContext returns the authenticated user ID stored by AuthMiddleware
+func GetUserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(UserIDKey).(uuid.UUID)
+	if !ok || userID == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// GetEmailFromContext returns the authenticated user's email stored by
+// AuthMiddleware, if their token carried one
+func GetEmailFromContext(ctx context.Context) (string, bool) {
+	email, ok := ctx.Value(EmailKey).(string)
+	return email, ok && email != ""
+}
@@ -0,0 +1,148 @@
+// Package webhook signs and sends webhook deliveries.
+//
+// Every request is a JSON POST carrying these headers:
+//
+//	X-Webhook-ID         the delivery ID; retries of a delivery reuse it
+//	X-Webhook-Event      the event type, e.g. task.completed
+//	X-Webhook-Timestamp  Unix seconds when the request was signed
+//	X-Webhook-Signature  "sha256=" + hex HMAC-SHA256 of "<timestamp>.<body>"
+//	                     keyed with the webhook's secret
+//
+// Receivers should recompute the signature, compare it in constant time and
+// reject timestamps more than a few minutes old.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// ErrPrivateAddress is returned for receivers on loopback, private or
+// link-local addresses when those are not allowed
+var ErrPrivateAddress = errors.New("webhook receivers on private networks are not allowed")
+
+// Sign returns the X-Webhook-Signature value for body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is valid for body sent at timestamp
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// NewSecret generates a random signing secret
+func NewSecret() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return "whsec_" + hex.EncodeToString(b)
+}
+
+// Request is one delivery attempt
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventType  string
+	Payload    []byte
+}
+
+// Sender POSTs deliveries to receivers
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewSender returns a Sender whose requests time out after timeout. Unless
+// allowPrivateNetworks is set, it refuses to connect to loopback, private and
+// link-local addresses, so webhooks cannot be used to probe the internal
+// network. The check runs on the resolved address, after any DNS lookup.
+func NewSender(timeout time.Duration, allowPrivateNetworks bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = refusePrivateAddresses
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect is treated as a failed delivery rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+func refusePrivateAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// Send signs and POSTs req. It returns the receiver's status code, or 0 if
+// there was no response, and an error unless the status was 2xx.
+func (s *Sender) Send(ctx context.Context, req Request) (int, error) {
+	timestamp := s.now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "task-service-webhooks/1.0")
+	httpReq.Header.Set(HeaderID, req.DeliveryID)
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Payload))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"task.created"}`)
+
+	signature := Sign(testSecret, 1700000000, body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.Equal(t, signature, Sign(testSecret, 1700000000, body))
+	assert.True(t, Verify(testSecret, 1700000000, body, signature))
+	assert.False(t, Verify("another-secret-value", 1700000000, body, signature))
+	assert.False(t, Verify(testSecret, 1700000001, body, signature))
+	assert.False(t, Verify(testSecret, 1700000000, []byte(`{"type":"task.deleted"}`), signature))
+}
+
+func TestNewSecret(t *testing.T) {
+	secret := NewSecret()
+
+	assert.Regexp(t, `^whsec_[0-9a-f]{48}$`, secret)
+	assert.NotEqual(t, secret, NewSecret())
+}
+
+func TestSenderSend(t *testing.T) {
+	payload := []byte(`{"id":"evt","type":"task.completed"}`)
+	req := Request{
+		Secret:     testSecret,
+		DeliveryID: "delivery-1",
+		EventType:  "task.completed",
+		Payload:    payload,
+	}
+
+	t.Run("signs the request", func(t *testing.T) {
+		var got *http.Request
+		var gotBody []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r
+			gotBody, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		req.URL = receiver.URL
+		status, err := NewSender(time.Second, true).Send(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		require.NotNil(t, got)
+		assert.Equal(t, http.MethodPost, got.Method)
+		assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+		assert.Equal(t, "delivery-1", got.Header.Get(HeaderID))
+		assert.Equal(t, "task.completed", got.Header.Get(HeaderEvent))
+		assert.Equal(t, payload, gotBody)
+
+		timestamp, err := strconv.ParseInt(got.Header.Get(HeaderTimestamp), 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), 5*time.Second)
+		assert.True(t, Verify(testSecret, timestamp, gotBody, got.Header.Get(HeaderSignature)))
+	})
+
+	t.Run("non-2xx responses are failures", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+		}))
+		defer receiver.Close()
+
+		req.URL = receiver.URL
+		status, err := NewSender(time.Second, true).Send(context.Background(), req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.EqualError(t, err, "receiver responded 503 Service Unavailable")
+	})
+
+	t.Run("redirects are not followed", func(t *testing.T) {
+		followed := false
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/moved" {
+				followed = true
+				return
+			}
+			http.Redirect(w, r, "/moved", http.StatusTemporaryRedirect)
+		}))
+		defer receiver.Close()
+
+		req.URL = receiver.URL
+		status, err := NewSender(time.Second, true).Send(context.Background(), req)
+
+		assert.Equal(t, http.StatusTemporaryRedirect, status)
+		assert.Error(t, err)
+		assert.False(t, followed)
+	})
+
+	t.Run("private addresses are refused", func(t *testing.T) {
+		called := false
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		defer receiver.Close()
+
+		req.URL = receiver.URL
+		status, err := NewSender(time.Second, false).Send(context.Background(), req)
+
+		assert.Equal(t, 0, status)
+		assert.True(t, errors.Is(err, ErrPrivateAddress))
+		assert.False(t, called)
+	})
+}
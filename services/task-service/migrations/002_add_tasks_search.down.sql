@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tasks.idx_tasks_search_vector;
+ALTER TABLE tasks.tasks DROP COLUMN IF EXISTS search_vector;
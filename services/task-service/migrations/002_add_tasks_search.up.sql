@@ -0,0 +1,9 @@
+-- Full-text search vector over title (weight A) and description (weight B)
+ALTER TABLE tasks.tasks
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+-- Indexes
+CREATE INDEX idx_tasks_search_vector ON tasks.tasks USING GIN (search_vector);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_labels_updated_at ON tasks.labels;
+DROP TABLE IF EXISTS tasks.task_labels;
+DROP TABLE IF EXISTS tasks.labels;
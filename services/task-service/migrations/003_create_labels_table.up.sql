@@ -0,0 +1,29 @@
+-- Create labels table
+CREATE TABLE tasks.labels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(7) NOT NULL DEFAULT '#6b7280',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_label_color CHECK (color ~ '^#[0-9a-fA-F]{6}$')
+);
+
+-- Create task/label join table
+CREATE TABLE tasks.task_labels (
+    task_id UUID NOT NULL REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    label_id UUID NOT NULL REFERENCES tasks.labels(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, label_id)
+);
+
+-- Indexes
+CREATE INDEX idx_labels_user_id ON tasks.labels(user_id);
+CREATE UNIQUE INDEX idx_labels_user_name ON tasks.labels(user_id, LOWER(name));
+CREATE INDEX idx_task_labels_label_id ON tasks.task_labels(label_id);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_labels_updated_at BEFORE UPDATE ON tasks.labels
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
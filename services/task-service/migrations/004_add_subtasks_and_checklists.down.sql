@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS update_checklist_items_updated_at ON tasks.checklist_items;
+DROP TABLE IF EXISTS tasks.checklist_items;
+DROP INDEX IF EXISTS tasks.idx_tasks_parent_id;
+ALTER TABLE tasks.tasks
+    DROP CONSTRAINT IF EXISTS chk_parent_not_self,
+    DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,23 @@
+-- Subtasks: a task may have a parent task. Deleting a parent deletes its subtasks.
+ALTER TABLE tasks.tasks
+    ADD COLUMN parent_id UUID REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    ADD CONSTRAINT chk_parent_not_self CHECK (parent_id <> id);
+
+-- Create checklist items table
+CREATE TABLE tasks.checklist_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    text VARCHAR(500) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Indexes
+CREATE INDEX idx_tasks_parent_id ON tasks.tasks(parent_id) WHERE parent_id IS NOT NULL;
+CREATE INDEX idx_checklist_items_task_position ON tasks.checklist_items(task_id, position);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_checklist_items_updated_at BEFORE UPDATE ON tasks.checklist_items
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS tasks.idx_tasks_project_id;
+ALTER TABLE tasks.tasks DROP COLUMN IF EXISTS project_id;
+DROP TRIGGER IF EXISTS update_projects_updated_at ON tasks.projects;
+DROP TABLE IF EXISTS tasks.projects;
@@ -0,0 +1,27 @@
+-- Create projects table
+CREATE TABLE tasks.projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    color VARCHAR(7) NOT NULL DEFAULT '#3b82f6',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_project_color CHECK (color ~ '^#[0-9a-fA-F]{6}$')
+);
+
+-- Tasks optionally belong to a project. Deleting a project keeps its tasks
+-- and moves them back to "no project".
+ALTER TABLE tasks.tasks
+    ADD COLUMN project_id UUID REFERENCES tasks.projects(id) ON DELETE SET NULL;
+
+-- Indexes
+CREATE INDEX idx_projects_user_id ON tasks.projects(user_id);
+CREATE INDEX idx_tasks_project_id ON tasks.tasks(project_id) WHERE project_id IS NOT NULL;
+
+-- Trigger for updated_at
+CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON tasks.projects
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
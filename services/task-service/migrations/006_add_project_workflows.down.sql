@@ -0,0 +1,24 @@
+-- Fold custom statuses back into todo/in-progress/done by category before
+-- the fixed constraint returns
+UPDATE tasks.tasks t
+SET status = CASE ws.category
+        WHEN 'done' THEN 'done'
+        WHEN 'active' THEN 'in-progress'
+        ELSE 'todo'
+    END
+FROM tasks.workflow_statuses ws
+WHERE ws.project_id = t.project_id
+  AND ws.key = t.status
+  AND t.status NOT IN ('todo', 'in-progress', 'done');
+
+UPDATE tasks.tasks
+SET status = 'todo'
+WHERE status NOT IN ('todo', 'in-progress', 'done');
+
+DROP TRIGGER IF EXISTS update_workflow_statuses_updated_at ON tasks.workflow_statuses;
+DROP TABLE IF EXISTS tasks.workflow_transitions;
+DROP TABLE IF EXISTS tasks.workflow_statuses;
+
+ALTER TABLE tasks.tasks
+    DROP CONSTRAINT IF EXISTS chk_status_key,
+    ADD CONSTRAINT chk_status CHECK (status IN ('todo', 'in-progress', 'done'));
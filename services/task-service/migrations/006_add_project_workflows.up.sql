@@ -0,0 +1,40 @@
+-- Statuses are now defined per project. Existing rows keep their
+-- todo/in-progress/done values, which form the default workflow used by
+-- tasks outside a project and by projects without statuses of their own.
+ALTER TABLE tasks.tasks
+    DROP CONSTRAINT IF EXISTS chk_status,
+    ADD CONSTRAINT chk_status_key CHECK (status ~ '^[a-z0-9]+(-[a-z0-9]+)*$');
+
+-- Create workflow statuses table
+CREATE TABLE tasks.workflow_statuses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES tasks.projects(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_workflow_status_key CHECK (key ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    CONSTRAINT chk_workflow_status_category CHECK (category IN ('not-started', 'active', 'done')),
+    CONSTRAINT uq_workflow_status_key UNIQUE (project_id, key)
+);
+
+-- Create allowed transitions table. A project without rows allows every move.
+CREATE TABLE tasks.workflow_transitions (
+    project_id UUID NOT NULL,
+    from_status VARCHAR(50) NOT NULL,
+    to_status VARCHAR(50) NOT NULL,
+    PRIMARY KEY (project_id, from_status, to_status),
+    FOREIGN KEY (project_id, from_status) REFERENCES tasks.workflow_statuses(project_id, key) ON DELETE CASCADE,
+    FOREIGN KEY (project_id, to_status) REFERENCES tasks.workflow_statuses(project_id, key) ON DELETE CASCADE
+);
+
+-- Indexes
+CREATE INDEX idx_workflow_statuses_project_position ON tasks.workflow_statuses(project_id, position);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_workflow_statuses_updated_at BEFORE UPDATE ON tasks.workflow_statuses
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
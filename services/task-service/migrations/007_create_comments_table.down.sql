@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_comments_updated_at ON tasks.comments;
+DROP TABLE IF EXISTS tasks.comments;
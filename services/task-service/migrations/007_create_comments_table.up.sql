@@ -0,0 +1,22 @@
+-- Create comments table
+CREATE TABLE tasks.comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    body TEXT NOT NULL,
+    edited_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_comment_body_length CHECK (char_length(body) <= 10000)
+);
+
+-- Indexes
+CREATE INDEX idx_comments_task_created ON tasks.comments(task_id, created_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_comments_deleted_at ON tasks.comments(deleted_at);
+
+-- Trigger for updated_at
+CREATE TRIGGER update_comments_updated_at BEFORE UPDATE ON tasks.comments
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
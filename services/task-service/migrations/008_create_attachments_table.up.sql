@@ -0,0 +1,19 @@
+-- Create attachments table. File contents live in the blob store under
+-- storage_key; rows are removed with their task and the service deletes the
+-- blobs.
+CREATE TABLE tasks.attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_attachment_size CHECK (size > 0)
+);
+
+-- Indexes
+CREATE INDEX idx_attachments_task_created ON tasks.attachments(task_id, created_at);
@@ -0,0 +1,18 @@
+-- Create task events table. There is deliberately no foreign key to
+-- tasks.tasks: a task's history, including its deletion, outlives the task.
+CREATE TABLE tasks.task_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    field VARCHAR(50),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_task_event_action CHECK (action IN ('created', 'updated', 'completed', 'deleted'))
+);
+
+-- Indexes
+CREATE INDEX idx_task_events_task_created ON tasks.task_events(task_id, created_at);
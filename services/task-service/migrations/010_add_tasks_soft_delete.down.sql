@@ -0,0 +1,10 @@
+-- Without soft delete, trashed tasks would come back; purge them instead
+DELETE FROM tasks.tasks WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS tasks.idx_tasks_deleted_at;
+ALTER TABLE tasks.tasks DROP COLUMN IF EXISTS deleted_at;
+
+DELETE FROM tasks.task_events WHERE action IN ('restored', 'purged');
+ALTER TABLE tasks.task_events DROP CONSTRAINT chk_task_event_action;
+ALTER TABLE tasks.task_events ADD CONSTRAINT chk_task_event_action
+    CHECK (action IN ('created', 'updated', 'completed', 'deleted'));
@@ -0,0 +1,11 @@
+-- Soft delete: deleted tasks move to the trash until they are restored or
+-- purged, either by the user or by the retention job.
+ALTER TABLE tasks.tasks ADD COLUMN deleted_at TIMESTAMP;
+
+-- Indexes
+CREATE INDEX idx_tasks_deleted_at ON tasks.tasks(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Task history gains restore and purge events
+ALTER TABLE tasks.task_events DROP CONSTRAINT chk_task_event_action;
+ALTER TABLE tasks.task_events ADD CONSTRAINT chk_task_event_action
+    CHECK (action IN ('created', 'updated', 'completed', 'deleted', 'restored', 'purged'));
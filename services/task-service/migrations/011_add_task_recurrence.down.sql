@@ -0,0 +1,6 @@
+ALTER TABLE tasks.tasks
+    DROP CONSTRAINT IF EXISTS chk_recurrence_occurrence,
+    DROP COLUMN IF EXISTS occurrence,
+    DROP COLUMN IF EXISTS recurrence_start,
+    DROP COLUMN IF EXISTS recurrence_tz,
+    DROP COLUMN IF EXISTS recurrence;
@@ -0,0 +1,10 @@
+-- Recurring tasks: completing an occurrence creates the next one from the
+-- RRULE, evaluated in recurrence_tz (an IANA timezone name, UTC if unset)
+-- from recurrence_start, the due date of the first occurrence.
+-- occurrence numbers the tasks of a series from 1; one-off tasks have 0.
+ALTER TABLE tasks.tasks
+    ADD COLUMN recurrence VARCHAR(500),
+    ADD COLUMN recurrence_tz VARCHAR(64),
+    ADD COLUMN recurrence_start TIMESTAMP,
+    ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT chk_recurrence_occurrence CHECK (occurrence >= 0);
@@ -0,0 +1,23 @@
+-- Due-date reminders. A reminder fires offset_minutes before its task is due
+-- and records the due date it fired for in sent_for, so moving the due date
+-- re-arms it. Scheduler replicas claim due reminders by setting lease_owner
+-- and lease_until; a failed delivery pushes lease_until out to the retry time.
+-- Reminders go to the owner's current address in auth.users, read when sent.
+CREATE TABLE tasks.reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks.tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    offset_minutes INTEGER NOT NULL,
+    sent_for TIMESTAMP,
+    sent_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    lease_owner VARCHAR(100),
+    lease_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_reminder_offset CHECK (offset_minutes BETWEEN 0 AND 525600),
+    CONSTRAINT uq_reminders_task_offset UNIQUE (task_id, offset_minutes)
+);
+
+-- Indexes
+CREATE INDEX idx_reminders_lease_until ON tasks.reminders(lease_until);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_webhook_deliveries_updated_at ON tasks.webhook_deliveries;
+DROP TRIGGER IF EXISTS update_webhooks_updated_at ON tasks.webhooks;
+DROP TABLE IF EXISTS tasks.webhook_deliveries;
+DROP TABLE IF EXISTS tasks.webhooks;
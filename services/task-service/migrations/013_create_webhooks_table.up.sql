@@ -0,0 +1,50 @@
+-- Outgoing webhooks. events is the comma-separated list of subscribed event
+-- types such as task.created,task.completed.
+CREATE TABLE tasks.webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events VARCHAR(500) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_webhook_url CHECK (url ~ '^https?://')
+);
+
+-- Delivery queue and log. Each event is queued once per subscribed webhook
+-- in the transaction that changed the task. Dispatchers claim pending
+-- deliveries whose next_attempt_at has passed by moving next_attempt_at to
+-- the end of their lease; a failed attempt moves it to the retry time.
+CREATE TABLE tasks.webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES tasks.webhooks(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    lease_owner VARCHAR(100),
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT chk_webhook_delivery_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+-- Indexes
+CREATE INDEX idx_webhooks_user_id ON tasks.webhooks(user_id);
+CREATE INDEX idx_webhook_deliveries_webhook_created ON tasks.webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_pending ON tasks.webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Triggers for updated_at
+CREATE TRIGGER update_webhooks_updated_at BEFORE UPDATE ON tasks.webhooks
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
+CREATE TRIGGER update_webhook_deliveries_updated_at BEFORE UPDATE ON tasks.webhook_deliveries
+    FOR EACH ROW EXECUTE FUNCTION update_tasks_updated_at_column();
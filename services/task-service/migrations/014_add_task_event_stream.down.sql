@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS number_task_event ON tasks.task_events;
+DROP FUNCTION IF EXISTS number_task_event();
+DROP INDEX IF EXISTS tasks.idx_task_events_unnumbered;
+DROP INDEX IF EXISTS tasks.idx_task_events_user_seq;
+ALTER TABLE tasks.task_events DROP COLUMN IF EXISTS seq;
+DROP SEQUENCE IF EXISTS tasks.task_events_seq;
@@ -0,0 +1,62 @@
+-- Live task updates. Task events get a sequence number, which streams use as
+-- the SSE event ID so a client can resume from the last event it saw.
+--
+-- Numbers are assigned when the inserting transaction commits, not when the
+-- event is inserted, and a user's transactions take them one at a time. A
+-- user's events therefore become visible in sequence order: once a stream
+-- has seen seq N, no event below N can still appear. Numbering at insert
+-- would let two transactions commit out of order, and the event with the
+-- lower number would be skipped by live streams and by resumes alike.
+CREATE SEQUENCE tasks.task_events_seq;
+ALTER TABLE tasks.task_events ADD COLUMN seq BIGINT;
+
+UPDATE tasks.task_events e
+SET seq = numbered.n
+FROM (SELECT id, row_number() OVER (ORDER BY created_at, id) AS n FROM tasks.task_events) numbered
+WHERE e.id = numbered.id;
+SELECT setval('tasks.task_events_seq', COALESCE(max(seq), 0) + 1, false) FROM tasks.task_events;
+
+-- Indexes
+CREATE UNIQUE INDEX idx_task_events_user_seq ON tasks.task_events(user_id, seq);
+-- Events of the committing transaction still waiting for a number
+CREATE INDEX idx_task_events_unnumbered ON tasks.task_events(user_id, task_id, action) WHERE seq IS NULL;
+
+-- Every replica LISTENs on task_events. Each transaction notifies once per
+-- task and action, with the number of the last such event; PostgreSQL
+-- delivers the notifications when, and only if, the transaction commits, and
+-- in commit order.
+CREATE OR REPLACE FUNCTION number_task_event()
+RETURNS TRIGGER AS $$
+DECLARE
+    event_seq BIGINT;
+BEGIN
+    -- Held until commit, so the user's next transaction numbers its events
+    -- only once this one's are visible
+    PERFORM pg_advisory_xact_lock(hashtextextended('task_events:' || NEW.user_id::text, 0));
+
+    UPDATE tasks.task_events
+    SET seq = nextval('tasks.task_events_seq')
+    WHERE id = NEW.id
+    RETURNING seq INTO event_seq;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM tasks.task_events
+        WHERE user_id = NEW.user_id AND task_id = NEW.task_id AND action = NEW.action AND seq IS NULL
+    ) THEN
+        PERFORM pg_notify('task_events', json_build_object(
+            'id', event_seq,
+            'user_id', NEW.user_id,
+            'task_id', NEW.task_id,
+            'action', NEW.action
+        )::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Deferred, so it runs at commit, after every statement of the transaction
+CREATE CONSTRAINT TRIGGER number_task_event
+    AFTER INSERT ON tasks.task_events
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION number_task_event();
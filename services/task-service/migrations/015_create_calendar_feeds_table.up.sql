@@ -0,0 +1,14 @@
+-- Secret-token iCalendar feeds, one per user. Only the SHA-256 of the token
+-- is stored; replacing the row rotates the token and deleting it revokes
+-- the feed.
+CREATE TABLE tasks.calendar_feeds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    last_accessed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT uq_calendar_feeds_user UNIQUE (user_id),
+    CONSTRAINT uq_calendar_feeds_token_hash UNIQUE (token_hash)
+);
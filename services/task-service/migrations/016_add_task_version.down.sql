@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS increment_task_version ON tasks.tasks;
+DROP FUNCTION IF EXISTS increment_task_version();
+ALTER TABLE tasks.tasks DROP COLUMN IF EXISTS version;
@@ -0,0 +1,14 @@
+-- Optimistic concurrency: every update of a task bumps its version, which
+-- the API exposes as the task's ETag and checks against If-Match.
+ALTER TABLE tasks.tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION increment_task_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER increment_task_version BEFORE UPDATE ON tasks.tasks
+    FOR EACH ROW EXECUTE FUNCTION increment_task_version();
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS rank_moved_task ON tasks.tasks;
+DROP FUNCTION IF EXISTS rank_moved_task();
+DROP TRIGGER IF EXISTS rank_new_task ON tasks.tasks;
+DROP FUNCTION IF EXISTS rank_new_task();
+DROP FUNCTION IF EXISTS next_task_rank(TEXT);
+DROP INDEX IF EXISTS tasks.idx_tasks_user_status_rank;
+ALTER TABLE tasks.tasks DROP COLUMN IF EXISTS rank;
+
+CREATE OR REPLACE FUNCTION increment_task_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
@@ -0,0 +1,93 @@
+-- Manual ordering within a board column. A rank is a base-36 fraction
+-- (digits 0-9a-z, never ending in 0) compared byte-wise, so a task can be
+-- placed between any two others by rewriting only its own rank. The service
+-- computes ranks for moves (internal/rank); new tasks go to the end of their
+-- column here.
+ALTER TABLE tasks.tasks ADD COLUMN rank TEXT COLLATE "C";
+
+-- Existing tasks keep their creation order, evenly spaced over 8 hex digits
+UPDATE tasks.tasks AS t
+SET rank = rtrim(lpad(to_hex(r.n * (4294967296 / (r.c + 1))), 8, '0'), '0')
+FROM (
+    SELECT id,
+           row_number() OVER (PARTITION BY user_id, status ORDER BY created_at, id) AS n,
+           count(*) OVER (PARTITION BY user_id, status) AS c
+    FROM tasks.tasks
+) AS r
+WHERE t.id = r.id;
+
+ALTER TABLE tasks.tasks ALTER COLUMN rank SET NOT NULL;
+
+CREATE INDEX idx_tasks_user_status_rank ON tasks.tasks(user_id, status, rank);
+
+-- A rank only places a task among its neighbours. Updates that change
+-- nothing else, such as respreading a column, leave the version and so the
+-- ETag alone; moves also set updated_at and still count as a change. This
+-- runs before update_tasks_updated_at, and search_vector is generated from
+-- columns compared here anyway.
+CREATE OR REPLACE FUNCTION increment_task_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - 'rank' - 'search_vector' = to_jsonb(OLD) - 'rank' - 'search_vector' THEN
+        RETURN NEW;
+    END IF;
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- next_task_rank returns a rank after r: the next first digit where there is
+-- one, so appending stays short. Mirrors rank.Between(r, "").
+CREATE OR REPLACE FUNCTION next_task_rank(r TEXT)
+RETURNS TEXT AS $$
+DECLARE
+    digits CONSTANT TEXT := '0123456789abcdefghijklmnopqrstuvwxyz';
+    prefix TEXT := '';
+BEGIN
+    LOOP
+        IF r IS NULL OR r = '' THEN
+            RETURN prefix || 'i';
+        END IF;
+        IF left(r, 1) <> 'z' THEN
+            RETURN prefix || substr(digits, strpos(digits, left(r, 1)) + 1, 1);
+        END IF;
+        prefix := prefix || 'z';
+        r := substr(r, 2);
+    END LOOP;
+END;
+$$ language 'plpgsql' IMMUTABLE;
+
+-- New tasks without a rank go to the end of their column, trashed tasks
+-- included so a restored task does not collide
+CREATE OR REPLACE FUNCTION rank_new_task()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.rank IS NULL THEN
+        SELECT next_task_rank(MAX(rank)) INTO NEW.rank
+        FROM tasks.tasks
+        WHERE user_id = NEW.user_id AND status = NEW.status;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER rank_new_task BEFORE INSERT ON tasks.tasks
+    FOR EACH ROW EXECUTE FUNCTION rank_new_task();
+
+-- Tasks that change status go to the end of their new column, unless the
+-- update gives them a rank of its own. Rows changed earlier by the same
+-- statement are visible here, so tasks moved together keep distinct ranks.
+CREATE OR REPLACE FUNCTION rank_moved_task()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status <> OLD.status AND NEW.rank = OLD.rank THEN
+        SELECT next_task_rank(MAX(rank)) INTO NEW.rank
+        FROM tasks.tasks
+        WHERE user_id = NEW.user_id AND status = NEW.status;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER rank_moved_task BEFORE UPDATE OF status ON tasks.tasks
+    FOR EACH ROW EXECUTE FUNCTION rank_moved_task();
@@ -113,7 +113,7 @@ resource "aws_iam_role_policy" "ecs_secrets_policy" {
         Resource = [
           aws_secretsmanager_secret.db_password.arn,
           aws_secretsmanager_secret.kong_db_password.arn,
-          aws_secretsmanager_secret.jwt_secret.arn
+          aws_secretsmanager_secret.jwt_key_encryption_key.arn
         ]
       }
     ]
@@ -199,13 +199,13 @@ resource "aws_secretsmanager_secret_version" "kong_db_password" {
   secret_string = var.kong_db_password
 }
 
-resource "aws_secretsmanager_secret" "jwt_secret" {
-  name = "task-management-jwt-secret"
+resource "aws_secretsmanager_secret" "jwt_key_encryption_key" {
+  name = "task-management-jwt-key-encryption-key"
 }
 
-resource "aws_secretsmanager_secret_version" "jwt_secret" {
-  secret_id     = aws_secretsmanager_secret.jwt_secret.id
-  secret_string = var.jwt_secret
+resource "aws_secretsmanager_secret_version" "jwt_key_encryption_key" {
+  secret_id     = aws_secretsmanager_secret.jwt_key_encryption_key.id
+  secret_string = var.jwt_key_encryption_key
 }
 
 # Service Discovery Namespace
@@ -352,9 +352,15 @@ resource "aws_ecs_task_definition" "kong" {
           name  = "KONG_PG_DATABASE"
           value = "kong"
         },
+        {
+          # Query strings are left out of the proxy log: the task stream takes
+          # its token in one
+          name  = "KONG_NGINX_HTTP_LOG_FORMAT"
+          value = "no_query '$remote_addr - $remote_user [$time_local] \"$request_method $uri $server_protocol\" $status $body_bytes_sent \"$http_referer\" \"$http_user_agent\"'"
+        },
         {
           name  = "KONG_PROXY_ACCESS_LOG"
-          value = "/dev/stdout"
+          value = "/dev/stdout no_query"
         },
         {
           name  = "KONG_ADMIN_ACCESS_LOG"
@@ -440,11 +446,11 @@ resource "aws_ecs_task_definition" "auth_service" {
           value = "8080"
         },
         {
-          name  = "ACCESS_TOKEN_EXPIRY"
+          name  = "JWT_ACCESS_TOKEN_EXPIRY"
           value = "15m"
         },
         {
-          name  = "REFRESH_TOKEN_EXPIRY"
+          name  = "JWT_REFRESH_TOKEN_EXPIRY"
           value = "168h"
         }
       ]
@@ -455,8 +461,8 @@ resource "aws_ecs_task_definition" "auth_service" {
           valueFrom = aws_secretsmanager_secret.db_password.arn
         },
         {
-          name      = "JWT_SECRET"
-          valueFrom = aws_secretsmanager_secret.jwt_secret.arn
+          name      = "JWT_KEY_ENCRYPTION_KEY"
+          valueFrom = aws_secretsmanager_secret.jwt_key_encryption_key.arn
         }
       ]
 
@@ -517,6 +523,10 @@ resource "aws_ecs_task_definition" "task_service" {
         {
           name  = "PORT"
           value = "8081"
+        },
+        {
+          name  = "JWKS_URL"
+          value = "http://${aws_service_discovery_service.auth_service.name}.${aws_service_discovery_private_dns_namespace.main.name}:8080/.well-known/jwks.json"
         }
       ]
 
@@ -524,10 +534,6 @@ resource "aws_ecs_task_definition" "task_service" {
         {
           name      = "DB_PASSWORD"
           valueFrom = aws_secretsmanager_secret.db_password.arn
-        },
-        {
-          name      = "JWT_SECRET"
-          valueFrom = aws_secretsmanager_secret.jwt_secret.arn
         }
       ]
 
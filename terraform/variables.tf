@@ -15,8 +15,8 @@ variable "kong_db_password" {
   sensitive   = true
 }
 
-variable "jwt_secret" {
-  description = "JWT secret key for signing tokens"
+variable "jwt_key_encryption_key" {
+  description = "Base64 32-byte key that encrypts JWT signing keys at rest (openssl rand -base64 32)"
   type        = string
   sensitive   = true
-}
\ No newline at end of file
+}